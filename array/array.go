@@ -0,0 +1,242 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package array implements an immutable, random access array of UNUM-64
+// encoded unsigned integers.
+//
+// Values are stored back to back as UNUM-64 images. Locating value i in
+// such a stream requires walking i tags, so the Array also records the
+// byte offset of every k-th value (the sample interval). Get(i) walks at
+// most k-1 tags from the nearest preceding sample.
+package array
+
+import (
+	"math"
+	"unsafe"
+	"unum"
+)
+
+// default number of values between sampled offsets
+const DefaultSampleInterval = 32
+
+// maximum length of the encoded values
+const MaxDataSize uint64 = math.MaxUint32
+
+// Array is an immutable UNUM-64 encoded array of unsigned integers.
+type Array struct {
+	n       int
+	k       int
+	data    []byte   // UNUM-64 images
+	samples []uint32 // offset in data of values 0, k, 2k, ..
+}
+
+// Returns a new Array of values vs, sampling every k-th offset. If k <= 0,
+// DefaultSampleInterval is used.
+//
+// On error returns (nil, e) where e is:
+//    unum.ErrorMaxValue  -- invalid arg vs : value >= 2^62, or encoded
+//                           length exceeds MaxDataSize
+func New(vs []uint64, k int) (*Array, error) {
+	if k <= 0 {
+		k = DefaultSampleInterval
+	}
+	size := 0
+	for _, v := range vs {
+		n := unum.SizeUnum64(v)
+		if n == 0 {
+			return nil, unum.ErrorMaxValue
+		}
+		size += n
+	}
+	if uint64(size) > MaxDataSize {
+		return nil, unum.ErrorMaxValue
+	}
+
+	a := &Array{
+		n:       len(vs),
+		k:       k,
+		data:    make([]byte, size),
+		samples: make([]uint32, 0, (len(vs)+k-1)/k),
+	}
+	off := 0
+	for i, v := range vs {
+		if i%k == 0 {
+			a.samples = append(a.samples, uint32(off))
+		}
+		n, _ := unum.EncodeUnum64(a.data[off:], v)
+		off += n
+	}
+	return a, nil
+}
+
+// Returns the number of values.
+func (a *Array) Len() int {
+	return a.n
+}
+
+// Returns the sample interval.
+func (a *Array) SampleInterval() int {
+	return a.k
+}
+
+// Returns the value at index i. Get panics if i is out of range.
+func (a *Array) Get(i int) uint64 {
+	if i < 0 || i >= a.n {
+		panic("array: index out of range")
+	}
+	off := int(a.samples[i/a.k])
+	for j := i % a.k; j > 0; j-- {
+		off += 1 << (a.data[off] >> 6)
+	}
+	v, _, _ := unum.DecodeUnum64(a.data[off:])
+	return v
+}
+
+// Calls f for each value with index in [start, end), in order, until f
+// returns false. Range panics if start or end is out of range.
+func (a *Array) Range(start, end int, f func(i int, v uint64) bool) {
+	if start < 0 || end > a.n || start > end {
+		panic("array: range out of range")
+	}
+	if start == end {
+		return
+	}
+	off := int(a.samples[start/a.k])
+	for j := start % a.k; j > 0; j-- {
+		off += 1 << (a.data[off] >> 6)
+	}
+	for i := start; i < end; i++ {
+		v, n, _ := unum.DecodeUnum64(a.data[off:])
+		if !f(i, v) {
+			return
+		}
+		off += n
+	}
+}
+
+// Returns all values, appended to dst.
+func (a *Array) Values(dst []uint64) []uint64 {
+	a.Range(0, a.n, func(_ int, v uint64) bool {
+		dst = append(dst, v)
+		return true
+	})
+	return dst
+}
+
+// Stats reports the memory use of an Array.
+type Stats struct {
+	Len        int     // number of values
+	DataBytes  int     // encoded values
+	IndexBytes int     // sampled offsets
+	Bytes      int     // total, including the Array header
+	PlainBytes int     // memory use of the values as []uint64
+	Ratio      float64 // Bytes / PlainBytes
+}
+
+// size of the Array struct and of a slice header
+const (
+	headerSize      = int(unsafe.Sizeof(Array{}))
+	sliceHeaderSize = int(unsafe.Sizeof([]uint64(nil)))
+)
+
+// Returns the memory use of the Array compared to the equivalent []uint64.
+func (a *Array) Stats() Stats {
+	s := Stats{
+		Len:        a.n,
+		DataBytes:  len(a.data),
+		IndexBytes: 4 * len(a.samples),
+		PlainBytes: 8*a.n + sliceHeaderSize,
+	}
+	s.Bytes = s.DataBytes + s.IndexBytes + headerSize
+	s.Ratio = float64(s.Bytes) / float64(s.PlainBytes)
+	return s
+}
+
+// Returns the binary image of the Array:
+//
+//      UNUM-64    number of values n
+//      UNUM-32    sample interval k
+//      UNUM-64    length of encoded values
+//      bytes      encoded values
+//
+// Sampled offsets are not stored; they are recovered by UnmarshalBinary
+// as it verifies the encoded values.
+func (a *Array) MarshalBinary() ([]byte, error) {
+	if uint64(a.k) >= uint64(unum.Unum32ValueBound) {
+		return nil, unum.ErrorMaxValue
+	}
+	b := make([]byte, 2*unum.Unum64Size+unum.Unum32Size+len(a.data))
+	off, _ := unum.EncodeUnum64(b, uint64(a.n))
+	n, _ := unum.EncodeUnum32(b[off:], uint32(a.k))
+	off += n
+	n, _ = unum.EncodeUnum64(b[off:], uint64(len(a.data)))
+	off += n
+	off += copy(b[off:], a.data)
+	return b[:off], nil
+}
+
+// Sets the Array to the image b produced by MarshalBinary. The Array
+// aliases b.
+//
+// On error returns e:
+//    unum.ErrorInvalidBuffer  -- invalid arg b : non-conformant image
+func (a *Array) UnmarshalBinary(b []byte) error {
+	n, n0, e := unum.DecodeUnum64(b)
+	if e != nil {
+		return unum.ErrorInvalidBuffer
+	}
+	off := n0
+	k, n0, e := unum.DecodeUnum32(b[off:])
+	if e != nil || k == 0 {
+		return unum.ErrorInvalidBuffer
+	}
+	off += n0
+	size, n0, e := unum.DecodeUnum64(b[off:])
+	if e != nil {
+		return unum.ErrorInvalidBuffer
+	}
+	off += n0
+	if size > MaxDataSize || uint64(len(b)-off) != size || n > size {
+		return unum.ErrorInvalidBuffer
+	}
+	data := b[off:]
+
+	// walk the tags, verifying the count and recording samples
+	samples := make([]uint32, 0, (n+uint64(k)-1)/uint64(k))
+	off = 0
+	for i := 0; i < int(n); i++ {
+		if off >= len(data) {
+			return unum.ErrorInvalidBuffer
+		}
+		if i%int(k) == 0 {
+			samples = append(samples, uint32(off))
+		}
+		off += 1 << (data[off] >> 6)
+	}
+	if off != len(data) {
+		return unum.ErrorInvalidBuffer
+	}
+
+	*a = Array{n: int(n), k: int(k), data: data, samples: samples}
+	return nil
+}
@@ -0,0 +1,169 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package array_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"unum"
+	"unum/array"
+)
+
+func testValues(n int) []uint64 {
+	r := rand.New(rand.NewSource(int64(n)))
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = uint64(r.Int63n(int64(unum.Unum64ValueBound))) >> uint(r.Intn(62))
+	}
+	return vs
+}
+
+func TestGet(t *testing.T) {
+	for _, n := range []int{0, 1, 31, 32, 33, 1000} {
+		vs := testValues(n)
+		for _, k := range []int{0, 1, 7, 32, 2000} {
+			a, e := array.New(vs, k)
+			if e != nil {
+				t.Fatalf("error creating array - e:%s\n", e.Error())
+			}
+			if a.Len() != n {
+				t.Errorf("Len - expected:%d have:%d\n", n, a.Len())
+			}
+			for i, v := range vs {
+				if v0 := a.Get(i); v0 != v {
+					t.Errorf("Get(%d) - n:%d k:%d - expected:%d have:%d\n", i, n, k, v, v0)
+				}
+			}
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	vs := testValues(500)
+	a, _ := array.New(vs, 16)
+	for _, r := range [][2]int{{0, 500}, {0, 0}, {17, 17}, {15, 200}, {499, 500}, {32, 48}} {
+		var have []uint64
+		a.Range(r[0], r[1], func(i int, v uint64) bool {
+			if i != r[0]+len(have) {
+				t.Errorf("unexpected index - have:%d\n", i)
+			}
+			have = append(have, v)
+			return true
+		})
+		if len(have) != r[1]-r[0] || (len(have) > 0 && !reflect.DeepEqual(have, vs[r[0]:r[1]])) {
+			t.Errorf("Range(%d, %d) - values differ\n", r[0], r[1])
+		}
+	}
+
+	var count int
+	a.Range(0, 500, func(i int, v uint64) bool {
+		count++
+		return i < 9
+	})
+	if count != 10 {
+		t.Errorf("expected early stop after 10 values - have:%d\n", count)
+	}
+	if !reflect.DeepEqual(a.Values(nil), vs) {
+		t.Errorf("Values - values differ\n")
+	}
+}
+
+func TestGetPanics(t *testing.T) {
+	a, _ := array.New(testValues(10), 0)
+	for _, i := range []int{-1, 10} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic - i:%d\n", i)
+				}
+			}()
+			a.Get(i)
+		}()
+	}
+}
+
+func TestBinary(t *testing.T) {
+	vs := testValues(1000)
+	a, _ := array.New(vs, 10)
+	b, e := a.MarshalBinary()
+	if e != nil {
+		t.Fatalf("error marshaling - e:%s\n", e.Error())
+	}
+	var a0 array.Array
+	if e := a0.UnmarshalBinary(b); e != nil {
+		t.Fatalf("error unmarshaling - e:%s\n", e.Error())
+	}
+	if a0.Len() != a.Len() || a0.SampleInterval() != 10 || !reflect.DeepEqual(a0.Values(nil), vs) {
+		t.Errorf("BUG - unmarshaled array differs\n")
+	}
+	for i := range vs {
+		if a0.Get(i) != vs[i] {
+			t.Fatalf("Get(%d) - expected:%d have:%d\n", i, vs[i], a0.Get(i))
+		}
+	}
+
+	// truncated and corrupt images
+	for i := 0; i < len(b); i++ {
+		if e := a0.UnmarshalBinary(b[:i]); e != unum.ErrorInvalidBuffer {
+			t.Fatalf("expected ErrorInvalidBuffer - len:%d have:%v\n", i, e)
+		}
+	}
+	c := append([]byte(nil), b...)
+	c[len(c)-1] = 0xc0 // an 8 byte tag where a shorter image ends
+	if e := a0.UnmarshalBinary(c); e == nil && reflect.DeepEqual(a0.Values(nil), vs) {
+		t.Errorf("undetected corruption\n")
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, e := array.New([]uint64{1, unum.Unum64ValueBound}, 0); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+}
+
+func TestStats(t *testing.T) {
+	vs := make([]uint64, 100000)
+	for i := range vs {
+		vs[i] = uint64(i % 1000)
+	}
+	a, _ := array.New(vs, 32)
+	s := a.Stats()
+	if s.Len != len(vs) || s.PlainBytes < 8*len(vs) || s.IndexBytes != 4*((len(vs)+31)/32) {
+		t.Errorf("unexpected stats - %+v\n", s)
+	}
+	// values < 2^14: at most 2 bytes per value plus index
+	if s.Ratio > 0.3 {
+		t.Errorf("expected ratio < 0.3 - %+v\n", s)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	vs := testValues(1 << 16)
+	a, _ := array.New(vs, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.Get(i & (1<<16 - 1))
+	}
+}
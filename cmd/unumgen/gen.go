@@ -0,0 +1,440 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// import path of the unum package used by the generated code
+const unumPkg = "unum"
+
+type kind int
+
+const (
+	kindUint kind = iota
+	kindBool
+	kindString
+	kindBytes
+	kindStruct
+)
+
+// field describes one encoded struct field.
+type field struct {
+	name  string // field name (selector)
+	typ   string // field type as spelled in the source
+	kind  kind   //
+	bits  int    // kindUint: bit size of the underlying type; 0 for uint and uintptr
+	width int    // kindUint: unum encoding { 16, 32, 64 }
+}
+
+type structType struct {
+	name   string
+	fields []field
+}
+
+type generator struct {
+	buf       bytes.Buffer
+	decls     map[string]ast.Expr // package level type declarations
+	requested map[string]bool     // types named in -type
+	warnings  []string
+}
+
+func (g *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// Generate parses the Go package in directory dir and returns the gofmt'd
+// source of the unum codec methods for the named struct types. args are
+// recorded in the header of the generated file. The warnings name the
+// untagged fields with values the selected encoding cannot represent.
+func Generate(dir string, typeNames []string, args []string) (src []byte, warnings []string, e error) {
+	pkg, e := build.ImportDir(dir, 0)
+	if e != nil {
+		return nil, nil, fmt.Errorf("loading package in %s: %s", dir, e)
+	}
+
+	g := &generator{
+		decls:     make(map[string]ast.Expr),
+		requested: make(map[string]bool),
+	}
+	fset := token.NewFileSet()
+	for _, name := range pkg.GoFiles {
+		f, e := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if e != nil {
+			return nil, nil, e
+		}
+		for _, d := range f.Decls {
+			gd, ok := d.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				g.decls[ts.Name.Name] = ts.Type
+			}
+		}
+	}
+
+	for _, name := range typeNames {
+		g.requested[name] = true
+	}
+	var structs []*structType
+	for _, name := range typeNames {
+		st, e := g.parseStruct(name)
+		if e != nil {
+			return nil, nil, e
+		}
+		structs = append(structs, st)
+	}
+
+	g.printf("// Code generated by \"unumgen %s\"; DO NOT EDIT.\n\n", strings.Join(args, " "))
+	g.printf("package %s\n\n", pkg.Name)
+	g.printf("import %q\n", unumPkg)
+	for _, st := range structs {
+		g.generate(st)
+	}
+
+	src, e = format.Source(g.buf.Bytes())
+	if e != nil {
+		return nil, nil, fmt.Errorf("BUG - generated invalid source: %s", e)
+	}
+	return src, g.warnings, nil
+}
+
+func (g *generator) parseStruct(name string) (*structType, error) {
+	expr, ok := g.decls[name]
+	if !ok {
+		return nil, fmt.Errorf("type %s not declared", name)
+	}
+	stexpr, ok := expr.(*ast.StructType)
+	if !ok {
+		return nil, fmt.Errorf("type %s is not a struct type", name)
+	}
+
+	st := &structType{name: name}
+	for _, f := range stexpr.Fields.List {
+		tag := ""
+		if f.Tag != nil {
+			s, e := strconv.Unquote(f.Tag.Value)
+			if e != nil {
+				return nil, fmt.Errorf("%s: invalid tag %s", name, f.Tag.Value)
+			}
+			tag = reflect.StructTag(s).Get("unum")
+		}
+		if tag == "-" {
+			continue
+		}
+
+		names := f.Names
+		if len(names) == 0 {
+			// embedded field
+			id, ok := f.Type.(*ast.Ident)
+			if !ok {
+				return nil, fmt.Errorf("%s: unsupported embedded field", name)
+			}
+			names = []*ast.Ident{id}
+		}
+		for _, id := range names {
+			if id.Name == "_" {
+				continue
+			}
+			fd, e := g.parseField(id.Name, f.Type, tag)
+			if e != nil {
+				return nil, fmt.Errorf("%s.%s: %s", name, id.Name, e)
+			}
+			if tag == "" && fd.kind == kindUint && !fd.fits() {
+				g.warnings = append(g.warnings, fmt.Sprintf("%s.%s: %s values >= 2^%d do not encode as UNUM-%d (tag `unum:\"%d\"` to accept)",
+					name, id.Name, fd.typ, valueBits[fd.width], fd.width, fd.width))
+			}
+			st.fields = append(st.fields, fd)
+		}
+	}
+	return st, nil
+}
+
+func (g *generator) parseField(name string, expr ast.Expr, tag string) (field, error) {
+	fd := field{name: name, typ: typeString(expr)}
+	if fd.typ == "" {
+		return fd, fmt.Errorf("unsupported type")
+	}
+	if id, ok := expr.(*ast.Ident); ok && g.requested[id.Name] {
+		fd.kind = kindStruct
+	} else if e := g.resolve(&fd, expr, 0); e != nil {
+		return fd, e
+	}
+
+	switch {
+	case tag == "":
+	case fd.kind != kindUint:
+		return fd, fmt.Errorf("tag %q applies to unsigned fields only", tag)
+	case tag == "16":
+		fd.width = 16
+	case tag == "32":
+		fd.width = 32
+	case tag == "64":
+		fd.width = 64
+	default:
+		return fd, fmt.Errorf("invalid tag %q", tag)
+	}
+	return fd, nil
+}
+
+// resolve sets the kind (and bit size) of fd per the underlying type of expr.
+func (g *generator) resolve(fd *field, expr ast.Expr, depth int) error {
+	if depth > len(g.decls) {
+		return fmt.Errorf("invalid recursive type")
+	}
+	switch t := expr.(type) {
+	case *ast.Ident:
+		switch t.Name {
+		case "uint8", "byte":
+			fd.kind, fd.bits, fd.width = kindUint, 8, 16
+		case "uint16":
+			fd.kind, fd.bits, fd.width = kindUint, 16, 32
+		case "uint32":
+			fd.kind, fd.bits, fd.width = kindUint, 32, 64
+		case "uint64":
+			fd.kind, fd.bits, fd.width = kindUint, 64, 64
+		case "uint", "uintptr":
+			fd.kind, fd.bits, fd.width = kindUint, 0, 64
+		case "bool":
+			fd.kind = kindBool
+		case "string":
+			fd.kind = kindString
+		default:
+			underlying, ok := g.decls[t.Name]
+			if !ok {
+				return fmt.Errorf("unsupported type %s", t.Name)
+			}
+			if _, ok := underlying.(*ast.StructType); ok {
+				return fmt.Errorf("struct type %s must also be named in -type", t.Name)
+			}
+			return g.resolve(fd, underlying, depth+1)
+		}
+		return nil
+	case *ast.ArrayType:
+		if id, ok := t.Elt.(*ast.Ident); ok && t.Len == nil && (id.Name == "byte" || id.Name == "uint8") {
+			fd.kind = kindBytes
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported type %s", typeString(expr))
+}
+
+// typeString returns the source spelling of the (simple) type expression expr,
+// or "" if expr is not supported.
+func typeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.ArrayType:
+		if t.Len == nil {
+			if elt := typeString(t.Elt); elt != "" {
+				return "[]" + elt
+			}
+		}
+	}
+	return ""
+}
+
+// valueBits is the number of value bits of each unum encoding.
+var valueBits = map[int]int{16: 15, 32: 30, 64: 62}
+
+// fits returns true if the encoding of the field represents every value of
+// the field's type.
+func (fd *field) fits() bool {
+	return fd.bits > 0 && fd.bits <= valueBits[fd.width]
+}
+
+// needsEncodeCheck returns true if a value of the field's type may be
+// truncated by conversion to the encoding's value type.
+func (fd *field) needsEncodeCheck() bool {
+	return fd.width < 64 && (fd.bits == 0 || fd.bits > fd.width)
+}
+
+// needsDecodeCheck returns true if a decoded value may not be representable
+// by the field's type.
+func (fd *field) needsDecodeCheck() bool {
+	return (fd.bits == 0 && fd.width == 64) || (fd.bits > 0 && fd.bits < fd.width)
+}
+
+func (g *generator) generate(st *structType) {
+	g.generateSize(st)
+	g.generateMarshal(st)
+	g.generateUnmarshal(st)
+}
+
+func (g *generator) generateSize(st *structType) {
+	g.printf("\n// SizeUnum returns the length of the unum image of x.\n")
+	g.printf("func (x *%s) SizeUnum() (n int) {\n", st.name)
+	for _, fd := range st.fields {
+		switch fd.kind {
+		case kindUint:
+			g.printf("n += unum.SizeUnum%d(uint%d(x.%s))\n", fd.width, fd.width, fd.name)
+		case kindBool:
+			g.printf("n++ // %s\n", fd.name)
+		case kindString, kindBytes:
+			g.printf("n += unum.SizeUnum32(uint32(len(x.%s))) + len(x.%s)\n", fd.name, fd.name)
+		case kindStruct:
+			g.printf("{\n")
+			g.printf("m := x.%s.SizeUnum()\n", fd.name)
+			g.printf("n += unum.SizeUnum32(uint32(m)) + m\n")
+			g.printf("}\n")
+		}
+	}
+	g.printf("return n\n")
+	g.printf("}\n")
+}
+
+func (g *generator) generateMarshal(st *structType) {
+	g.printf("\n// MarshalUnum returns the unum image of x.\n")
+	g.printf("func (x *%s) MarshalUnum() ([]byte, error) {\n", st.name)
+	g.printf("b := make([]byte, x.SizeUnum())\n")
+	g.printf("n, e := x.marshalUnum(b)\n")
+	g.printf("if e != nil {\n return nil, e\n }\n")
+	g.printf("return b[:n], nil\n")
+	g.printf("}\n")
+
+	g.printf("\nfunc (x *%s) marshalUnum(b []byte) (n int, e error) {\n", st.name)
+	for _, fd := range st.fields {
+		if fd.kind != kindBool {
+			g.printf("var n0 int\n")
+			break
+		}
+	}
+	for _, fd := range st.fields {
+		switch fd.kind {
+		case kindUint:
+			if fd.needsEncodeCheck() {
+				g.printf("if uint64(x.%s) >= uint64(unum.Unum%dValueBound) {\n", fd.name, fd.width)
+				g.printf("return 0, unum.ErrorMaxValue\n")
+				g.printf("}\n")
+			}
+			g.printf("if n0, e = unum.EncodeUnum%d(b[n:], uint%d(x.%s)); e != nil {\n", fd.width, fd.width, fd.name)
+			g.printf("return 0, e\n")
+			g.printf("}\n")
+			g.printf("n += n0\n")
+		case kindBool:
+			g.printf("if len(b) < n+1 {\n return 0, unum.ErrorBufferOverflow\n }\n")
+			g.printf("b[n] = 0\n")
+			g.printf("if x.%s {\n b[n] = 1\n }\n", fd.name)
+			g.printf("n++\n")
+		case kindString, kindBytes:
+			g.printf("if uint64(len(x.%s)) >= uint64(unum.Unum32ValueBound) {\n", fd.name)
+			g.printf("return 0, unum.ErrorMaxValue\n")
+			g.printf("}\n")
+			g.printf("if n0, e = unum.EncodeUnum32(b[n:], uint32(len(x.%s))); e != nil {\n", fd.name)
+			g.printf("return 0, e\n")
+			g.printf("}\n")
+			g.printf("n += n0\n")
+			g.printf("if len(b) < n+len(x.%s) {\n return 0, unum.ErrorBufferOverflow\n }\n", fd.name)
+			g.printf("n += copy(b[n:], x.%s)\n", fd.name)
+		case kindStruct:
+			g.printf("{\n")
+			g.printf("m := x.%s.SizeUnum()\n", fd.name)
+			g.printf("if n0, e = unum.EncodeUnum32(b[n:], uint32(m)); e != nil {\n")
+			g.printf("return 0, e\n")
+			g.printf("}\n")
+			g.printf("n += n0\n")
+			g.printf("if len(b) < n+m {\n return 0, unum.ErrorBufferOverflow\n }\n")
+			g.printf("if n0, e = x.%s.marshalUnum(b[n : n+m]); e != nil {\n", fd.name)
+			g.printf("return 0, e\n")
+			g.printf("}\n")
+			g.printf("n += n0\n")
+			g.printf("}\n")
+		}
+	}
+	g.printf("return n, nil\n")
+	g.printf("}\n")
+}
+
+func (g *generator) generateUnmarshal(st *structType) {
+	g.printf("\n// UnmarshalUnum decodes the unum image b into x.\n")
+	g.printf("// The entire buffer must be consumed.\n")
+	g.printf("func (x *%s) UnmarshalUnum(b []byte) error {\n", st.name)
+	g.printf("n, e := x.unmarshalUnum(b)\n")
+	g.printf("if e != nil {\n return e\n }\n")
+	g.printf("if n != len(b) {\n return unum.ErrorInvalidBuffer\n }\n")
+	g.printf("return nil\n")
+	g.printf("}\n")
+
+	g.printf("\nfunc (x *%s) unmarshalUnum(b []byte) (n int, e error) {\n", st.name)
+	for _, fd := range st.fields {
+		switch fd.kind {
+		case kindUint:
+			g.printf("{\n")
+			g.printf("v, n0, e := unum.DecodeUnum%d(b[n:])\n", fd.width)
+			g.printf("if e != nil {\n return 0, e\n }\n")
+			if fd.needsDecodeCheck() {
+				g.printf("if uint%d(%s(v)) != v {\n", fd.width, fd.typ)
+				g.printf("return 0, unum.ErrorInvalidBuffer\n")
+				g.printf("}\n")
+			}
+			g.printf("x.%s = %s(v)\n", fd.name, fd.typ)
+			g.printf("n += n0\n")
+			g.printf("}\n")
+		case kindBool:
+			g.printf("if len(b) < n+1 {\n return 0, unum.ErrorBufferEOF\n }\n")
+			g.printf("switch b[n] {\n")
+			g.printf("case 0:\n x.%s = false\n", fd.name)
+			g.printf("case 1:\n x.%s = true\n", fd.name)
+			g.printf("default:\n return 0, unum.ErrorInvalidBuffer\n")
+			g.printf("}\n")
+			g.printf("n++\n")
+		case kindString, kindBytes, kindStruct:
+			g.printf("{\n")
+			g.printf("l, n0, e := unum.DecodeUnum32(b[n:])\n")
+			g.printf("if e != nil {\n return 0, e\n }\n")
+			g.printf("n += n0\n")
+			g.printf("if len(b)-n < int(l) {\n return 0, unum.ErrorInvalidBuffer\n }\n")
+			switch fd.kind {
+			case kindString:
+				g.printf("x.%s = %s(b[n : n+int(l)])\n", fd.name, fd.typ)
+				g.printf("n += int(l)\n")
+			case kindBytes:
+				g.printf("x.%s = append(x.%s[:0], b[n:n+int(l)]...)\n", fd.name, fd.name)
+				g.printf("n += int(l)\n")
+			case kindStruct:
+				g.printf("m, e := x.%s.unmarshalUnum(b[n : n+int(l)])\n", fd.name)
+				g.printf("if e != nil {\n return 0, e\n }\n")
+				g.printf("if m != int(l) {\n return 0, unum.ErrorInvalidBuffer\n }\n")
+				g.printf("n += m\n")
+			}
+			g.printf("}\n")
+		}
+	}
+	g.printf("return n, nil\n")
+	g.printf("}\n")
+}
@@ -0,0 +1,96 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package main
+
+import (
+	"bytes"
+	"go/format"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// generated source for the example package must be current and gofmt clean
+func TestGenerateExample(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	args := []string{"-type=Header,Message", "-output=example_unum.go"}
+	src, warnings, e := Generate(dir, []string{"Header", "Message"}, args)
+	if e != nil {
+		t.Fatalf("error generating - e:%s\n", e.Error())
+	}
+	// Header.Seq is the only untagged field wider than its encoding
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "Header.Seq: uint64 values >= 2^62") {
+		t.Errorf("unexpected warnings - %q\n", warnings)
+	}
+
+	formatted, e := format.Source(src)
+	if e != nil {
+		t.Fatalf("error formatting - e:%s\n", e.Error())
+	}
+	if !bytes.Equal(src, formatted) {
+		t.Errorf("generated source is not gofmt clean\n")
+	}
+
+	golden, e := os.ReadFile(filepath.Join(dir, "example_unum.go"))
+	if e != nil {
+		t.Fatalf("error reading golden file - e:%s\n", e.Error())
+	}
+	if !bytes.Equal(src, golden) {
+		t.Errorf("example_unum.go is stale - run go generate in %s\n", dir)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	var tests = []struct {
+		src  string
+		want string
+	}{
+		{"type T struct { A int }", "unsupported type int"},
+		{"type T struct { A []uint32 }", "unsupported type []uint32"},
+		{"type T struct { A *uint32 }", "unsupported type"},
+		{"type T struct { A string `unum:\"32\"` }", "applies to unsigned fields only"},
+		{"type T struct { A uint32 `unum:\"8\"` }", "invalid tag"},
+		{"type S struct{}\ntype T struct { A S }", "must also be named in -type"},
+		{"type T uint32", "not a struct type"},
+		{"type U struct{}", "type T not declared"},
+	}
+
+	dir := t.TempDir()
+
+	fname := filepath.Join(dir, "t.go")
+	for _, test := range tests {
+		if e := os.WriteFile(fname, []byte("package t\n\n"+test.src+"\n"), 0644); e != nil {
+			t.Fatal(e)
+		}
+		_, _, e := Generate(dir, []string{"T"}, nil)
+		if e == nil {
+			t.Errorf("expected error - src:%q\n", test.src)
+			continue
+		}
+		if !strings.Contains(e.Error(), test.want) {
+			t.Errorf("unexpected error - src:%q - expected:%q have:%q\n", test.src, test.want, e.Error())
+		}
+	}
+}
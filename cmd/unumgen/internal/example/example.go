@@ -0,0 +1,53 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package example declares sample message types for unumgen. The codec
+// methods in example_unum.go are generated; do not edit them by hand.
+package example
+
+//go:generate unumgen -type=Header,Message -output=example_unum.go
+
+type Kind uint8
+
+const (
+	KindData Kind = iota
+	KindAck
+	KindClose
+)
+
+type Header struct {
+	Version uint8
+	Kind    Kind
+	Seq     uint64
+	Flags   uint32 `unum:"32"`
+	Trace   uint64 `unum:"-"` // process local
+}
+
+type Message struct {
+	Header
+	ID      uint64 `unum:"64"`
+	Port    uint16 `unum:"16"`
+	Urgent  bool
+	Topic   string
+	Payload []byte
+}
@@ -0,0 +1,167 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package example
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"unum"
+)
+
+// reference encoding of Header, written directly against the unum API.
+func refHeader(w *bytes.Buffer, h *Header) {
+	unum.WriteUnum16(w, uint16(h.Version))
+	unum.WriteUnum16(w, uint16(h.Kind))
+	unum.WriteUnum64(w, h.Seq)
+	unum.WriteUnum32(w, h.Flags)
+}
+
+// reference encoding of Message, written directly against the unum API.
+func refMessage(m *Message) []byte {
+	var w, hw bytes.Buffer
+	refHeader(&hw, &m.Header)
+	unum.WriteUnum32(&w, uint32(hw.Len()))
+	w.Write(hw.Bytes())
+	unum.WriteUnum64(&w, m.ID)
+	unum.WriteUnum16(&w, m.Port)
+	if m.Urgent {
+		w.WriteByte(1)
+	} else {
+		w.WriteByte(0)
+	}
+	unum.WriteUnum32(&w, uint32(len(m.Topic)))
+	w.WriteString(m.Topic)
+	unum.WriteUnum32(&w, uint32(len(m.Payload)))
+	w.Write(m.Payload)
+	return w.Bytes()
+}
+
+func randMessage(r *rand.Rand) *Message {
+	payload := make([]byte, r.Intn(300))
+	r.Read(payload)
+	topic := make([]byte, r.Intn(80))
+	for i := range topic {
+		topic[i] = byte('a' + r.Intn(26))
+	}
+	return &Message{
+		Header: Header{
+			Version: uint8(r.Intn(256)),
+			Kind:    Kind(r.Intn(3)),
+			Seq:     uint64(r.Int63n(int64(unum.Unum64ValueBound))) >> uint(r.Intn(62)),
+			Flags:   uint32(r.Int31n(int32(unum.Unum32ValueBound))) >> uint(r.Intn(30)),
+		},
+		ID:      uint64(r.Int63n(int64(unum.Unum64ValueBound))) >> uint(r.Intn(62)),
+		Port:    uint16(r.Intn(int(unum.Unum16ValueBound))),
+		Urgent:  r.Intn(2) == 1,
+		Topic:   string(topic),
+		Payload: payload,
+	}
+}
+
+func (m *Message) Generate(r *rand.Rand, size int) reflect.Value {
+	return reflect.ValueOf(randMessage(r))
+}
+
+func TestMarshalReference(t *testing.T) {
+	f := func(m *Message) bool {
+		b, e := m.MarshalUnum()
+		if e != nil {
+			t.Errorf("error marshaling - e:%s\n", e.Error())
+			return false
+		}
+		if expected := refMessage(m); !bytes.Equal(b, expected) {
+			t.Errorf("image mismatch - expected:%x have:%x\n", expected, b)
+			return false
+		}
+		if len(b) != m.SizeUnum() {
+			t.Errorf("SizeUnum mismatch - expected:%d have:%d\n", len(b), m.SizeUnum())
+			return false
+		}
+
+		var m0 Message
+		if e := m0.UnmarshalUnum(b); e != nil {
+			t.Errorf("error unmarshaling - e:%s\n", e.Error())
+			return false
+		}
+		if len(m.Payload) == 0 {
+			m0.Payload = m.Payload
+		}
+		if !reflect.DeepEqual(m, &m0) {
+			t.Errorf("BUG - m:%v - m0:%v\n", m, m0)
+			return false
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	m := Message{Port: unum.Unum16ValueBound}
+	if _, e := m.MarshalUnum(); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	m = Message{Header: Header{Flags: unum.Unum32ValueBound}}
+	if _, e := m.MarshalUnum(); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+
+	// skipped fields are not encoded
+	h := Header{Version: 1, Trace: 42}
+	b, e := h.MarshalUnum()
+	if e != nil {
+		t.Fatalf("error marshaling - e:%s\n", e.Error())
+	}
+	var h0 Header
+	if e := h0.UnmarshalUnum(b); e != nil || h0.Trace != 0 || h0.Version != 1 {
+		t.Errorf("unexpected decode - h0:%v e:%v\n", h0, e)
+	}
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	m := randMessage(rand.New(rand.NewSource(1)))
+	b, e := m.MarshalUnum()
+	if e != nil {
+		t.Fatalf("error marshaling - e:%s\n", e.Error())
+	}
+	var m0 Message
+	for i := 0; i < len(b); i++ {
+		if e := m0.UnmarshalUnum(b[:i]); e == nil {
+			t.Errorf("expected error decoding truncated image - len:%d\n", i)
+		}
+	}
+	if e := m0.UnmarshalUnum(append(b, 0)); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer for trailing bytes - have:%v\n", e)
+	}
+
+	// Version is a uint8 encoded as UNUM-16
+	var h Header
+	if e := h.UnmarshalUnum([]byte{0x81, 0x00, 0, 0, 0}); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer for out of range value - have:%v\n", e)
+	}
+}
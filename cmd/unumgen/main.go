@@ -0,0 +1,113 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// unumgen generates zero-reflection unum codecs for Go struct types.
+//
+// Given the name of one or more struct types declared in a package,
+// unumgen writes a Go source file with MarshalUnum, UnmarshalUnum and
+// SizeUnum methods for each type. The generated methods call the
+// EncodeUnum16/32/64 and DecodeUnum16/32/64 functions directly.
+//
+// Typical use is via go:generate:
+//
+//      //go:generate unumgen -type=Header,Message
+//
+// Fields are encoded in declaration order. Supported field types:
+//
+//      uint8, uint16, uint32, uint64, uint, uintptr (and named types thereof)
+//      bool                    -- a single byte { 0, 1 }
+//      string, []byte          -- UNUM-32 length followed by the bytes
+//      T                       -- a struct type also named in -type,
+//                                 as UNUM-32 length followed by its image
+//
+// The `unum` struct tag controls the encoding of a field:
+//
+//      `unum:"-"`              -- field is skipped
+//      `unum:"16"`             -- unsigned field is encoded as UNUM-16
+//      `unum:"32"`             -- unsigned field is encoded as UNUM-32
+//      `unum:"64"`             -- unsigned field is encoded as UNUM-64
+//
+// Untagged unsigned fields use the narrowest encoding of their type:
+// uint8 -> UNUM-16, uint16 -> UNUM-32, and UNUM-64 for all others. UNUM-64
+// represents values < 2^62 only, so it does not cover every value of
+// uint64, uint and uintptr fields: unumgen reports such untagged fields at
+// generation time (a `unum:"64"` tag accepts the bound). Marshaling a value
+// that exceeds the selected encoding's bound returns unum.ErrorMaxValue.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma-separated list of struct type names; must be set")
+	output    = flag.String("output", "", "output file name; default srcdir/<type>_unum.go")
+)
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: unumgen -type T[,T...] [-output file] [directory]\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("unumgen: ")
+	flag.Usage = usage
+	flag.Parse()
+	if len(*typeNames) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	types := strings.Split(*typeNames, ",")
+
+	dir := "."
+	switch flag.NArg() {
+	case 0:
+	case 1:
+		dir = flag.Arg(0)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	src, warnings, e := Generate(dir, types, os.Args[1:])
+	if e != nil {
+		log.Fatal(e)
+	}
+	for _, w := range warnings {
+		log.Printf("warning: %s", w)
+	}
+
+	fname := *output
+	if fname == "" {
+		fname = filepath.Join(dir, strings.ToLower(types[0])+"_unum.go")
+	}
+	if e := os.WriteFile(fname, src, 0644); e != nil {
+		log.Fatalf("writing output: %s", e)
+	}
+}
@@ -0,0 +1,248 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package columnar implements a columnar format for batches of records.
+//
+// A batch stores each column of its records contiguously, as a chunk of
+// typed values with a column specific encoding, preceded (for nullable
+// columns) by a null bitmap. A footer records the schema, the offset and
+// length of each chunk, and per column statistics, so that readers can
+// read only the columns they need and skip batches by their min/max
+// values. The image is:
+//
+//      magic       4 bytes     "UCOL"
+//      chunks      per column
+//      bitmap      [ceil(rows/8)]byte  nullable columns only: bit i (LSB
+//                              first) is set if row i is not null
+//      values      values of the non-null rows, per column encoding
+//
+//      footer:
+//      version     UNUM-16     1
+//      rows        UNUM-64     number of records
+//      columns     UNUM-32     number of columns
+//      column:     per column
+//      name        UNUM-32 length, bytes
+//      type        UNUM-16     Type
+//      encoding    UNUM-16     Encoding
+//      nullable    UNUM-16     0 or 1
+//      offset      UNUM-64     offset of chunk
+//      length      UNUM-64     length of chunk
+//      nulls       UNUM-64     number of null rows
+//      min, max    values      (if rows > nulls) per type, see below
+//      checksum    4 bytes     CRC32C of footer (big-endian)
+//
+//      length      4 bytes     length of footer, including checksum (big-endian)
+//      magic       4 bytes     "UCOL"
+//
+// Values are encoded per type and encoding:
+//
+//      type        encoding    values
+//      -----------+-----------+-------------------------------------------
+//      Uint        Plain       UNUM-64
+//                  Delta       signed delta UNUM-64 (see unum.EncodeSignedDelta)
+//                  RLE         hybrid run-length (see package rle)
+//                  Dict        uint64 dictionary block (see package dict)
+//      Int         Plain       zigzag UNUM-64
+//                  Delta       zigzag UNUM-64 of first value and differences
+//                  RLE, Dict   as Uint, of the zigzag values
+//      Float       Plain       8 bytes IEEE 754 (big-endian)
+//      Bool        Plain       bitmap, LSB first
+//                  RLE         as Uint, of 0 and 1 values
+//      String,     Plain       UNUM-32 length, bytes
+//      Bytes       Dict        string dictionary block (see package dict)
+//
+// Min and max statistics are encoded as plain values.
+package columnar
+
+import (
+	"fmt"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorSchema  = fmt.Errorf("columnar.ErrorSchema")
+	ErrorType    = fmt.Errorf("columnar.ErrorType")
+	ErrorNull    = fmt.Errorf("columnar.ErrorNull")
+	ErrorArity   = fmt.Errorf("columnar.ErrorArity")
+	ErrorMagic   = fmt.Errorf("columnar.ErrorMagic")
+	ErrorVersion = fmt.Errorf("columnar.ErrorVersion")
+	ErrorCorrupt = fmt.Errorf("columnar.ErrorCorrupt")
+)
+
+const (
+	magic   = "UCOL"
+	version = 1
+)
+
+// Type is the type of the values of a column.
+type Type byte
+
+// Column types
+const (
+	Uint   Type = 1 // uint64, < 2^62
+	Int    Type = 2 // int64, in [-2^61, 2^61)
+	Float  Type = 3 // float64
+	Bool   Type = 4 // bool
+	String Type = 5 // string
+	Bytes  Type = 6 // []byte
+)
+
+var typeNames = map[Type]string{
+	Uint: "uint", Int: "int", Float: "float", Bool: "bool", String: "string", Bytes: "bytes",
+}
+
+func (t Type) String() string {
+	if s, ok := typeNames[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Type(%d)", byte(t))
+}
+
+// Encoding is the encoding of the values of a column.
+type Encoding byte
+
+// Column encodings
+const (
+	Plain Encoding = 0
+	Delta Encoding = 1
+	RLE   Encoding = 2
+	Dict  Encoding = 3
+)
+
+var encodingNames = map[Encoding]string{
+	Plain: "plain", Delta: "delta", RLE: "rle", Dict: "dict",
+}
+
+func (enc Encoding) String() string {
+	if s, ok := encodingNames[enc]; ok {
+		return s
+	}
+	return fmt.Sprintf("Encoding(%d)", byte(enc))
+}
+
+// encodings supported by each type.
+var encodings = map[Type][]Encoding{
+	Uint:   {Plain, Delta, RLE, Dict},
+	Int:    {Plain, Delta, RLE, Dict},
+	Float:  {Plain},
+	Bool:   {Plain, RLE},
+	String: {Plain, Dict},
+	Bytes:  {Plain, Dict},
+}
+
+// Column describes a column of a batch.
+type Column struct {
+	Name     string
+	Type     Type
+	Encoding Encoding
+	Nullable bool
+}
+
+// returns true if the column type supports its encoding.
+func (c Column) valid() bool {
+	for _, enc := range encodings[c.Type] {
+		if enc == c.Encoding {
+			return true
+		}
+	}
+	return false
+}
+
+// returns ErrorSchema if a column of schema has an unsupported type or
+// encoding, or a duplicate name.
+func checkSchema(schema []Column) error {
+	names := make(map[string]bool, len(schema))
+	for _, c := range schema {
+		if !c.valid() || names[c.Name] || len(c.Name) >= int(unum.Unum32ValueBound) {
+			return ErrorSchema
+		}
+		names[c.Name] = true
+	}
+	return nil
+}
+
+// Stats are the statistics of a column.
+type Stats struct {
+	Nulls int         // number of null rows
+	Min   interface{} // minimum value, nil if all rows are null
+	Max   interface{} // maximum value, nil if all rows are null
+}
+
+// Vector is the decoded values of a column. Values of null rows are zero.
+type Vector struct {
+	Type    Type
+	Valid   []bool // false for null rows; nil if there are no nulls
+	Uints   []uint64
+	Ints    []int64
+	Floats  []float64
+	Bools   []bool
+	Strings []string
+	Bytes   [][]byte
+}
+
+// Returns the number of rows.
+func (v *Vector) Len() int {
+	switch v.Type {
+	case Uint:
+		return len(v.Uints)
+	case Int:
+		return len(v.Ints)
+	case Float:
+		return len(v.Floats)
+	case Bool:
+		return len(v.Bools)
+	case String:
+		return len(v.Strings)
+	case Bytes:
+		return len(v.Bytes)
+	}
+	return 0
+}
+
+// Returns true if row i is null.
+func (v *Vector) IsNull(i int) bool {
+	return v.Valid != nil && !v.Valid[i]
+}
+
+// Returns the value of row i, or nil if it is null.
+func (v *Vector) Value(i int) interface{} {
+	if v.IsNull(i) {
+		return nil
+	}
+	switch v.Type {
+	case Uint:
+		return v.Uints[i]
+	case Int:
+		return v.Ints[i]
+	case Float:
+		return v.Floats[i]
+	case Bool:
+		return v.Bools[i]
+	case String:
+		return v.Strings[i]
+	case Bytes:
+		return v.Bytes[i]
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package columnar_test
+
+import (
+	"testing"
+	"unum/columnar"
+)
+
+func TestTypeString(t *testing.T) {
+	for _, tc := range []struct {
+		s        string
+		expected string
+	}{
+		{columnar.Uint.String(), "uint"},
+		{columnar.Bytes.String(), "bytes"},
+		{columnar.Type(9).String(), "Type(9)"},
+		{columnar.Dict.String(), "dict"},
+		{columnar.Encoding(7).String(), "Encoding(7)"},
+	} {
+		if tc.s != tc.expected {
+			t.Errorf("expected %q - have:%q\n", tc.expected, tc.s)
+		}
+	}
+}
+
+func TestSchema(t *testing.T) {
+	for _, schema := range [][]columnar.Column{
+		{{Name: "x", Type: columnar.Float, Encoding: columnar.Delta}},
+		{{Name: "x", Type: columnar.String, Encoding: columnar.RLE}},
+		{{Name: "x", Type: columnar.Bool, Encoding: columnar.Dict}},
+		{{Name: "x", Type: columnar.Type(0)}},
+		{{Name: "x", Type: columnar.Uint}, {Name: "x", Type: columnar.Int}},
+	} {
+		if _, e := columnar.NewWriter(schema); e != columnar.ErrorSchema {
+			t.Errorf("expected ErrorSchema - schema:%v have:%v\n", schema, e)
+		}
+	}
+}
+
+func TestVector(t *testing.T) {
+	v := &columnar.Vector{
+		Type:  columnar.Int,
+		Valid: []bool{true, false, true},
+		Ints:  []int64{-1, 0, 7},
+	}
+	if v.Len() != 3 || v.IsNull(0) || !v.IsNull(1) {
+		t.Errorf("BUG - len:%d\n", v.Len())
+	}
+	if v.Value(0) != int64(-1) || v.Value(1) != nil || v.Value(2) != int64(7) {
+		t.Errorf("BUG - values:%v %v %v\n", v.Value(0), v.Value(1), v.Value(2))
+	}
+}
@@ -0,0 +1,410 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package columnar
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"unum"
+	"unum/dict"
+	"unum/rle"
+)
+
+// footer entry of a column.
+type columnInfo struct {
+	Column
+	offset int64
+	length int64
+	stats  Stats
+}
+
+// Reader reads the columns of a batch.
+type Reader struct {
+	r       io.ReaderAt
+	rows    int
+	columns []columnInfo
+}
+
+// Returns a new Reader of the batch of size bytes at r, reading its footer.
+//
+// On error returns (nil, e) where e is:
+//    ErrorMagic    -- not a batch
+//    ErrorVersion  -- unsupported version
+//    ErrorCorrupt  -- non-conformant (or truncated) footer
+//    <other>       -- propagated io.ReaderAt error
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	if size < 2*int64(len(magic))+4 {
+		return nil, ErrorMagic
+	}
+	var head [4]byte
+	var tail [8]byte
+	if e := readAt(r, head[:], 0); e != nil {
+		return nil, e
+	}
+	if e := readAt(r, tail[:], size-8); e != nil {
+		return nil, e
+	}
+	if string(head[:]) != magic || string(tail[4:]) != magic {
+		return nil, ErrorMagic
+	}
+	flen := int64(binary.BigEndian.Uint32(tail[:]))
+	if flen < 4 || flen > size-16 {
+		return nil, ErrorCorrupt
+	}
+	footer := make([]byte, flen)
+	if e := readAt(r, footer, size-8-flen); e != nil {
+		return nil, e
+	}
+	body := footer[:flen-4]
+	if crc32.Checksum(body, castagnoli) != binary.BigEndian.Uint32(footer[flen-4:]) {
+		return nil, ErrorCorrupt
+	}
+	rd := &Reader{r: r}
+	if e := rd.parseFooter(body, size-8-flen); e != nil {
+		return nil, e
+	}
+	return rd, nil
+}
+
+// Reads len(p) bytes at off of r.
+func readAt(r io.ReaderAt, p []byte, off int64) error {
+	n, e := r.ReadAt(p, off)
+	if n == len(p) {
+		return nil
+	}
+	if e == nil || e == io.EOF {
+		e = io.ErrUnexpectedEOF
+	}
+	return e
+}
+
+// decodes the footer, with chunks in [4, end).
+func (rd *Reader) parseFooter(b []byte, end int64) error {
+	d := newDecoder(b)
+	if v := d.Unum16(); d.Err() == nil && v != version {
+		return ErrorVersion
+	}
+	rows := d.Unum64()
+	ncols := d.Unum32()
+	if d.Err() != nil || ncols > uint32(len(b)) || rows > uint64(end)*8 {
+		return ErrorCorrupt
+	}
+	rd.rows = int(rows)
+	rd.columns = make([]columnInfo, ncols)
+	schema := make([]Column, ncols)
+	for i := range rd.columns {
+		ci := &rd.columns[i]
+		ci.Name = d.string()
+		ci.Type = Type(d.Unum16())
+		ci.Encoding = Encoding(d.Unum16())
+		nullable := d.Unum16()
+		ci.Nullable = nullable == 1
+		ci.offset = int64(d.Unum64())
+		ci.length = int64(d.Unum64())
+		ci.stats.Nulls = int(d.Unum64())
+		if d.Err() != nil || nullable > 1 || ci.offset < 4 || ci.length < 0 || ci.offset > end-ci.length ||
+			ci.stats.Nulls < 0 || ci.stats.Nulls > rd.rows || (!ci.Nullable && ci.stats.Nulls > 0) {
+			return ErrorCorrupt
+		}
+		if rd.rows > ci.stats.Nulls {
+			ci.stats.Min = d.value(ci.Type)
+			ci.stats.Max = d.value(ci.Type)
+		}
+		schema[i] = ci.Column
+	}
+	if d.Err() != nil || len(d.Rest()) != 0 || checkSchema(schema) != nil {
+		return ErrorCorrupt
+	}
+	return nil
+}
+
+// Returns the number of records of the batch.
+func (rd *Reader) Len() int {
+	return rd.rows
+}
+
+// Returns the schema of the batch.
+func (rd *Reader) Schema() []Column {
+	schema := make([]Column, len(rd.columns))
+	for i := range rd.columns {
+		schema[i] = rd.columns[i].Column
+	}
+	return schema
+}
+
+// Returns the index of the column with the given name, or -1.
+func (rd *Reader) ColumnIndex(name string) int {
+	for i := range rd.columns {
+		if rd.columns[i].Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// Returns the statistics of column i.
+func (rd *Reader) Stats(i int) Stats {
+	return rd.columns[i].stats
+}
+
+// Reads and decodes column i.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCorrupt  -- non-conformant chunk
+//    <other>       -- propagated io.ReaderAt error
+func (rd *Reader) ReadColumn(i int) (*Vector, error) {
+	ci := &rd.columns[i]
+	chunk := make([]byte, ci.length)
+	if e := readAt(rd.r, chunk, ci.offset); e != nil {
+		return nil, e
+	}
+	var valid []bool
+	if ci.Nullable {
+		n := (rd.rows + 7) / 8
+		if len(chunk) < n {
+			return nil, ErrorCorrupt
+		}
+		valid = make([]bool, rd.rows)
+		nulls := 0
+		for k := range valid {
+			valid[k] = chunk[k>>3]&(1<<uint(k&7)) != 0
+			if !valid[k] {
+				nulls++
+			}
+		}
+		if nulls != ci.stats.Nulls {
+			return nil, ErrorCorrupt
+		}
+		chunk = chunk[n:]
+	}
+	v := &Vector{Type: ci.Type}
+	if e := decodeValues(v, ci.Column, chunk, rd.rows-ci.stats.Nulls); e != nil {
+		return nil, e
+	}
+	if ci.stats.Nulls > 0 {
+		v.Valid = valid
+		v.scatter(valid)
+	}
+	return v, nil
+}
+
+// spreads the values of non-null rows to their rows.
+func (v *Vector) scatter(valid []bool) {
+	j := 0
+	for _, ok := range valid {
+		if ok {
+			j++
+		}
+	}
+	n := len(valid)
+	switch v.Type {
+	case Uint:
+		v.Uints = append(v.Uints, make([]uint64, n-j)...)
+	case Int:
+		v.Ints = append(v.Ints, make([]int64, n-j)...)
+	case Float:
+		v.Floats = append(v.Floats, make([]float64, n-j)...)
+	case Bool:
+		v.Bools = append(v.Bools, make([]bool, n-j)...)
+	case String:
+		v.Strings = append(v.Strings, make([]string, n-j)...)
+	case Bytes:
+		v.Bytes = append(v.Bytes, make([][]byte, n-j)...)
+	}
+	// move values backwards, from the last non-null row
+	for i := n - 1; i >= 0; i-- {
+		if !valid[i] {
+			continue
+		}
+		j--
+		if i == j {
+			// rows before i are not null
+			break
+		}
+		switch v.Type {
+		case Uint:
+			v.Uints[i], v.Uints[j] = v.Uints[j], 0
+		case Int:
+			v.Ints[i], v.Ints[j] = v.Ints[j], 0
+		case Float:
+			v.Floats[i], v.Floats[j] = v.Floats[j], 0
+		case Bool:
+			v.Bools[i], v.Bools[j] = v.Bools[j], false
+		case String:
+			v.Strings[i], v.Strings[j] = v.Strings[j], ""
+		case Bytes:
+			v.Bytes[i], v.Bytes[j] = v.Bytes[j], nil
+		}
+	}
+}
+
+// decodes the n values of chunk b of column c into v.
+func decodeValues(v *Vector, c Column, b []byte, n int) error {
+	switch c.Type {
+	case Uint:
+		us, e := decodeUints(b, c.Encoding, n)
+		v.Uints = us
+		return e
+	case Int:
+		v.Ints = make([]int64, n)
+		if c.Encoding == Delta {
+			var x int64
+			for i := range v.Ints {
+				d, n0, e := unum.DecodeInt64(b)
+				if e != nil {
+					return ErrorCorrupt
+				}
+				x += d
+				v.Ints[i], b = x, b[n0:]
+			}
+			if len(b) != 0 {
+				return ErrorCorrupt
+			}
+			return nil
+		}
+		us, e := decodeUints(b, c.Encoding, n)
+		for i, u := range us {
+			v.Ints[i] = unum.Unzigzag64(u)
+		}
+		return e
+	case Float:
+		if len(b) != 8*n {
+			return ErrorCorrupt
+		}
+		v.Floats = make([]float64, n)
+		for i := range v.Floats {
+			v.Floats[i] = math.Float64frombits(binary.BigEndian.Uint64(b[8*i:]))
+		}
+		return nil
+	case Bool:
+		v.Bools = make([]bool, n)
+		if c.Encoding == RLE {
+			us, e := decodeUints(b, RLE, n)
+			for i, u := range us {
+				v.Bools[i] = u != 0
+			}
+			return e
+		}
+		if len(b) != (n+7)/8 {
+			return ErrorCorrupt
+		}
+		for i := range v.Bools {
+			v.Bools[i] = b[i>>3]&(1<<uint(i&7)) != 0
+		}
+		return nil
+	}
+	var ss []string
+	if c.Encoding == Dict {
+		var e error
+		if ss, e = dict.DecodeStrings(make([]string, 0, n), b); e != nil || len(ss) != n {
+			return ErrorCorrupt
+		}
+	} else {
+		d := newDecoder(b)
+		ss = make([]string, n)
+		for i := range ss {
+			ss[i] = d.string()
+		}
+		if d.Err() != nil || len(d.Rest()) != 0 {
+			return ErrorCorrupt
+		}
+	}
+	if c.Type == String {
+		v.Strings = ss
+		return nil
+	}
+	v.Bytes = make([][]byte, n)
+	for i, s := range ss {
+		v.Bytes[i] = []byte(s)
+	}
+	return nil
+}
+
+// decodes the n values of chunk b of a Uint column with encoding enc.
+func decodeUints(b []byte, enc Encoding, n int) ([]uint64, error) {
+	var us []uint64
+	var e error
+	switch enc {
+	case Delta:
+		us = make([]uint64, n)
+		var n0 int
+		if n0, e = unum.DecodeSignedDelta(b, us); e == nil && n0 != len(b) {
+			e = ErrorCorrupt
+		}
+	case RLE:
+		us, e = rle.DecodeHybrid(make([]uint64, 0, n), b)
+	case Dict:
+		us, e = dict.DecodeUint64s(make([]uint64, 0, n), b)
+	default:
+		d := newDecoder(b)
+		us = make([]uint64, n)
+		for i := range us {
+			us[i] = d.Unum64()
+		}
+		if d.Err() != nil || len(d.Rest()) != 0 {
+			e = ErrorCorrupt
+		}
+	}
+	if e != nil || len(us) != n {
+		return make([]uint64, n), ErrorCorrupt
+	}
+	return us, nil
+}
+
+// decoder decodes plain values of a buffer, retaining the first error.
+type decoder struct {
+	unum.BufferDecoder
+}
+
+func newDecoder(b []byte) *decoder {
+	d := &decoder{}
+	d.Reset(b)
+	return d
+}
+
+func (d *decoder) string() string {
+	return string(d.Next(int(d.Unum32())))
+}
+
+// decodes a plain value of type t.
+func (d *decoder) value(t Type) interface{} {
+	switch t {
+	case Uint:
+		return d.Unum64()
+	case Int:
+		return unum.Unzigzag64(d.Unum64())
+	case Float:
+		if p := d.Next(8); p != nil {
+			return math.Float64frombits(binary.BigEndian.Uint64(p))
+		}
+		return 0.0
+	case Bool:
+		return d.Unum16() != 0
+	case String:
+		return d.string()
+	}
+	return []byte(d.string())
+}
@@ -0,0 +1,212 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package columnar_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"reflect"
+	"testing"
+	"unum/columnar"
+)
+
+// counts the bytes read through it
+type countingReaderAt struct {
+	r *bytes.Reader
+	n int
+}
+
+func (c *countingReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	c.n += len(p)
+	return c.r.ReadAt(p, off)
+}
+
+// returns io.EOF with reads that end at the end of the input, as
+// io.ReaderAt allows
+type eofReaderAt struct {
+	*bytes.Reader
+}
+
+func (r eofReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	n, e := r.Reader.ReadAt(p, off)
+	if e == nil && off+int64(n) == r.Size() {
+		e = io.EOF
+	}
+	return n, e
+}
+
+func TestReadColumns(t *testing.T) {
+	const n = 1000
+	b := writeEvents(t, n)
+	rd, e := columnar.NewReader(bytes.NewReader(b), int64(len(b)))
+	if e != nil {
+		t.Fatalf("error opening - e:%s\n", e.Error())
+	}
+	if rd.Len() != n || !reflect.DeepEqual(rd.Schema(), eventSchema) {
+		t.Fatalf("BUG - len:%d schema:%v\n", rd.Len(), rd.Schema())
+	}
+	for c := range eventSchema {
+		v, e := rd.ReadColumn(c)
+		if e != nil {
+			t.Fatalf("error reading column %s - e:%s\n", eventSchema[c].Name, e.Error())
+		}
+		if v.Len() != n {
+			t.Fatalf("BUG - column:%s len:%d\n", eventSchema[c].Name, v.Len())
+		}
+		for i := 0; i < n; i++ {
+			if expected := event(i)[c]; !reflect.DeepEqual(v.Value(i), expected) {
+				t.Fatalf("BUG - column:%s row:%d expected:%v have:%v\n", eventSchema[c].Name, i, expected, v.Value(i))
+			}
+		}
+	}
+}
+
+func TestSelectiveRead(t *testing.T) {
+	b := writeEvents(t, 5000)
+	r := &countingReaderAt{r: bytes.NewReader(b)}
+	rd, e := columnar.NewReader(r, int64(len(b)))
+	if e != nil {
+		t.Fatalf("error opening - e:%s\n", e.Error())
+	}
+	footer := r.n
+	v, e := rd.ReadColumn(rd.ColumnIndex("status"))
+	if e != nil || v.Uints[4999] != 300 {
+		t.Fatalf("BUG - e:%v\n", e)
+	}
+	if r.n-footer > len(b)/100 {
+		t.Errorf("read more than footer and column - read:%d footer:%d len:%d\n", r.n, footer, len(b))
+	}
+	if rd.ColumnIndex("missing") != -1 {
+		t.Errorf("expected index -1\n")
+	}
+}
+
+func TestStats(t *testing.T) {
+	b := writeEvents(t, 1000)
+	rd, _ := columnar.NewReader(bytes.NewReader(b), int64(len(b)))
+	for _, tc := range []struct {
+		name     string
+		expected columnar.Stats
+	}{
+		{"id", columnar.Stats{Min: uint64(1000000), Max: uint64(1002997)}},
+		{"user", columnar.Stats{Nulls: 334, Min: uint64(1 << 50), Max: uint64(1<<50 + 6)}},
+		{"offset", columnar.Stats{Min: int64(-4), Max: int64(4)}},
+		{"score", columnar.Stats{Nulls: 200, Min: 0.25, Max: 999.0 / 4}},
+		{"ok", columnar.Stats{Min: false, Max: true}},
+		{"path", columnar.Stats{Min: "/", Max: "/index.html"}},
+		{"tag", columnar.Stats{Min: []byte("a"), Max: []byte("c")}},
+	} {
+		if s := rd.Stats(rd.ColumnIndex(tc.name)); !reflect.DeepEqual(s, tc.expected) {
+			t.Errorf("BUG - column:%s expected:%v have:%v\n", tc.name, tc.expected, s)
+		}
+	}
+}
+
+func TestNullColumn(t *testing.T) {
+	schema := []columnar.Column{{Name: "f", Type: columnar.Float, Nullable: true}}
+	w, _ := columnar.NewWriter(schema)
+	w.Append(nil)
+	w.Append(math.NaN())
+	w.Append(nil)
+	var buf bytes.Buffer
+	w.WriteTo(&buf)
+	rd, e := columnar.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if e != nil {
+		t.Fatalf("error opening - e:%s\n", e.Error())
+	}
+	s := rd.Stats(0)
+	if s.Nulls != 2 || !math.IsNaN(s.Min.(float64)) {
+		t.Errorf("BUG - stats:%v\n", s)
+	}
+	v, _ := rd.ReadColumn(0)
+	if !v.IsNull(0) || v.IsNull(1) || !v.IsNull(2) || !math.IsNaN(v.Floats[1]) {
+		t.Errorf("BUG - vector:%v\n", v)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	b := writeEvents(t, 100)
+	open := func(b []byte) error {
+		_, e := columnar.NewReader(bytes.NewReader(b), int64(len(b)))
+		return e
+	}
+	if e := open(b[1:]); e != columnar.ErrorMagic {
+		t.Errorf("expected ErrorMagic - have:%v\n", e)
+	}
+	if e := open(b[:len(b)-1]); e != columnar.ErrorMagic {
+		t.Errorf("expected ErrorMagic - have:%v\n", e)
+	}
+	p := append([]byte(nil), b...)
+	p[len(p)-20] ^= 1
+	if e := open(p); e != columnar.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+
+	// nullable flag not 0 or 1
+	p = append([]byte(nil), b...)
+	flen := int(binary.BigEndian.Uint32(p[len(p)-8:]))
+	footer := p[len(p)-8-flen : len(p)-8]
+	i := bytes.Index(footer, []byte("\x02id")) + 1 + 2 + 2 // name, type, encoding
+	if footer[i] != 0 {
+		t.Fatalf("BUG - nullable flag of id not found\n")
+	}
+	footer[i] = 2
+	binary.BigEndian.PutUint32(footer[flen-4:], crc32.Checksum(footer[:flen-4], crc32.MakeTable(crc32.Castagnoli)))
+	if e := open(p); e != columnar.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+
+	// a final read may return io.EOF with all bytes
+	rd, e := columnar.NewReader(eofReaderAt{bytes.NewReader(b)}, int64(len(b)))
+	if e != nil {
+		t.Fatalf("error opening - e:%s\n", e.Error())
+	}
+	for c := range eventSchema {
+		if _, e := rd.ReadColumn(c); e != nil {
+			t.Errorf("error reading column %s - e:%s\n", eventSchema[c].Name, e.Error())
+		}
+	}
+
+	// clobbered chunk
+	p = append([]byte(nil), b...)
+	for i := 4; i < 100; i++ {
+		p[i] = 0xff
+	}
+	rd, _ = columnar.NewReader(bytes.NewReader(p), int64(len(p)))
+	if _, e := rd.ReadColumn(0); e != columnar.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+}
+
+func BenchmarkReadColumn(b *testing.B) {
+	p := writeEvents(b, 10000)
+	rd, _ := columnar.NewReader(bytes.NewReader(p), int64(len(p)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rd.ReadColumn(i % len(eventSchema))
+	}
+}
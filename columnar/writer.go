@@ -0,0 +1,453 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package columnar
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math"
+	"unum"
+	"unum/dict"
+	"unum/rle"
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// values of a column.
+type columnData struct {
+	valid   []bool // nullable columns only
+	nulls   int
+	uints   []uint64
+	ints    []int64
+	floats  []float64
+	bools   []bool
+	strings []string // String and Bytes columns
+}
+
+// Writer accumulates records and writes them as a batch.
+type Writer struct {
+	schema []Column
+	cols   []columnData
+	rows   int
+}
+
+// Returns a new Writer of batches of the given schema.
+//
+// On error returns (nil, e) where e is:
+//    ErrorSchema   -- invalid arg schema : unsupported type or encoding,
+//                     or duplicate column name
+func NewWriter(schema []Column) (*Writer, error) {
+	if e := checkSchema(schema); e != nil {
+		return nil, e
+	}
+	return &Writer{
+		schema: append([]Column(nil), schema...),
+		cols:   make([]columnData, len(schema)),
+	}, nil
+}
+
+// Returns the schema of the batch.
+func (w *Writer) Schema() []Column {
+	return w.schema
+}
+
+// Returns the number of records appended.
+func (w *Writer) Len() int {
+	return w.rows
+}
+
+// returns nil if v is a valid value of column c.
+func checkValue(c Column, v interface{}) error {
+	if v == nil {
+		if !c.Nullable {
+			return ErrorNull
+		}
+		return nil
+	}
+	switch c.Type {
+	case Uint:
+		x, ok := v.(uint64)
+		if !ok {
+			return ErrorType
+		}
+		if x >= unum.Unum64ValueBound {
+			return unum.ErrorMaxValue
+		}
+	case Int:
+		x, ok := v.(int64)
+		if !ok {
+			return ErrorType
+		}
+		if x < -unum.Int64ValueBound || x >= unum.Int64ValueBound {
+			return unum.ErrorMaxValue
+		}
+	case Float:
+		if _, ok := v.(float64); !ok {
+			return ErrorType
+		}
+	case Bool:
+		if _, ok := v.(bool); !ok {
+			return ErrorType
+		}
+	case String:
+		s, ok := v.(string)
+		if !ok {
+			return ErrorType
+		}
+		if len(s) >= int(unum.Unum32ValueBound) {
+			return unum.ErrorMaxValue
+		}
+	case Bytes:
+		p, ok := v.([]byte)
+		if !ok {
+			return ErrorType
+		}
+		if len(p) >= int(unum.Unum32ValueBound) {
+			return unum.ErrorMaxValue
+		}
+	}
+	return nil
+}
+
+// returns nil if the difference of v (valid, not nil) and the preceding
+// value of a Delta column is in the signed range of UNUM-64.
+func checkDelta(c Column, col *columnData, v interface{}) error {
+	var d int64
+	switch {
+	case c.Encoding != Delta:
+		return nil
+	case c.Type == Uint && len(col.uints) > 0:
+		d = int64(v.(uint64)) - int64(col.uints[len(col.uints)-1])
+	case c.Type == Int && len(col.ints) > 0:
+		d = v.(int64) - col.ints[len(col.ints)-1]
+	}
+	if d < -unum.Int64ValueBound || d >= unum.Int64ValueBound {
+		return unum.ErrorMaxValue
+	}
+	return nil
+}
+
+// Appends a record, with one value per column of the schema: uint64 for
+// Uint columns, int64 for Int, float64 for Float, bool for Bool, string
+// for String, []byte (copied) for Bytes, and nil for null values. The
+// writer is unchanged on error.
+//
+// On error returns e:
+//    ErrorArity          -- invalid arg values : not one per column
+//    ErrorType           -- invalid arg values : value not of column type
+//    ErrorNull           -- invalid arg values : nil value of non-nullable
+//                           column
+//    unum.ErrorMaxValue  -- invalid arg values : value out of range, or
+//                           difference from the preceding value of a Delta
+//                           column out of signed range
+func (w *Writer) Append(values ...interface{}) error {
+	if len(values) != len(w.schema) {
+		return ErrorArity
+	}
+	for i, v := range values {
+		if e := checkValue(w.schema[i], v); e != nil {
+			return e
+		}
+		if v == nil {
+			continue
+		}
+		if e := checkDelta(w.schema[i], &w.cols[i], v); e != nil {
+			return e
+		}
+	}
+	for i, v := range values {
+		c, col := w.schema[i], &w.cols[i]
+		if c.Nullable {
+			col.valid = append(col.valid, v != nil)
+		}
+		if v == nil {
+			col.nulls++
+			continue
+		}
+		switch x := v.(type) {
+		case uint64:
+			col.uints = append(col.uints, x)
+		case int64:
+			col.ints = append(col.ints, x)
+		case float64:
+			col.floats = append(col.floats, x)
+		case bool:
+			col.bools = append(col.bools, x)
+		case string:
+			col.strings = append(col.strings, x)
+		case []byte:
+			col.strings = append(col.strings, string(x))
+		}
+	}
+	w.rows++
+	return nil
+}
+
+// Resets the writer to begin a new batch of the same schema.
+func (w *Writer) Reset() {
+	for i := range w.cols {
+		w.cols[i] = columnData{}
+	}
+	w.rows = 0
+}
+
+// Writes the batch to out. Returns the number of bytes written.
+//
+// On error returns (n, e) where e is:
+//    <other>             -- propagated io.Writer error
+func (w *Writer) WriteTo(out io.Writer) (int64, error) {
+	var b bytes.Buffer
+	b.WriteString(magic)
+	footer, _ := unum.AppendUnum16(nil, version)
+	footer, _ = unum.AppendUnum64(footer, uint64(w.rows))
+	footer, _ = unum.AppendUnum32(footer, uint32(len(w.schema)))
+	for i, c := range w.schema {
+		col := &w.cols[i]
+		offset := b.Len()
+		if c.Nullable {
+			b.Write(bitmap(col.valid))
+		}
+		chunk, e := encodeValues(c, col)
+		if e != nil {
+			return 0, e
+		}
+		b.Write(chunk)
+
+		footer = appendString(footer, c.Name)
+		footer, _ = unum.AppendUnum16(footer, uint16(c.Type))
+		footer, _ = unum.AppendUnum16(footer, uint16(c.Encoding))
+		nullable := uint16(0)
+		if c.Nullable {
+			nullable = 1
+		}
+		footer, _ = unum.AppendUnum16(footer, nullable)
+		footer, _ = unum.AppendUnum64(footer, uint64(offset))
+		footer, _ = unum.AppendUnum64(footer, uint64(b.Len()-offset))
+		footer, _ = unum.AppendUnum64(footer, uint64(col.nulls))
+		if w.rows > col.nulls {
+			min, max := minMax(c.Type, col)
+			footer = appendValue(footer, c.Type, min)
+			footer = appendValue(footer, c.Type, max)
+		}
+	}
+	footer = appendUint32(footer, crc32.Checksum(footer, castagnoli))
+	b.Write(footer)
+	b.Write(appendUint32(nil, uint32(len(footer))))
+	b.WriteString(magic)
+	return b.WriteTo(out)
+}
+
+// returns the bitmap of valid, LSB first.
+func bitmap(valid []bool) []byte {
+	p := make([]byte, (len(valid)+7)/8)
+	for i, ok := range valid {
+		if ok {
+			p[i>>3] |= 1 << uint(i&7)
+		}
+	}
+	return p
+}
+
+// returns the minimum and maximum values of a column with non-null values.
+func minMax(t Type, col *columnData) (min, max interface{}) {
+	switch t {
+	case Uint:
+		lo, hi := col.uints[0], col.uints[0]
+		for _, x := range col.uints {
+			if x < lo {
+				lo = x
+			}
+			if x > hi {
+				hi = x
+			}
+		}
+		return lo, hi
+	case Int:
+		lo, hi := col.ints[0], col.ints[0]
+		for _, x := range col.ints {
+			if x < lo {
+				lo = x
+			}
+			if x > hi {
+				hi = x
+			}
+		}
+		return lo, hi
+	case Float:
+		// NaNs are not ordered, and are ignored (unless all values are NaN)
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, x := range col.floats {
+			if x < lo {
+				lo = x
+			}
+			if x > hi {
+				hi = x
+			}
+		}
+		if lo > hi {
+			return math.NaN(), math.NaN()
+		}
+		return lo, hi
+	case Bool:
+		lo, hi := true, false
+		for _, x := range col.bools {
+			lo, hi = lo && x, hi || x
+		}
+		return lo, hi
+	}
+	lo, hi := col.strings[0], col.strings[0]
+	for _, x := range col.strings {
+		if x < lo {
+			lo = x
+		}
+		if x > hi {
+			hi = x
+		}
+	}
+	if t == Bytes {
+		return []byte(lo), []byte(hi)
+	}
+	return lo, hi
+}
+
+// returns the encoded non-null values of a column.
+func encodeValues(c Column, col *columnData) ([]byte, error) {
+	switch c.Type {
+	case Uint:
+		return encodeUints(col.uints, c.Encoding)
+	case Int:
+		if c.Encoding == Delta {
+			var p []byte
+			var prev int64
+			for i, x := range col.ints {
+				d := x
+				if i > 0 {
+					d = x - prev
+					if d < -unum.Int64ValueBound || d >= unum.Int64ValueBound {
+						return nil, unum.ErrorMaxValue
+					}
+				}
+				p, _ = unum.AppendUnum64(p, unum.Zigzag64(d))
+				prev = x
+			}
+			return p, nil
+		}
+		us := make([]uint64, len(col.ints))
+		for i, x := range col.ints {
+			us[i] = unum.Zigzag64(x)
+		}
+		return encodeUints(us, c.Encoding)
+	case Float:
+		p := make([]byte, 8*len(col.floats))
+		for i, x := range col.floats {
+			binary.BigEndian.PutUint64(p[8*i:], math.Float64bits(x))
+		}
+		return p, nil
+	case Bool:
+		if c.Encoding == RLE {
+			us := make([]uint64, len(col.bools))
+			for i, x := range col.bools {
+				if x {
+					us[i] = 1
+				}
+			}
+			return encodeUints(us, RLE)
+		}
+		return bitmap(col.bools), nil
+	}
+	if c.Encoding == Dict {
+		enc := dict.NewStringEncoder(0)
+		for _, s := range col.strings {
+			enc.Append(s)
+		}
+		return enc.Bytes(), nil
+	}
+	var p []byte
+	for _, s := range col.strings {
+		p = appendString(p, s)
+	}
+	return p, nil
+}
+
+// returns the encoded values us (< 2^62) of a Uint column.
+func encodeUints(us []uint64, enc Encoding) ([]byte, error) {
+	switch enc {
+	case Delta:
+		p := make([]byte, len(us)*unum.Unum64Size)
+		n, e := unum.EncodeSignedDelta(p, us)
+		return p[:n], e
+	case RLE:
+		p := make([]byte, rle.SizeHybrid(us))
+		_, e := rle.EncodeHybrid(p, us)
+		return p, e
+	case Dict:
+		denc := dict.NewUint64Encoder(0)
+		for _, x := range us {
+			denc.Append(x)
+		}
+		return denc.Bytes(), nil
+	}
+	var p []byte
+	for _, x := range us {
+		p, _ = unum.AppendUnum64(p, x)
+	}
+	return p, nil
+}
+
+// appends the plain image of v of type t.
+func appendValue(dst []byte, t Type, v interface{}) []byte {
+	switch t {
+	case Uint:
+		dst, _ = unum.AppendUnum64(dst, v.(uint64))
+		return dst
+	case Int:
+		dst, _ = unum.AppendUnum64(dst, unum.Zigzag64(v.(int64)))
+		return dst
+	case Float:
+		return appendUint64(dst, math.Float64bits(v.(float64)))
+	case Bool:
+		if v.(bool) {
+			return append(dst, 1)
+		}
+		return append(dst, 0)
+	case String:
+		return appendString(dst, v.(string))
+	}
+	return appendString(dst, string(v.([]byte)))
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst, _ = unum.AppendUnum32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
+
+func appendUint32(dst []byte, v uint32) []byte {
+	return append(dst, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
+
+func appendUint64(dst []byte, v uint64) []byte {
+	return appendUint32(appendUint32(dst, uint32(v>>32)), uint32(v))
+}
@@ -0,0 +1,163 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package columnar_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"unum"
+	"unum/columnar"
+)
+
+var eventSchema = []columnar.Column{
+	{Name: "id", Type: columnar.Uint, Encoding: columnar.Delta},
+	{Name: "status", Type: columnar.Uint, Encoding: columnar.RLE},
+	{Name: "user", Type: columnar.Uint, Encoding: columnar.Dict, Nullable: true},
+	{Name: "offset", Type: columnar.Int, Encoding: columnar.Plain},
+	{Name: "ts", Type: columnar.Int, Encoding: columnar.Delta},
+	{Name: "score", Type: columnar.Float, Encoding: columnar.Plain, Nullable: true},
+	{Name: "ok", Type: columnar.Bool, Encoding: columnar.Plain},
+	{Name: "flag", Type: columnar.Bool, Encoding: columnar.RLE},
+	{Name: "path", Type: columnar.String, Encoding: columnar.Dict},
+	{Name: "note", Type: columnar.String, Encoding: columnar.Plain, Nullable: true},
+	{Name: "blob", Type: columnar.Bytes, Encoding: columnar.Plain, Nullable: true},
+	{Name: "tag", Type: columnar.Bytes, Encoding: columnar.Dict},
+	{Name: "level", Type: columnar.Int, Encoding: columnar.RLE},
+	{Name: "kind", Type: columnar.Int, Encoding: columnar.Dict},
+}
+
+// returns record i of the event schema.
+func event(i int) []interface{} {
+	var user, score, note, blob interface{}
+	if i%3 != 0 {
+		user = 1<<50 + uint64(i%7)
+	}
+	if i%5 != 0 {
+		score = float64(i) / 4
+	}
+	if i%2 == 0 {
+		note = fmt.Sprintf("note %d", i)
+	}
+	if i%11 != 0 {
+		blob = []byte{byte(i), byte(i >> 8)}
+	}
+	return []interface{}{
+		uint64(1000000 + 3*i),
+		uint64(200 + 100*(i/50%2)),
+		user,
+		int64(i%9 - 4),
+		int64(1458000000000 + 1000*int64(i)),
+		score,
+		i%4 != 0,
+		i > 500,
+		[]string{"/", "/index.html", "/api/v1"}[i%3],
+		note,
+		blob,
+		[]byte{"abc"[i%3]},
+		int64(-(i / 100)),
+		int64(i%2*1000 - 500),
+	}
+}
+
+func writeEvents(t testing.TB, n int) []byte {
+	w, e := columnar.NewWriter(eventSchema)
+	if e != nil {
+		t.Fatalf("error creating writer - e:%s\n", e.Error())
+	}
+	for i := 0; i < n; i++ {
+		if e := w.Append(event(i)...); e != nil {
+			t.Fatalf("error appending - i:%d e:%s\n", i, e.Error())
+		}
+	}
+	var buf bytes.Buffer
+	n0, e := w.WriteTo(&buf)
+	if e != nil {
+		t.Fatalf("error writing - e:%s\n", e.Error())
+	}
+	if int(n0) != buf.Len() {
+		t.Errorf("BUG - n:%d len:%d\n", n0, buf.Len())
+	}
+	return buf.Bytes()
+}
+
+func TestAppendErrors(t *testing.T) {
+	w, _ := columnar.NewWriter(eventSchema)
+	rec := event(1)
+	for _, tc := range []struct {
+		col      int
+		v        interface{}
+		expected error
+	}{
+		{0, nil, columnar.ErrorNull},
+		{0, 1, columnar.ErrorType},
+		{0, unum.Unum64ValueBound, unum.ErrorMaxValue},
+		{3, -unum.Int64ValueBound - 1, unum.ErrorMaxValue},
+		{5, float32(1), columnar.ErrorType},
+		{10, "bytes", columnar.ErrorType},
+	} {
+		r := append([]interface{}(nil), rec...)
+		r[tc.col] = tc.v
+		if e := w.Append(r...); e != tc.expected {
+			t.Errorf("expected %v - col:%d have:%v\n", tc.expected, tc.col, e)
+		}
+	}
+	if e := w.Append(rec[1:]...); e != columnar.ErrorArity {
+		t.Errorf("expected ErrorArity - have:%v\n", e)
+	}
+	if w.Len() != 0 {
+		t.Errorf("expected unchanged writer - len:%d\n", w.Len())
+	}
+	w.Append(rec...)
+	w.Reset()
+	if w.Len() != 0 {
+		t.Errorf("expected empty writer - len:%d\n", w.Len())
+	}
+}
+
+func TestDeltaRange(t *testing.T) {
+	w, _ := columnar.NewWriter([]columnar.Column{
+		{Name: "id", Type: columnar.Uint, Encoding: columnar.Delta, Nullable: true},
+		{Name: "ts", Type: columnar.Int, Encoding: columnar.Delta},
+	})
+	if e := w.Append(uint64(0), int64(-unum.Int64ValueBound)); e != nil {
+		t.Fatalf("error appending - e:%s\n", e.Error())
+	}
+	if e := w.Append(nil, int64(unum.Int64ValueBound-1)); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if e := w.Append(uint64(unum.Int64ValueBound), int64(-1)); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if e := w.Append(uint64(unum.Int64ValueBound-1), int64(-1)); e != nil {
+		t.Errorf("error appending - e:%s\n", e.Error())
+	}
+	if w.Len() != 2 {
+		t.Errorf("expected 2 records - have:%d\n", w.Len())
+	}
+	var buf bytes.Buffer
+	if _, e := w.WriteTo(&buf); e != nil {
+		t.Errorf("error writing - e:%s\n", e.Error())
+	}
+}
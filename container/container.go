@@ -0,0 +1,669 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package container implements a self-describing file format for streams
+// of unum encoded unsigned integers.
+//
+// A container is a header followed by a sequence of blocks, terminated by
+// an end block:
+//
+//      header:
+//      magic       4 bytes      "UNUM"
+//      version     1 byte       1
+//      codec       1 byte       Codec of the values
+//      metadata    UNUM-32      number of metadata entries, followed by
+//                               (key, value) entries, each a UNUM-32 length
+//                               followed by length bytes
+//      checksum    4 bytes      CRC32C of the above (big-endian)
+//
+//      block:
+//      flags       1 byte       bit 0: payload is DEFLATE compressed
+//      count       UNUM-64      number of values in the block; 0 for end block
+//      length      UNUM-64      length of the stored payload
+//      head sum    4 bytes      CRC32C of flags, count and length
+//      payload sum 4 bytes      CRC32C of payload
+//      payload     length bytes the unum encoded values
+//
+// The end block distinguishes a complete container from a truncated one.
+// The block length, verified by the head sum, allows a reader to skip a
+// block without reading its payload.
+package container
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"sort"
+	"unum"
+)
+
+// Codec identifies the unum encoding of the values of a container.
+type Codec uint8
+
+const (
+	CodecUnum16 Codec = 1
+	CodecUnum32 Codec = 2
+	CodecUnum64 Codec = 3
+)
+
+func (c Codec) String() string {
+	switch c {
+	case CodecUnum16:
+		return "UNUM-16"
+	case CodecUnum32:
+		return "UNUM-32"
+	case CodecUnum64:
+		return "UNUM-64"
+	}
+	return fmt.Sprintf("Codec(%d)", uint8(c))
+}
+
+// Returns the maximum encoded size of a value, or 0 for unknown codecs.
+func (c Codec) size() int {
+	switch c {
+	case CodecUnum16:
+		return unum.Unum16Size
+	case CodecUnum32:
+		return unum.Unum32Size
+	case CodecUnum64:
+		return unum.Unum64Size
+	}
+	return 0
+}
+
+func (c Codec) encode(b []byte, v uint64) (int, error) {
+	switch c {
+	case CodecUnum16:
+		if v >= uint64(unum.Unum16ValueBound) {
+			return 0, unum.ErrorMaxValue
+		}
+		return unum.EncodeUnum16(b, uint16(v))
+	case CodecUnum32:
+		if v >= uint64(unum.Unum32ValueBound) {
+			return 0, unum.ErrorMaxValue
+		}
+		return unum.EncodeUnum32(b, uint32(v))
+	}
+	return unum.EncodeUnum64(b, v)
+}
+
+func (c Codec) decode(b []byte) (uint64, int, error) {
+	switch c {
+	case CodecUnum16:
+		v, n, e := unum.DecodeUnum16(b)
+		return uint64(v), n, e
+	case CodecUnum32:
+		v, n, e := unum.DecodeUnum32(b)
+		return uint64(v), n, e
+	}
+	return unum.DecodeUnum64(b)
+}
+
+const (
+	Version = 1
+
+	// default number of values per block
+	DefaultBlockSize = 4096
+
+	// maximum number of values per block
+	MaxBlockSize = 1 << 20
+
+	flagDeflate = 0x01
+)
+
+var magic = [4]byte{'U', 'N', 'U', 'M'}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Errors
+var (
+	ErrorMagic     = fmt.Errorf("container.ErrorMagic")
+	ErrorVersion   = fmt.Errorf("container.ErrorVersion")
+	ErrorCodec     = fmt.Errorf("container.ErrorCodec")
+	ErrorChecksum  = fmt.Errorf("container.ErrorChecksum")
+	ErrorCorrupt   = fmt.Errorf("container.ErrorCorrupt")
+	ErrorTruncated = fmt.Errorf("container.ErrorTruncated")
+	ErrorClosed    = fmt.Errorf("container.ErrorClosed")
+)
+
+// Header describes a container.
+type Header struct {
+	Version  int
+	Codec    Codec
+	Metadata map[string]string
+}
+
+// ----------------------------------------------------------------------------
+// Writer
+// ----------------------------------------------------------------------------
+
+// Options of a Writer.
+type Options struct {
+	Codec     Codec             // defaults to CodecUnum64
+	Metadata  map[string]string // optional; written in key order
+	Compress  bool              // DEFLATE compress block payloads
+	BlockSize int               // values per block; defaults to DefaultBlockSize
+}
+
+// Writer writes a container.
+type Writer struct {
+	w      io.Writer
+	opts   Options
+	count  int          // values in current block
+	block  bytes.Buffer // current block payload
+	zbuf   bytes.Buffer // compressed payload
+	zw     *flate.Writer
+	closed bool
+	err    error // first write error; the output is then incomplete
+}
+
+// Returns a new Writer writing to w, after writing the container header.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCodec           -- invalid Options.Codec
+//    unum.ErrorMaxValue   -- invalid Options : BlockSize or Metadata too large
+//    <other>              -- propagated io.Writer error
+func NewWriter(w io.Writer, opts Options) (*Writer, error) {
+	if opts.Codec == 0 {
+		opts.Codec = CodecUnum64
+	}
+	if opts.Codec.size() == 0 {
+		return nil, ErrorCodec
+	}
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = DefaultBlockSize
+	}
+	if opts.BlockSize > MaxBlockSize {
+		return nil, unum.ErrorMaxValue
+	}
+
+	var h bytes.Buffer
+	h.Write(magic[:])
+	h.WriteByte(Version)
+	h.WriteByte(byte(opts.Codec))
+	keys := make([]string, 0, len(opts.Metadata))
+	for k := range opts.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if e := writeLen(&h, len(keys)); e != nil {
+		return nil, e
+	}
+	for _, k := range keys {
+		for _, s := range []string{k, opts.Metadata[k]} {
+			if len(s) > maxMetadataLen {
+				return nil, unum.ErrorMaxValue
+			}
+			if e := writeLen(&h, len(s)); e != nil {
+				return nil, e
+			}
+			h.WriteString(s)
+		}
+	}
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc32.Checksum(h.Bytes(), castagnoli))
+	h.Write(sum[:])
+
+	if _, e := w.Write(h.Bytes()); e != nil {
+		return nil, e
+	}
+	wr := &Writer{w: w, opts: opts}
+	if opts.Compress {
+		wr.zw, _ = flate.NewWriter(&wr.zbuf, flate.DefaultCompression)
+	}
+	return wr, nil
+}
+
+func writeLen(w io.Writer, n int) error {
+	if uint64(n) >= uint64(unum.Unum32ValueBound) {
+		return unum.ErrorMaxValue
+	}
+	_, e := unum.WriteUnum32(w, uint32(n))
+	return e
+}
+
+// Appends value v to the container. A block is written when BlockSize
+// values are pending.
+//
+// On error returns e:
+//    unum.ErrorMaxValue   -- invalid arg v : not encodable by Codec
+//    ErrorClosed          -- Writer is closed
+//    <other>              -- propagated io.Writer error, also returned by
+//                            all later calls
+func (w *Writer) Write(v uint64) error {
+	if w.closed {
+		return ErrorClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	var b [unum.Unum64Size]byte
+	n, e := w.opts.Codec.encode(b[:], v)
+	if e != nil {
+		return e
+	}
+	w.block.Write(b[:n])
+	w.count++
+	if w.count == w.opts.BlockSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Appends values vs to the container. See Write.
+func (w *Writer) WriteValues(vs []uint64) error {
+	for _, v := range vs {
+		if e := w.Write(v); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// Writes pending values, if any, as a block. See Write.
+func (w *Writer) Flush() error {
+	if w.closed {
+		return ErrorClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	if w.count == 0 {
+		return nil
+	}
+	var flags byte
+	payload := w.block.Bytes()
+	if w.zw != nil {
+		w.zbuf.Reset()
+		w.zw.Reset(&w.zbuf)
+		w.zw.Write(payload)
+		w.zw.Close()
+		if w.zbuf.Len() < len(payload) {
+			flags |= flagDeflate
+			payload = w.zbuf.Bytes()
+		}
+	}
+	if e := writeBlock(w.w, flags, uint64(w.count), payload); e != nil {
+		// (the block may be partially written)
+		w.err = e
+		return e
+	}
+	w.block.Reset()
+	w.count = 0
+	return nil
+}
+
+func writeBlock(w io.Writer, flags byte, count uint64, payload []byte) error {
+	var head [1 + 2*unum.Unum64Size + 2*4]byte
+	head[0] = flags
+	n := 1
+	n += encodeUnum64(head[n:], count)
+	n += encodeUnum64(head[n:], uint64(len(payload)))
+	binary.BigEndian.PutUint32(head[n:], crc32.Checksum(head[:n], castagnoli))
+	binary.BigEndian.PutUint32(head[n+4:], crc32.Checksum(payload, castagnoli))
+	n += 2 * 4
+	if _, e := w.Write(head[:n]); e != nil {
+		return e
+	}
+	_, e := w.Write(payload)
+	return e
+}
+
+// encodes v, asserted in range and with sufficient buffer
+func encodeUnum64(b []byte, v uint64) int {
+	n, e := unum.EncodeUnum64(b, v)
+	if e != nil {
+		panic("bug - asserted encodable")
+	}
+	return n
+}
+
+// Flushes pending values and writes the end block. Close does not close
+// the underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return ErrorClosed
+	}
+	if e := w.Flush(); e != nil {
+		return e
+	}
+	w.closed = true
+	return writeBlock(w.w, 0, 0, nil)
+}
+
+// ----------------------------------------------------------------------------
+// Reader
+// ----------------------------------------------------------------------------
+
+// BlockInfo describes a block.
+type BlockInfo struct {
+	Count      int  // number of values
+	Length     int  // stored payload length
+	Compressed bool // payload is DEFLATE compressed
+}
+
+// Reader reads a container.
+//
+//      r, e := container.NewReader(f)
+//      ..
+//      for {
+//              info, e := r.Next()
+//              if e == io.EOF {
+//                      break
+//              }
+//              ..
+//              values, e = r.Values(values[:0])
+//              ..
+//      }
+type Reader struct {
+	r      io.Reader
+	header Header
+	info   BlockInfo // current block
+	sum    uint32    // stored payload checksum of current block
+	unread bool      // current block payload is unread
+	done   bool      // end block read
+	buf    []byte
+	zbuf   bytes.Buffer
+	head   [2 * unum.Unum64Size]byte
+}
+
+// Returns a new Reader reading from r, after reading and verifying the
+// container header.
+//
+// On error returns (nil, e) where e is:
+//    ErrorMagic      -- r is not a container
+//    ErrorVersion    -- unsupported container version
+//    ErrorCodec      -- unsupported codec
+//    ErrorChecksum   -- header checksum mismatch
+//    ErrorTruncated  -- r ends before end of header
+//    <other>         -- propagated io.Reader error
+func NewReader(r io.Reader) (*Reader, error) {
+	var h bytes.Buffer
+	tr := io.TeeReader(r, &h)
+
+	var fixed [6]byte
+	if _, e := io.ReadFull(tr, fixed[:]); e != nil {
+		return nil, truncated(e)
+	}
+	if !bytes.Equal(fixed[:4], magic[:]) {
+		return nil, ErrorMagic
+	}
+	if fixed[4] != Version {
+		return nil, ErrorVersion
+	}
+	header := Header{Version: int(fixed[4]), Codec: Codec(fixed[5])}
+	if header.Codec.size() == 0 {
+		return nil, ErrorCodec
+	}
+
+	n, e := readLen(tr)
+	if e != nil {
+		return nil, e
+	}
+	if n > 0 {
+		header.Metadata = make(map[string]string)
+	}
+	for i := 0; i < n; i++ {
+		k, e := readString(tr)
+		if e != nil {
+			return nil, e
+		}
+		v, e := readString(tr)
+		if e != nil {
+			return nil, e
+		}
+		header.Metadata[k] = v
+	}
+
+	var sum [4]byte
+	if _, e := io.ReadFull(r, sum[:]); e != nil {
+		return nil, truncated(e)
+	}
+	if binary.BigEndian.Uint32(sum[:]) != crc32.Checksum(h.Bytes(), castagnoli) {
+		return nil, ErrorChecksum
+	}
+	return &Reader{r: r, header: header}, nil
+}
+
+// maximum length of metadata keys and values
+const maxMetadataLen = 1 << 16
+
+func readLen(r io.Reader) (int, error) {
+	v, _, e := unum.ReadUnum32(r)
+	if e != nil {
+		if e == unum.ErrorBufferEOF || e == unum.ErrorInvalidBuffer {
+			return 0, ErrorTruncated
+		}
+		return 0, e
+	}
+	return int(v), nil
+}
+
+func readString(r io.Reader) (string, error) {
+	n, e := readLen(r)
+	if e != nil {
+		return "", e
+	}
+	if n > maxMetadataLen {
+		return "", ErrorCorrupt
+	}
+	b := make([]byte, n)
+	if _, e := io.ReadFull(r, b); e != nil {
+		return "", truncated(e)
+	}
+	return string(b), nil
+}
+
+func truncated(e error) error {
+	if e == io.EOF || e == io.ErrUnexpectedEOF {
+		return ErrorTruncated
+	}
+	return e
+}
+
+// Returns the container header.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Advances to the next block, skipping the payload of the current block if
+// it was not read. Returns io.EOF after the end block.
+//
+// On error returns (BlockInfo{}, e) where e is:
+//    io.EOF          -- end of container
+//    ErrorTruncated  -- input ends before the end block
+//    ErrorChecksum   -- block head checksum mismatch
+//    ErrorCorrupt    -- invalid block head
+//    <other>         -- propagated io.Reader error
+func (r *Reader) Next() (BlockInfo, error) {
+	if r.unread {
+		if e := r.Skip(); e != nil {
+			return BlockInfo{}, e
+		}
+	}
+	if r.done {
+		return BlockInfo{}, io.EOF
+	}
+
+	var flags [1]byte
+	if _, e := io.ReadFull(r.r, flags[:]); e != nil {
+		return BlockInfo{}, truncated(e)
+	}
+	if flags[0]&^flagDeflate != 0 {
+		return BlockInfo{}, ErrorCorrupt
+	}
+	count, n0, e := r.readUnum64(0)
+	if e != nil {
+		return BlockInfo{}, e
+	}
+	length, n1, e := r.readUnum64(n0)
+	if e != nil {
+		return BlockInfo{}, e
+	}
+	var sums [2 * 4]byte
+	if _, e := io.ReadFull(r.r, sums[:]); e != nil {
+		return BlockInfo{}, truncated(e)
+	}
+	sum := crc32.Update(crc32.Checksum(flags[:], castagnoli), castagnoli, r.head[:n0+n1])
+	if sum != binary.BigEndian.Uint32(sums[:4]) {
+		return BlockInfo{}, ErrorChecksum
+	}
+	r.sum = binary.BigEndian.Uint32(sums[4:])
+
+	if count == 0 {
+		if length != 0 || flags[0] != 0 || r.sum != 0 {
+			return BlockInfo{}, ErrorCorrupt
+		}
+		r.done = true
+		return BlockInfo{}, io.EOF
+	}
+	// stored length is bound by the uncompressed length (plus deflate overhead)
+	maxLength := count * uint64(r.header.Codec.size())
+	if count > MaxBlockSize || length > maxLength+maxLength/8+64 {
+		return BlockInfo{}, ErrorCorrupt
+	}
+
+	r.info = BlockInfo{Count: int(count), Length: int(length), Compressed: flags[0]&flagDeflate != 0}
+	r.unread = true
+	return r.info, nil
+}
+
+// reads a UNUM-64 value into r.head at offset off.
+func (r *Reader) readUnum64(off int) (uint64, int, error) {
+	if _, e := io.ReadFull(r.r, r.head[off:off+1]); e != nil {
+		return 0, 0, truncated(e)
+	}
+	vlen := 1 << (r.head[off] >> 6)
+	if _, e := io.ReadFull(r.r, r.head[off+1:off+vlen]); e != nil {
+		return 0, 0, truncated(e)
+	}
+	v, _, e := unum.DecodeUnum64(r.head[off : off+vlen])
+	return v, vlen, e
+}
+
+// Skips the payload of the current block without reading or verifying it.
+// Its length was verified by Next.
+// If the underlying reader is an io.Seeker, the payload is seeked over.
+//
+// On error returns e:
+//    ErrorTruncated  -- input ends before end of block
+//    <other>         -- propagated io.Reader (or io.Seeker) error
+func (r *Reader) Skip() error {
+	if !r.unread {
+		return nil
+	}
+	r.unread = false
+	length := int64(r.info.Length)
+	if s, ok := r.r.(io.Seeker); ok {
+		cur, e := s.Seek(0, io.SeekCurrent)
+		if e != nil {
+			return e
+		}
+		end, e := s.Seek(0, io.SeekEnd)
+		if e != nil {
+			return e
+		}
+		if end-cur < length {
+			return ErrorTruncated
+		}
+		_, e = s.Seek(cur+length, io.SeekStart)
+		return e
+	}
+	n, e := io.CopyN(io.Discard, r.r, length)
+	if n < length {
+		return truncated(e)
+	}
+	return nil
+}
+
+// Reads, verifies and decodes the values of the current block, appending
+// them to dst.
+//
+// On error returns (dst, e) where e is:
+//    io.EOF          -- no current block (or payload already read)
+//    ErrorTruncated  -- input ends before end of block
+//    ErrorChecksum   -- block checksum mismatch
+//    ErrorCorrupt    -- payload does not decode to Count values
+//    <other>         -- propagated io.Reader error
+func (r *Reader) Values(dst []uint64) ([]uint64, error) {
+	if !r.unread {
+		return dst, io.EOF
+	}
+	r.unread = false
+
+	if cap(r.buf) < r.info.Length {
+		r.buf = make([]byte, r.info.Length)
+	}
+	payload := r.buf[:r.info.Length]
+	if _, e := io.ReadFull(r.r, payload); e != nil {
+		return dst, truncated(e)
+	}
+	if crc32.Checksum(payload, castagnoli) != r.sum {
+		return dst, ErrorChecksum
+	}
+
+	if r.info.Compressed {
+		limit := int64(r.info.Count * r.header.Codec.size())
+		r.zbuf.Reset()
+		zr := flate.NewReader(bytes.NewReader(payload))
+		n, e := io.Copy(&r.zbuf, io.LimitReader(zr, limit+1))
+		zr.Close()
+		if e != nil || n > limit {
+			return dst, ErrorCorrupt
+		}
+		payload = r.zbuf.Bytes()
+	}
+
+	codec := r.header.Codec
+	start := len(dst)
+	for i := 0; i < r.info.Count; i++ {
+		v, n, e := codec.decode(payload)
+		if e != nil {
+			return dst[:start], ErrorCorrupt
+		}
+		dst = append(dst, v)
+		payload = payload[n:]
+	}
+	if len(payload) != 0 {
+		return dst[:start], ErrorCorrupt
+	}
+	return dst, nil
+}
+
+// Reads all remaining values of the container, appending them to dst.
+func (r *Reader) ReadAll(dst []uint64) ([]uint64, error) {
+	for {
+		if _, e := r.Next(); e == io.EOF {
+			return dst, nil
+		} else if e != nil {
+			return dst, e
+		}
+		var e error
+		if dst, e = r.Values(dst); e != nil {
+			return dst, e
+		}
+	}
+}
@@ -0,0 +1,288 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package container_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+	"unum"
+	"unum/container"
+)
+
+func testValues(n int, bound uint64) []uint64 {
+	r := rand.New(rand.NewSource(int64(n)))
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = uint64(r.Int63n(int64(bound))) >> uint(r.Intn(40))
+		if i%7 == 0 {
+			vs[i] = uint64(i % 5) // compressible runs
+		}
+	}
+	return vs
+}
+
+func write(t *testing.T, opts container.Options, vs []uint64) []byte {
+	var buf bytes.Buffer
+	w, e := container.NewWriter(&buf, opts)
+	if e != nil {
+		t.Fatalf("error creating writer - e:%s\n", e.Error())
+	}
+	if e := w.WriteValues(vs); e != nil {
+		t.Fatalf("error writing - e:%s\n", e.Error())
+	}
+	if e := w.Close(); e != nil {
+		t.Fatalf("error closing - e:%s\n", e.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	codecs := []struct {
+		codec container.Codec
+		bound uint64
+	}{
+		{container.CodecUnum16, uint64(unum.Unum16ValueBound)},
+		{container.CodecUnum32, uint64(unum.Unum32ValueBound)},
+		{container.CodecUnum64, unum.Unum64ValueBound},
+	}
+	for _, c := range codecs {
+		for _, compress := range []bool{false, true} {
+			for _, n := range []int{0, 1, 99, 100, 1001} {
+				vs := testValues(n, c.bound)
+				opts := container.Options{
+					Codec:     c.codec,
+					Compress:  compress,
+					BlockSize: 100,
+					Metadata:  map[string]string{"source": "test", "n": "x"},
+				}
+				b := write(t, opts, vs)
+
+				r, e := container.NewReader(bytes.NewReader(b))
+				if e != nil {
+					t.Fatalf("error creating reader - e:%s\n", e.Error())
+				}
+				h := r.Header()
+				if h.Codec != c.codec || h.Version != container.Version || !reflect.DeepEqual(h.Metadata, opts.Metadata) {
+					t.Errorf("unexpected header - have:%v\n", h)
+				}
+				vs0, e := r.ReadAll(nil)
+				if e != nil {
+					t.Fatalf("error reading - codec:%s n:%d - e:%s\n", c.codec, n, e.Error())
+				}
+				if len(vs0) != len(vs) || (n > 0 && !reflect.DeepEqual(vs0, vs)) {
+					t.Errorf("BUG - codec:%s compress:%t n:%d - values differ\n", c.codec, compress, n)
+				}
+			}
+		}
+	}
+}
+
+func TestCompression(t *testing.T) {
+	vs := make([]uint64, 10000)
+	for i := range vs {
+		vs[i] = uint64(1<<20 + i%3)
+	}
+	plain := write(t, container.Options{}, vs)
+	compressed := write(t, container.Options{Compress: true}, vs)
+	if len(compressed) >= len(plain)/4 {
+		t.Errorf("expected compression - plain:%d compressed:%d\n", len(plain), len(compressed))
+	}
+}
+
+// hides io.Seeker
+type reader struct{ io.Reader }
+
+func TestSkip(t *testing.T) {
+	vs := testValues(1000, unum.Unum64ValueBound)
+	b := write(t, container.Options{BlockSize: 100, Compress: true}, vs)
+
+	for _, src := range []io.Reader{bytes.NewReader(b), reader{bytes.NewReader(b)}} {
+		r, e := container.NewReader(src)
+		if e != nil {
+			t.Fatalf("error creating reader - e:%s\n", e.Error())
+		}
+		var blocks int
+		for {
+			info, e := r.Next()
+			if e == io.EOF {
+				break
+			} else if e != nil {
+				t.Fatalf("error reading block - e:%s\n", e.Error())
+			}
+			if info.Count != 100 {
+				t.Errorf("unexpected block count - have:%d\n", info.Count)
+			}
+			// read every third block; skip the others implicitly or explicitly
+			switch blocks % 3 {
+			case 0:
+				vs0, e := r.Values(nil)
+				if e != nil {
+					t.Fatalf("error reading values - e:%s\n", e.Error())
+				}
+				if !reflect.DeepEqual(vs0, vs[blocks*100:(blocks+1)*100]) {
+					t.Errorf("BUG - block:%d - values differ\n", blocks)
+				}
+			case 1:
+				if e := r.Skip(); e != nil {
+					t.Fatalf("error skipping - e:%s\n", e.Error())
+				}
+			}
+			blocks++
+		}
+		if blocks != 10 {
+			t.Errorf("expected 10 blocks - have:%d\n", blocks)
+		}
+	}
+}
+
+func TestTruncation(t *testing.T) {
+	b := write(t, container.Options{BlockSize: 10, Metadata: map[string]string{"k": "v"}}, testValues(35, 1<<20))
+	for i := 0; i < len(b); i++ {
+		r, e := container.NewReader(bytes.NewReader(b[:i]))
+		if e == nil {
+			_, e = r.ReadAll(nil)
+		}
+		if e != container.ErrorTruncated {
+			t.Errorf("expected ErrorTruncated - len:%d have:%v\n", i, e)
+		}
+	}
+}
+
+func TestCorruption(t *testing.T) {
+	vs := testValues(50, 1<<20)
+	b := write(t, container.Options{BlockSize: 10}, vs)
+	headerLen := 4 + 1 + 1 + 1 + 4
+
+	for i := 0; i < len(b); i++ {
+		c := append([]byte(nil), b...)
+		c[i] ^= 0x01
+		r, e := container.NewReader(bytes.NewReader(c))
+		if e == nil {
+			var vs0 []uint64
+			vs0, e = r.ReadAll(nil)
+			if e == nil && reflect.DeepEqual(vs0, vs) {
+				t.Errorf("undetected corruption - offset:%d\n", i)
+			}
+		}
+		if i < headerLen {
+			if e != container.ErrorMagic && e != container.ErrorVersion && e != container.ErrorCodec &&
+				e != container.ErrorChecksum && e != container.ErrorCorrupt && e != container.ErrorTruncated {
+				t.Errorf("unexpected header error - offset:%d have:%v\n", i, e)
+			}
+		} else if e == nil {
+			t.Errorf("undetected corruption - offset:%d\n", i)
+		}
+	}
+
+	// payload corruption is detected per block
+	c := append([]byte(nil), b...)
+	c[len(c)-14] ^= 0xff // last block payload
+	r, _ := container.NewReader(bytes.NewReader(c))
+	var good []uint64
+	var e error
+	for {
+		if _, e = r.Next(); e != nil {
+			break
+		}
+		if good, e = r.Values(good); e != nil {
+			break
+		}
+	}
+	if e != container.ErrorChecksum || len(good) != 40 {
+		t.Errorf("expected ErrorChecksum after 40 values - have:%v values:%d\n", e, len(good))
+	}
+
+	// a block that does not decode to its count appends nothing
+	c = append([]byte(nil), b...)
+	c[headerLen+1]++                              // first block count, 10 in one byte
+	_, n, _ := unum.DecodeUnum64(c[headerLen+2:]) // payload length
+	n += 2
+	binary.BigEndian.PutUint32(c[headerLen+n:], crc32.Checksum(c[headerLen:headerLen+n], crc32.MakeTable(crc32.Castagnoli)))
+	r, _ = container.NewReader(bytes.NewReader(c))
+	if _, e := r.Next(); e != nil {
+		t.Fatalf("error reading block - e:%s\n", e.Error())
+	}
+	if vs0, e := r.Values([]uint64{7}); e != container.ErrorCorrupt || len(vs0) != 1 {
+		t.Errorf("expected ErrorCorrupt and dst - have:%v values:%d\n", e, len(vs0))
+	}
+
+	// head corruption is detected before the length is used
+	c = append([]byte(nil), b...)
+	c[headerLen+2] ^= 0x01 // first block length
+	r, _ = container.NewReader(bytes.NewReader(c))
+	if _, e := r.Next(); e != container.ErrorChecksum {
+		t.Errorf("expected ErrorChecksum - have:%v\n", e)
+	}
+}
+
+// failWriter fails writes after n bytes.
+type failWriter struct {
+	n int
+}
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		return 0, io.ErrShortWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestErrors(t *testing.T) {
+	if _, e := container.NewWriter(&bytes.Buffer{}, container.Options{Codec: 9}); e != container.ErrorCodec {
+		t.Errorf("expected ErrorCodec - have:%v\n", e)
+	}
+	var buf bytes.Buffer
+	w, _ := container.NewWriter(&buf, container.Options{Codec: container.CodecUnum16})
+	if e := w.Write(uint64(unum.Unum16ValueBound)); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	w.Close()
+	if e := w.Write(1); e != container.ErrorClosed {
+		t.Errorf("expected ErrorClosed - have:%v\n", e)
+	}
+	// write errors are sticky
+	fw := &failWriter{n: 64}
+	w, _ = container.NewWriter(fw, container.Options{BlockSize: 100})
+	if e := w.WriteValues(testValues(100, 1<<40)); e != io.ErrShortWrite {
+		t.Errorf("expected io.ErrShortWrite - have:%v\n", e)
+	}
+	if e := w.Flush(); e != io.ErrShortWrite {
+		t.Errorf("expected sticky io.ErrShortWrite from Flush - have:%v\n", e)
+	}
+	if e := w.Close(); e != io.ErrShortWrite {
+		t.Errorf("expected sticky io.ErrShortWrite from Close - have:%v\n", e)
+	}
+	if _, e := container.NewReader(bytes.NewReader([]byte("UNIX\x01\x03\x00\x00\x00\x00\x00"))); e != container.ErrorMagic {
+		t.Errorf("expected ErrorMagic - have:%v\n", e)
+	}
+	if _, e := container.NewReader(bytes.NewReader([]byte("UNUM\x02\x03\x00\x00\x00\x00\x00"))); e != container.ErrorVersion {
+		t.Errorf("expected ErrorVersion - have:%v\n", e)
+	}
+}
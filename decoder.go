@@ -0,0 +1,115 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum
+
+// BufferDecoder decodes successive values of a buffer, retaining the first
+// error: once a value fails to decode, the remaining calls return zero
+// values and Err returns the error. A sequence of fields can then be
+// decoded with a single error check.
+//
+//      var d unum.BufferDecoder
+//      d.Reset(b)
+//      count, size := d.Unum64(), d.Unum32()
+//      data := d.Next(int(size))
+//      if d.Err() != nil {
+//              ..
+//      }
+type BufferDecoder struct {
+	b []byte
+	e error
+}
+
+// Returns a new BufferDecoder of buffer b.
+func NewBufferDecoder(b []byte) *BufferDecoder {
+	return &BufferDecoder{b: b}
+}
+
+// Resets the decoder to decode buffer b, clearing its error.
+func (d *BufferDecoder) Reset(b []byte) {
+	d.b, d.e = b, nil
+}
+
+// Returns the first error, or nil.
+func (d *BufferDecoder) Err() error {
+	return d.e
+}
+
+// Sets the error e, unless the decoder has an error.
+func (d *BufferDecoder) Fail(e error) {
+	if d.e == nil {
+		d.e = e
+	}
+}
+
+// Returns the undecoded bytes of the buffer.
+func (d *BufferDecoder) Rest() []byte {
+	return d.b
+}
+
+// Returns the next UNUM-16 value. See DecodeUnum16.
+func (d *BufferDecoder) Unum16() uint16 {
+	if d.e != nil {
+		return 0
+	}
+	v, n, e := DecodeUnum16(d.b)
+	d.b, d.e = d.b[n:], e
+	return v
+}
+
+// Returns the next UNUM-32 value. See DecodeUnum32.
+func (d *BufferDecoder) Unum32() uint32 {
+	if d.e != nil {
+		return 0
+	}
+	v, n, e := DecodeUnum32(d.b)
+	d.b, d.e = d.b[n:], e
+	return v
+}
+
+// Returns the next UNUM-64 value. See DecodeUnum64.
+func (d *BufferDecoder) Unum64() uint64 {
+	if d.e != nil {
+		return 0
+	}
+	v, n, e := DecodeUnum64(d.b)
+	d.b, d.e = d.b[n:], e
+	return v
+}
+
+// Returns the next n bytes of the buffer, a slice of the buffer with
+// capacity n.
+//
+// On error (ErrorBufferEOF if n < 0 or n > len(Rest())) returns nil.
+func (d *BufferDecoder) Next(n int) []byte {
+	if d.e != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.b) {
+		d.e = ErrorBufferEOF
+		return nil
+	}
+	p := d.b[:n:n]
+	d.b = d.b[n:]
+	return p
+}
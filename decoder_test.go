@@ -0,0 +1,74 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum_test
+
+import (
+	"testing"
+	"unum"
+)
+
+func TestBufferDecoder(t *testing.T) {
+	b, _ := unum.AppendUnum16(nil, 300)
+	b, _ = unum.AppendUnum32(b, 70000)
+	b, _ = unum.AppendUnum64(b, 1<<40)
+	b = append(b, "abc"...)
+
+	d := unum.NewBufferDecoder(b)
+	if v := d.Unum16(); v != 300 {
+		t.Errorf("Unum16 - expected:300 have:%d\n", v)
+	}
+	if v := d.Unum32(); v != 70000 {
+		t.Errorf("Unum32 - expected:70000 have:%d\n", v)
+	}
+	if v := d.Unum64(); v != 1<<40 {
+		t.Errorf("Unum64 - expected:%d have:%d\n", uint64(1<<40), v)
+	}
+	if p := d.Next(2); string(p) != "ab" || cap(p) != 2 || string(d.Rest()) != "c" {
+		t.Errorf("Next - have:%q rest:%q\n", p, d.Rest())
+	}
+	if d.Err() != nil {
+		t.Fatalf("unexpected error - e:%s\n", d.Err().Error())
+	}
+
+	// the first error is retained
+	if p := d.Next(2); p != nil || d.Err() != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", d.Err())
+	}
+	d.Fail(unum.ErrorInvalidBuffer)
+	if v := d.Unum64(); v != 0 || d.Err() != unum.ErrorBufferEOF || string(d.Rest()) != "c" {
+		t.Errorf("expected retained ErrorBufferEOF - have:%v\n", d.Err())
+	}
+
+	d.Reset(b[:1])
+	if d.Err() != nil {
+		t.Errorf("expected no error after Reset - have:%v\n", d.Err())
+	}
+	if d.Unum16(); d.Err() != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", d.Err())
+	}
+	d.Reset(b)
+	if d.Next(-1); d.Err() != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", d.Err())
+	}
+}
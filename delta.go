@@ -0,0 +1,268 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum
+
+import (
+	"io"
+)
+
+// Delta encoding of sequences
+//
+// A delta encoded sequence is the UNUM-64 image of its first value followed
+// by the UNUM-64 images of the differences of successive values. Sorted
+// sequences of large values (timestamps, ids, offsets) typically have small
+// differences that fit the 1 and 2 byte size classes.
+//
+// The (unsigned) delta encoding requires non-decreasing sequences. The
+// signed delta encoding accepts any sequence, encoding the differences with
+// the zigzag UNUM-64 (signed) encoding; differences must be in [-2^61, 2^61).
+// A sequence must be decoded with the encoding used to encode it.
+//
+// Decoded values must be within the UNUM-64 value bound; decoders report
+// ErrorDeltaOverflow if the cumulative sum of a sequence exceeds it.
+
+// returns the delta image of v following prev.
+func deltaOf(prev, v uint64, signed bool) (uint64, error) {
+	if signed {
+		d := int64(v - prev)
+		if d < -Int64ValueBound || d >= Int64ValueBound {
+			return 0, ErrorMaxValue
+		}
+		return Zigzag64(d), nil
+	}
+	if v < prev {
+		return 0, ErrorDeltaOrder
+	}
+	return v - prev, nil
+}
+
+// returns the value of delta image d following prev.
+func undelta(prev, d uint64, signed bool) (uint64, error) {
+	v := prev + d
+	if signed {
+		v = prev + uint64(Unzigzag64(d))
+	}
+	if v >= Unum64ValueBound {
+		return 0, ErrorDeltaOverflow
+	}
+	return v, nil
+}
+
+func encodeDelta(b []byte, vs []uint64, signed bool) (n int, e error) {
+	var prev uint64
+	for i, v := range vs {
+		if v >= Unum64ValueBound {
+			return 0, ErrorMaxValue
+		}
+		d := v
+		if i > 0 {
+			if d, e = deltaOf(prev, v, signed); e != nil {
+				return 0, e
+			}
+		}
+		n0, e := EncodeUnum64(b[n:], d)
+		if e != nil {
+			return 0, e
+		}
+		n += n0
+		prev = v
+	}
+	return n, nil
+}
+
+func decodeDelta(b []byte, vs []uint64, signed bool) (n int, e error) {
+	var prev uint64
+	for i := range vs {
+		d, n0, e := DecodeUnum64(b[n:])
+		if e != nil {
+			return 0, e
+		}
+		v := d
+		if i > 0 {
+			if v, e = undelta(prev, d, signed); e != nil {
+				return 0, e
+			}
+		}
+		vs[i] = v
+		prev = v
+		n += n0
+	}
+	return n, nil
+}
+
+// Delta encodes the non-decreasing sequence vs in buffer b.
+// returns number of bytes written on nil error.
+//
+// On error returns (0, e) where e is:
+//    ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    ErrorMaxValue        -- invalid arg vs : value >= 2^62
+//    ErrorDeltaOrder      -- invalid arg vs : sequence is decreasing
+func EncodeDelta(b []byte, vs []uint64) (n int, e error) {
+	return encodeDelta(b, vs, false)
+}
+
+// Signed delta encodes the sequence vs in buffer b.
+// returns number of bytes written on nil error.
+//
+// On error returns (0, e) where e is:
+//    ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    ErrorMaxValue        -- invalid arg vs : value >= 2^62, or difference
+//                            out of signed range
+func EncodeSignedDelta(b []byte, vs []uint64) (n int, e error) {
+	return encodeDelta(b, vs, true)
+}
+
+// decodes len(vs) values of a delta encoded sequence from input buffer b
+// into vs. returns number of bytes n read, if there are no errors.
+//
+// On error returns (0, e) where e is:
+//    ErrorBufferEOF       -- invalid arg b : too short for len(vs) values
+//    ErrorInvalidBuffer   -- invalid arg b : non-conformant byte sequence
+//    ErrorDeltaOverflow   -- invalid arg b : decoded value >= 2^62
+func DecodeDelta(b []byte, vs []uint64) (n int, e error) {
+	return decodeDelta(b, vs, false)
+}
+
+// decodes len(vs) values of a signed delta encoded sequence from input
+// buffer b into vs. See DecodeDelta.
+func DecodeSignedDelta(b []byte, vs []uint64) (n int, e error) {
+	return decodeDelta(b, vs, true)
+}
+
+// Returns the delta encoded size of the non-decreasing sequence vs, or 0
+// if vs is not encodable.
+func SizeDelta(vs []uint64) (n int) {
+	var prev uint64
+	for i, v := range vs {
+		d := v
+		if i > 0 {
+			if v < prev {
+				return 0
+			}
+			d = v - prev
+		}
+		n0 := SizeUnum64(d)
+		if n0 == 0 || v >= Unum64ValueBound {
+			return 0
+		}
+		n += n0
+		prev = v
+	}
+	return n
+}
+
+// DeltaEncoder writes a delta encoded sequence to an io.Writer.
+type DeltaEncoder struct {
+	w       io.Writer
+	signed  bool
+	started bool
+	prev    uint64
+}
+
+// Returns a new DeltaEncoder of non-decreasing sequences, writing to w.
+func NewDeltaEncoder(w io.Writer) *DeltaEncoder {
+	return &DeltaEncoder{w: w}
+}
+
+// Returns a new signed DeltaEncoder, writing to w.
+func NewSignedDeltaEncoder(w io.Writer) *DeltaEncoder {
+	return &DeltaEncoder{w: w, signed: true}
+}
+
+// Writes the delta image of value v, the next value of the sequence.
+// Returns number of bytes written 'n' (n > 0) if there are no errors.
+// The encoder state is unchanged on error.
+//
+// On error returns (0, e) where e is:
+//    ErrorMaxValue        -- invalid arg v : v >= 2^62, or signed difference
+//                            out of range
+//    ErrorDeltaOrder      -- invalid arg v : v < previous value
+//    <other>              -- propagated io.Writer.Write error
+func (enc *DeltaEncoder) Encode(v uint64) (n int, e error) {
+	if v >= Unum64ValueBound {
+		return 0, ErrorMaxValue
+	}
+	d := v
+	if enc.started {
+		if d, e = deltaOf(enc.prev, v, enc.signed); e != nil {
+			return 0, e
+		}
+	}
+	if n, e = WriteUnum64(enc.w, d); e != nil {
+		return n, e
+	}
+	enc.started, enc.prev = true, v
+	return n, nil
+}
+
+// Resets the encoder to begin a new sequence.
+func (enc *DeltaEncoder) Reset() {
+	enc.started, enc.prev = false, 0
+}
+
+// DeltaDecoder reads a delta encoded sequence from an io.Reader.
+type DeltaDecoder struct {
+	r       io.Reader
+	signed  bool
+	started bool
+	prev    uint64
+}
+
+// Returns a new DeltaDecoder of non-decreasing sequences, reading from r.
+func NewDeltaDecoder(r io.Reader) *DeltaDecoder {
+	return &DeltaDecoder{r: r}
+}
+
+// Returns a new signed DeltaDecoder, reading from r.
+func NewSignedDeltaDecoder(r io.Reader) *DeltaDecoder {
+	return &DeltaDecoder{r: r, signed: true}
+}
+
+// Reads the next value of the sequence.
+// Returns value v, number of bytes read n (n > 0), if there are no errors.
+//
+// On error returns (0, n, e) where e is:
+//    ErrorBufferEOF       -- end of sequence
+//    ErrorInvalidBuffer   -- non-conformant byte sequence
+//    ErrorDeltaOverflow   -- decoded value >= 2^62
+//    <other>              -- propagated io.Reader.Read error
+func (dec *DeltaDecoder) Decode() (v uint64, n int, e error) {
+	d, n, e := ReadUint(dec.r)
+	if e != nil {
+		return 0, n, e
+	}
+	v = d
+	if dec.started {
+		if v, e = undelta(dec.prev, d, dec.signed); e != nil {
+			return 0, n, e
+		}
+	}
+	dec.started, dec.prev = true, v
+	return v, n, nil
+}
+
+// Resets the decoder to begin a new sequence.
+func (dec *DeltaDecoder) Reset() {
+	dec.started, dec.prev = false, 0
+}
@@ -0,0 +1,212 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum_test
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"testing"
+	"testing/quick"
+	"unum"
+)
+
+func sortedValues(r *rand.Rand, n int) []uint64 {
+	vs := make([]uint64, n)
+	v := uint64(r.Int63n(1 << 50))
+	for i := range vs {
+		vs[i] = v
+		v += uint64(r.Intn(100))
+	}
+	return vs
+}
+
+func TestCodecDelta(t *testing.T) {
+	f := func(seed int64, n uint8) bool {
+		vs := sortedValues(rand.New(rand.NewSource(seed)), int(n))
+		size := unum.SizeDelta(vs)
+		b := make([]byte, size)
+		nb, e := unum.EncodeDelta(b, vs)
+		if e != nil || nb != size {
+			t.Errorf("error encoding - size:%d n:%d e:%v\n", size, nb, e)
+			return false
+		}
+		// after the first value each delta takes 1 or 2 bytes
+		if n > 0 && size > unum.Unum64Size+2*(int(n)-1) {
+			t.Errorf("unexpected size - n:%d size:%d\n", n, size)
+		}
+		vs0 := make([]uint64, len(vs))
+		nb0, e := unum.DecodeDelta(b, vs0)
+		if e != nil || nb0 != nb {
+			t.Errorf("error decoding - n:%d e:%v\n", nb0, e)
+			return false
+		}
+		for i := range vs {
+			if vs[i] != vs0[i] {
+				t.Errorf("BUG - index:%d v:%d v0:%d\n", i, vs[i], vs0[i])
+				return false
+			}
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestCodecSignedDelta(t *testing.T) {
+	f := func(vs []uint64) bool {
+		for i := range vs {
+			vs[i] >>= 3
+		}
+		b := make([]byte, len(vs)*unum.Unum64Size)
+		n, e := unum.EncodeSignedDelta(b, vs)
+		if e != nil {
+			t.Errorf("error encoding - e:%s\n", e.Error())
+			return false
+		}
+		vs0 := make([]uint64, len(vs))
+		if _, e := unum.DecodeSignedDelta(b[:n], vs0); e != nil {
+			t.Errorf("error decoding - e:%s\n", e.Error())
+			return false
+		}
+		for i := range vs {
+			if vs[i] != vs0[i] {
+				t.Errorf("BUG - index:%d v:%d v0:%d\n", i, vs[i], vs0[i])
+				return false
+			}
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestDeltaErrors(t *testing.T) {
+	b := make([]byte, 64)
+	if _, e := unum.EncodeDelta(b, []uint64{5, 4}); e != unum.ErrorDeltaOrder {
+		t.Errorf("expected ErrorDeltaOrder - have:%v\n", e)
+	}
+	if _, e := unum.EncodeDelta(b, []uint64{unum.Unum64ValueBound}); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if _, e := unum.EncodeSignedDelta(b, []uint64{unum.Unum64ValueBound - 1, 0}); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue for signed difference - have:%v\n", e)
+	}
+	if _, e := unum.EncodeDelta(b[:3], []uint64{1, 1 << 20}); e != unum.ErrorBufferOverflow {
+		t.Errorf("expected ErrorBufferOverflow - have:%v\n", e)
+	}
+	if unum.SizeDelta([]uint64{2, 1}) != 0 {
+		t.Errorf("expected 0 size for decreasing sequence\n")
+	}
+
+	// cumulative overflow
+	n, _ := unum.EncodeUnum64(b, unum.Unum64ValueBound-1)
+	n0, _ := unum.EncodeUnum64(b[n:], 1)
+	vs := make([]uint64, 2)
+	if _, e := unum.DecodeDelta(b[:n+n0], vs); e != unum.ErrorDeltaOverflow {
+		t.Errorf("expected ErrorDeltaOverflow - have:%v\n", e)
+	}
+	b[0] = 5
+	n0, _ = unum.EncodeInt64(b[1:], -6)
+	if _, e := unum.DecodeSignedDelta(b[:1+n0], vs); e != unum.ErrorDeltaOverflow {
+		t.Errorf("expected ErrorDeltaOverflow for negative value - have:%v\n", e)
+	}
+	if _, e := unum.DecodeDelta([]byte{1}, vs); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+}
+
+func TestDeltaEncoderDecoder(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	sorted := sortedValues(r, 1000)
+	unsorted := make([]uint64, 1000)
+	for i := range unsorted {
+		unsorted[i] = uint64(r.Int63n(1 << 40))
+	}
+	sort.Slice(unsorted[:500], func(i, j int) bool { return unsorted[i] < unsorted[j] })
+
+	for _, test := range []struct {
+		signed bool
+		vs     []uint64
+	}{{false, sorted}, {true, sorted}, {true, unsorted}} {
+		var buf bytes.Buffer
+		enc := unum.NewDeltaEncoder(&buf)
+		dec := unum.NewDeltaDecoder(&buf)
+		if test.signed {
+			enc = unum.NewSignedDeltaEncoder(&buf)
+			dec = unum.NewSignedDeltaDecoder(&buf)
+		}
+		for _, v := range test.vs {
+			if _, e := enc.Encode(v); e != nil {
+				t.Fatalf("error encoding - v:%d e:%s\n", v, e.Error())
+			}
+		}
+		for i, v := range test.vs {
+			v0, _, e := dec.Decode()
+			if e != nil || v0 != v {
+				t.Fatalf("BUG - signed:%t index:%d v:%d v0:%d e:%v\n", test.signed, i, v, v0, e)
+			}
+		}
+		if _, _, e := dec.Decode(); e != unum.ErrorBufferEOF {
+			t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+		}
+	}
+
+	// rejected values leave the encoder unchanged
+	var buf bytes.Buffer
+	enc := unum.NewDeltaEncoder(&buf)
+	enc.Encode(10)
+	if _, e := enc.Encode(9); e != unum.ErrorDeltaOrder {
+		t.Errorf("expected ErrorDeltaOrder - have:%v\n", e)
+	}
+	enc.Encode(12)
+	enc.Reset()
+	enc.Encode(3)
+	if !bytes.Equal(buf.Bytes(), []byte{10, 2, 3}) {
+		t.Errorf("unexpected image - have:%v\n", buf.Bytes())
+	}
+}
+
+func BenchmarkEncodeDelta(b *testing.B) {
+	vs := sortedValues(rand.New(rand.NewSource(1)), 1024)
+	buf := make([]byte, unum.SizeDelta(vs))
+	b.SetBytes(int64(8 * len(vs)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		unum.EncodeDelta(buf, vs)
+	}
+}
+
+func BenchmarkDecodeDelta(b *testing.B) {
+	vs := sortedValues(rand.New(rand.NewSource(1)), 1024)
+	buf := make([]byte, unum.SizeDelta(vs))
+	unum.EncodeDelta(buf, vs)
+	b.SetBytes(int64(8 * len(vs)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		unum.DecodeDelta(buf, vs)
+	}
+}
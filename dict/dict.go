@@ -0,0 +1,611 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package dict implements dictionary encoding of low cardinality columns.
+//
+// A dictionary encoded block stores each distinct value once, and each
+// value of the column as its (small) code, the index of the value in the
+// dictionary. Codes are UNUM-16 for dictionaries of up to 2^15 entries and
+// UNUM-32 otherwise. Encoders fall back to plain encoding of the values if
+// the dictionary exceeds its maximum number of entries, or if the plain
+// image is smaller. The block is:
+//
+//      header:
+//      encoding    UNUM-16         EncodingPlain, EncodingDict16, EncodingDict32
+//      count       UNUM-64         number of values
+//
+//      plain:
+//      values      [count]value
+//
+//      dictionary:
+//      entries     UNUM-32         number of dictionary entries
+//      dictionary  [entries]value
+//      codes       [count]code     UNUM-16 or UNUM-32 per encoding
+//
+// uint64 values are UNUM-64 encoded, and string values are a UNUM-32
+// length followed by the bytes of the string.
+package dict
+
+import (
+	"fmt"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorBlock = fmt.Errorf("dict.ErrorBlock")
+	ErrorCode  = fmt.Errorf("dict.ErrorCode")
+)
+
+// Block encodings
+const (
+	EncodingPlain  = 0
+	EncodingDict16 = 1
+	EncodingDict32 = 2
+)
+
+const (
+	// DefaultMaxEntries is the maximum dictionary size of encoders
+	// created with maxEntries <= 0.
+	DefaultMaxEntries = 1 << 16
+	// MaxEntries is the bound of dictionary sizes.
+	MaxEntries = int(unum.Unum32ValueBound - 1)
+	// bound of dictionary sizes with UNUM-16 codes.
+	maxEntries16 = int(unum.Unum16ValueBound)
+)
+
+// returns the max entries arg of constructors in (0, MaxEntries].
+func maxEntriesArg(n int) int {
+	if n <= 0 {
+		return DefaultMaxEntries
+	}
+	if n > MaxEntries {
+		return MaxEntries
+	}
+	return n
+}
+
+// returns the encoding of codes of a dictionary of n entries.
+func codeEncoding(n int) int {
+	if n <= maxEntries16 {
+		return EncodingDict16
+	}
+	return EncodingDict32
+}
+
+// returns the size of code c in encoding enc.
+func sizeCode(c uint32, enc int) int {
+	if enc == EncodingDict16 {
+		return unum.SizeUnum16(uint16(c))
+	}
+	return unum.SizeUnum32(c)
+}
+
+func appendCode(dst []byte, c uint32, enc int) []byte {
+	if enc == EncodingDict16 {
+		dst, _ = unum.AppendUnum16(dst, uint16(c))
+	} else {
+		dst, _ = unum.AppendUnum32(dst, c)
+	}
+	return dst
+}
+
+func decodeCode(b []byte, enc int) (c uint32, n int, e error) {
+	if enc == EncodingDict16 {
+		c16, n, e := unum.DecodeUnum16(b)
+		return uint32(c16), n, e
+	}
+	return unum.DecodeUnum32(b)
+}
+
+func appendHeader(dst []byte, enc, count int) []byte {
+	dst, _ = unum.AppendUnum16(dst, uint16(enc))
+	dst, _ = unum.AppendUnum64(dst, uint64(count))
+	return dst
+}
+
+func appendString(dst []byte, s string) []byte {
+	dst, _ = unum.AppendUnum32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
+
+func sizeString(s string) int {
+	return unum.SizeUnum32(uint32(len(s))) + len(s)
+}
+
+// header of a decoded block.
+type header struct {
+	enc     int
+	count   int
+	entries int
+	b       []byte // remaining input
+}
+
+// decodes the block header, and (for dictionary blocks) the entry count.
+func readHeader(b []byte) (h header, e error) {
+	enc, n, e := unum.DecodeUnum16(b)
+	if e != nil || enc > EncodingDict32 {
+		return h, ErrorBlock
+	}
+	count, n0, e := unum.DecodeUnum64(b[n:])
+	if e != nil || count > uint64(len(b)) {
+		return h, ErrorBlock
+	}
+	n += n0
+	h.enc, h.count = int(enc), int(count)
+	if h.enc != EncodingPlain {
+		entries, n0, e := unum.DecodeUnum32(b[n:])
+		if e != nil || entries > uint32(len(b)) {
+			return h, ErrorBlock
+		}
+		n += n0
+		h.entries = int(entries)
+	}
+	h.b = b[n:]
+	return h, nil
+}
+
+// returns the next code of a dictionary block with entries entries.
+func (h *header) nextCode() (int, error) {
+	c, n, e := decodeCode(h.b, h.enc)
+	if e != nil {
+		return 0, unum.ErrorInvalidBuffer
+	}
+	if int(c) >= h.entries {
+		return 0, ErrorCode
+	}
+	h.b = h.b[n:]
+	return int(c), nil
+}
+
+
+// ----------------------------------------------------------------------------
+// encoder & decoder
+// ----------------------------------------------------------------------------
+
+// values is the column type specific part of an encoder: the dictionary
+// entries or, once the dictionary is abandoned, the plain values.
+type values interface {
+	len() int
+	// returns the encoded size of value i.
+	size(i int) int
+	// appends encoded value i to dst.
+	appendValue(dst []byte, i int) []byte
+	// replaces the values by the values of the dictionary codes.
+	expand(codes []uint32)
+}
+
+// encoder is the column type independent part of the encoders.
+type encoder struct {
+	max   int
+	vals  values
+	codes []uint32
+	plain bool // dictionary abandoned
+}
+
+// adds a value not in the dictionary, which the caller then appends to
+// vals. Returns false if the values are plain encoded, abandoning the
+// dictionary if it is full.
+func (enc *encoder) add() bool {
+	if enc.plain {
+		return false
+	}
+	n := enc.vals.len()
+	if n == enc.max {
+		enc.vals.expand(enc.codes)
+		enc.codes, enc.plain = nil, true
+		return false
+	}
+	enc.codes = append(enc.codes, uint32(n))
+	return true
+}
+
+// Returns the number of values appended.
+func (enc *encoder) Len() int {
+	if enc.plain {
+		return enc.vals.len()
+	}
+	return len(enc.codes)
+}
+
+// Returns the number of dictionary entries, or -1 if the dictionary has
+// exceeded its maximum size.
+func (enc *encoder) Cardinality() int {
+	if enc.plain {
+		return -1
+	}
+	return enc.vals.len()
+}
+
+// Returns the encoded block, using the smaller of the dictionary and plain
+// encodings.
+func (enc *encoder) Bytes() []byte {
+	vs := enc.vals
+	if enc.plain {
+		b := appendHeader(nil, EncodingPlain, vs.len())
+		for i := 0; i < vs.len(); i++ {
+			b = vs.appendValue(b, i)
+		}
+		return b
+	}
+	ce := codeEncoding(vs.len())
+	sizes := make([]int, vs.len())
+	dsize := unum.SizeUnum32(uint32(vs.len()))
+	for i := range sizes {
+		sizes[i] = vs.size(i)
+		dsize += sizes[i]
+	}
+	var psize int
+	for _, c := range enc.codes {
+		psize += sizes[c]
+		dsize += sizeCode(c, ce)
+	}
+	if psize <= dsize {
+		b := appendHeader(make([]byte, 0, psize+unum.Unum64Size+1), EncodingPlain, len(enc.codes))
+		for _, c := range enc.codes {
+			b = vs.appendValue(b, int(c))
+		}
+		return b
+	}
+	b := appendHeader(make([]byte, 0, dsize+unum.Unum64Size+1), ce, len(enc.codes))
+	b, _ = unum.AppendUnum32(b, uint32(vs.len()))
+	for i := 0; i < vs.len(); i++ {
+		b = vs.appendValue(b, i)
+	}
+	for _, c := range enc.codes {
+		b = appendCode(b, c, ce)
+	}
+	return b
+}
+
+// resets the column independent state, with an empty dictionary.
+func (enc *encoder) reset() {
+	enc.codes, enc.plain = enc.codes[:0], false
+}
+
+// decoder is the column type independent part of the decoders.
+type decoder struct {
+	h header
+	i int
+}
+
+// Returns the encoding of the block.
+func (d *decoder) Encoding() int {
+	return d.h.enc
+}
+
+// Returns the number of values in the block.
+func (d *decoder) Len() int {
+	return d.h.count
+}
+
+// Returns the dictionary code of the next value. See Uint64Decoder.Next.
+//
+// On error returns (0, e) where e is:
+//    ErrorBlock               -- block is plain encoded
+//    <other>                  -- errors of Next
+func (d *decoder) NextCode() (int, error) {
+	if d.h.enc == EncodingPlain {
+		return 0, ErrorBlock
+	}
+	if d.i == d.h.count {
+		return 0, unum.ErrorBufferEOF
+	}
+	c, e := d.h.nextCode()
+	if e != nil {
+		return 0, e
+	}
+	d.i++
+	return c, nil
+}
+
+// ----------------------------------------------------------------------------
+// uint64 columns
+// ----------------------------------------------------------------------------
+
+type uint64Values []uint64
+
+func (vs *uint64Values) len() int       { return len(*vs) }
+func (vs *uint64Values) size(i int) int { return unum.SizeUnum64((*vs)[i]) }
+
+func (vs *uint64Values) appendValue(dst []byte, i int) []byte {
+	dst, _ = unum.AppendUnum64(dst, (*vs)[i])
+	return dst
+}
+
+func (vs *uint64Values) expand(codes []uint32) {
+	p := make(uint64Values, len(codes), 2*len(codes))
+	for i, c := range codes {
+		p[i] = (*vs)[c]
+	}
+	*vs = p
+}
+
+// Uint64Encoder dictionary encodes a column of uint64 values.
+type Uint64Encoder struct {
+	encoder
+	index  map[uint64]uint32 // nil once the dictionary is abandoned
+	values uint64Values
+}
+
+// Returns a new Uint64Encoder with dictionaries of at most maxEntries
+// entries (DefaultMaxEntries if maxEntries <= 0).
+func NewUint64Encoder(maxEntries int) *Uint64Encoder {
+	enc := &Uint64Encoder{index: make(map[uint64]uint32)}
+	enc.max, enc.vals = maxEntriesArg(maxEntries), &enc.values
+	return enc
+}
+
+// Appends value v to the column.
+//
+// On error returns e:
+//    unum.ErrorMaxValue   -- invalid arg v : v >= 2^62
+func (enc *Uint64Encoder) Append(v uint64) error {
+	if v >= unum.Unum64ValueBound {
+		return unum.ErrorMaxValue
+	}
+	if c, ok := enc.index[v]; ok {
+		enc.codes = append(enc.codes, c)
+		return nil
+	}
+	if enc.add() {
+		enc.index[v] = uint32(len(enc.values))
+	} else {
+		enc.index = nil
+	}
+	enc.values = append(enc.values, v)
+	return nil
+}
+
+// Resets the encoder to begin a new column, with an empty dictionary.
+func (enc *Uint64Encoder) Reset() {
+	enc.index = make(map[uint64]uint32)
+	enc.values = enc.values[:0]
+	enc.reset()
+}
+
+// Uint64Decoder decodes a block of uint64 values produced by Uint64Encoder.
+type Uint64Decoder struct {
+	decoder
+	dict []uint64
+}
+
+// Returns a new Uint64Decoder for block b, decoding its dictionary (if any).
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock              -- invalid block header
+//    unum.ErrorInvalidBuffer -- non-conformant dictionary
+func NewUint64Decoder(b []byte) (*Uint64Decoder, error) {
+	h, e := readHeader(b)
+	if e != nil {
+		return nil, e
+	}
+	d := &Uint64Decoder{decoder: decoder{h: h}}
+	if h.enc != EncodingPlain {
+		d.dict = make([]uint64, h.entries)
+		for i := range d.dict {
+			v, n, e := unum.DecodeUnum64(d.h.b)
+			if e != nil {
+				return nil, unum.ErrorInvalidBuffer
+			}
+			d.dict[i] = v
+			d.h.b = d.h.b[n:]
+		}
+	}
+	return d, nil
+}
+
+// Returns the dictionary of the block, or nil for plain blocks.
+// The dictionary must not be modified.
+func (d *Uint64Decoder) Dictionary() []uint64 {
+	return d.dict
+}
+
+// Returns the next value.
+//
+// On error returns (0, e) where e is:
+//    unum.ErrorBufferEOF      -- end of block
+//    unum.ErrorInvalidBuffer  -- non-conformant block
+//    ErrorCode                -- code not in dictionary
+func (d *Uint64Decoder) Next() (uint64, error) {
+	if d.h.enc != EncodingPlain {
+		c, e := d.NextCode()
+		if e != nil {
+			return 0, e
+		}
+		return d.dict[c], nil
+	}
+	if d.i == d.h.count {
+		return 0, unum.ErrorBufferEOF
+	}
+	v, n, e := unum.DecodeUnum64(d.h.b)
+	if e != nil {
+		return 0, unum.ErrorInvalidBuffer
+	}
+	d.h.b = d.h.b[n:]
+	d.i++
+	return v, nil
+}
+
+// Returns the values of block b, appended to dst.
+func DecodeUint64s(dst []uint64, b []byte) ([]uint64, error) {
+	d, e := NewUint64Decoder(b)
+	if e != nil {
+		return dst, e
+	}
+	for i := 0; i < d.h.count; i++ {
+		v, e := d.Next()
+		if e != nil {
+			return dst, e
+		}
+		dst = append(dst, v)
+	}
+	return dst, nil
+}
+
+// ----------------------------------------------------------------------------
+// string columns
+// ----------------------------------------------------------------------------
+
+type stringValues []string
+
+func (vs *stringValues) len() int       { return len(*vs) }
+func (vs *stringValues) size(i int) int { return sizeString((*vs)[i]) }
+
+func (vs *stringValues) appendValue(dst []byte, i int) []byte {
+	return appendString(dst, (*vs)[i])
+}
+
+func (vs *stringValues) expand(codes []uint32) {
+	p := make(stringValues, len(codes), 2*len(codes))
+	for i, c := range codes {
+		p[i] = (*vs)[c]
+	}
+	*vs = p
+}
+
+// StringEncoder dictionary encodes a column of string values.
+type StringEncoder struct {
+	encoder
+	index  map[string]uint32 // nil once the dictionary is abandoned
+	values stringValues
+}
+
+// Returns a new StringEncoder with dictionaries of at most maxEntries
+// entries (DefaultMaxEntries if maxEntries <= 0).
+func NewStringEncoder(maxEntries int) *StringEncoder {
+	enc := &StringEncoder{index: make(map[string]uint32)}
+	enc.max, enc.vals = maxEntriesArg(maxEntries), &enc.values
+	return enc
+}
+
+// Appends value s to the column.
+//
+// On error returns e:
+//    unum.ErrorMaxValue   -- invalid arg s : len(s) >= 2^30
+func (enc *StringEncoder) Append(s string) error {
+	if len(s) >= int(unum.Unum32ValueBound) {
+		return unum.ErrorMaxValue
+	}
+	if c, ok := enc.index[s]; ok {
+		enc.codes = append(enc.codes, c)
+		return nil
+	}
+	if enc.add() {
+		enc.index[s] = uint32(len(enc.values))
+	} else {
+		enc.index = nil
+	}
+	enc.values = append(enc.values, s)
+	return nil
+}
+
+// Resets the encoder to begin a new column, with an empty dictionary.
+func (enc *StringEncoder) Reset() {
+	enc.index = make(map[string]uint32)
+	enc.values = enc.values[:0]
+	enc.reset()
+}
+
+// StringDecoder decodes a block of string values produced by StringEncoder.
+type StringDecoder struct {
+	decoder
+	dict []string
+}
+
+// returns the next string of b.
+func decodeString(b []byte) (s string, n int, e error) {
+	l, n, e := unum.DecodeUnum32(b)
+	if e != nil || len(b)-n < int(l) {
+		return "", 0, unum.ErrorInvalidBuffer
+	}
+	return string(b[n : n+int(l)]), n + int(l), nil
+}
+
+// Returns a new StringDecoder for block b, decoding its dictionary (if any).
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock              -- invalid block header
+//    unum.ErrorInvalidBuffer -- non-conformant dictionary
+func NewStringDecoder(b []byte) (*StringDecoder, error) {
+	h, e := readHeader(b)
+	if e != nil {
+		return nil, e
+	}
+	d := &StringDecoder{decoder: decoder{h: h}}
+	if h.enc != EncodingPlain {
+		d.dict = make([]string, h.entries)
+		for i := range d.dict {
+			s, n, e := decodeString(d.h.b)
+			if e != nil {
+				return nil, e
+			}
+			d.dict[i] = s
+			d.h.b = d.h.b[n:]
+		}
+	}
+	return d, nil
+}
+
+// Returns the dictionary of the block, or nil for plain blocks.
+// The dictionary must not be modified.
+func (d *StringDecoder) Dictionary() []string {
+	return d.dict
+}
+
+// Returns the next value. See Uint64Decoder.Next.
+func (d *StringDecoder) Next() (string, error) {
+	if d.h.enc != EncodingPlain {
+		c, e := d.NextCode()
+		if e != nil {
+			return "", e
+		}
+		return d.dict[c], nil
+	}
+	if d.i == d.h.count {
+		return "", unum.ErrorBufferEOF
+	}
+	s, n, e := decodeString(d.h.b)
+	if e != nil {
+		return "", e
+	}
+	d.h.b = d.h.b[n:]
+	d.i++
+	return s, nil
+}
+
+// Returns the values of block b, appended to dst.
+func DecodeStrings(dst []string, b []byte) ([]string, error) {
+	d, e := NewStringDecoder(b)
+	if e != nil {
+		return dst, e
+	}
+	for i := 0; i < d.h.count; i++ {
+		s, e := d.Next()
+		if e != nil {
+			return dst, e
+		}
+		dst = append(dst, s)
+	}
+	return dst, nil
+}
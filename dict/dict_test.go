@@ -0,0 +1,192 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package dict_test
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"unum"
+	"unum/dict"
+)
+
+// column of n values with (about) k distinct 8-byte ids
+func testIds(n, k int) []uint64 {
+	r := rand.New(rand.NewSource(int64(n * k)))
+	ids := make([]uint64, k)
+	for i := range ids {
+		ids[i] = 1<<40 + uint64(r.Int63n(1<<60))
+	}
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = ids[r.Intn(k)]
+	}
+	return vs
+}
+
+func TestUint64Codec(t *testing.T) {
+	for _, tc := range []struct {
+		n, k, max int
+		enc       int
+	}{
+		{0, 1, 0, dict.EncodingPlain},
+		{1000, 1, 0, dict.EncodingDict16},
+		{10000, 300, 0, dict.EncodingDict16},
+		{100000, 100000, 0, dict.EncodingDict32},
+		{1000, 300, 100, dict.EncodingPlain},
+	} {
+		vs := testIds(tc.n, tc.k)
+		enc := dict.NewUint64Encoder(tc.max)
+		for _, v := range vs {
+			if e := enc.Append(v); e != nil {
+				t.Fatalf("error appending - e:%s\n", e.Error())
+			}
+		}
+		if enc.Len() != len(vs) {
+			t.Errorf("expected len %d - have:%d\n", len(vs), enc.Len())
+		}
+		block := enc.Bytes()
+		d, e := dict.NewUint64Decoder(block)
+		if e != nil {
+			t.Fatalf("error creating decoder - e:%s\n", e.Error())
+		}
+		if d.Encoding() != tc.enc {
+			t.Errorf("n:%d k:%d - expected encoding %d - have:%d\n", tc.n, tc.k, tc.enc, d.Encoding())
+		}
+		vs0, e := dict.DecodeUint64s(nil, block)
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		if len(vs0) != len(vs) {
+			t.Fatalf("expected %d values - have:%d\n", len(vs), len(vs0))
+		}
+		for i := range vs {
+			if vs[i] != vs0[i] {
+				t.Fatalf("BUG - index:%d v:%d v0:%d\n", i, vs[i], vs0[i])
+			}
+		}
+		if tc.enc == dict.EncodingDict16 && len(block) > 2*tc.n+9*tc.k+16 {
+			t.Errorf("dictionary block not compact - n:%d k:%d len:%d\n", tc.n, tc.k, len(block))
+		}
+	}
+}
+
+func TestUint64Encoder(t *testing.T) {
+	enc := dict.NewUint64Encoder(2)
+	enc.Append(10)
+	enc.Append(20)
+	enc.Append(10)
+	if c := enc.Cardinality(); c != 2 {
+		t.Errorf("expected cardinality 2 - have:%d\n", c)
+	}
+	enc.Append(30)
+	if c := enc.Cardinality(); c != -1 {
+		t.Errorf("expected cardinality -1 - have:%d\n", c)
+	}
+	vs, _ := dict.DecodeUint64s(nil, enc.Bytes())
+	if fmt.Sprint(vs) != "[10 20 10 30]" {
+		t.Errorf("BUG - vs:%v\n", vs)
+	}
+	enc.Reset()
+	if enc.Len() != 0 || enc.Cardinality() != 0 {
+		t.Errorf("expected empty encoder - len:%d cardinality:%d\n", enc.Len(), enc.Cardinality())
+	}
+	if e := enc.Append(unum.Unum64ValueBound); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+}
+
+func TestStringCodec(t *testing.T) {
+	statuses := []string{"", "ok", "pending", "failed", "retrying", "cancelled by user"}
+	r := rand.New(rand.NewSource(1))
+	vs := make([]string, 5000)
+	for i := range vs {
+		vs[i] = statuses[r.Intn(len(statuses))]
+	}
+	enc := dict.NewStringEncoder(0)
+	for _, s := range vs {
+		enc.Append(s)
+	}
+	if c := enc.Cardinality(); c != len(statuses) {
+		t.Errorf("expected cardinality %d - have:%d\n", len(statuses), c)
+	}
+	block := enc.Bytes()
+	if len(block) > len(vs)+64 {
+		t.Errorf("dictionary block not compact - len:%d\n", len(block))
+	}
+	d, e := dict.NewStringDecoder(block)
+	if e != nil {
+		t.Fatalf("error creating decoder - e:%s\n", e.Error())
+	}
+	if d.Encoding() != dict.EncodingDict16 || len(d.Dictionary()) != len(statuses) {
+		t.Errorf("unexpected block - encoding:%d dictionary:%q\n", d.Encoding(), d.Dictionary())
+	}
+	for i := range vs {
+		c, e := d.NextCode()
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		if d.Dictionary()[c] != vs[i] {
+			t.Fatalf("BUG - index:%d s:%q s0:%q\n", i, vs[i], d.Dictionary()[c])
+		}
+	}
+	if _, e := d.Next(); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+
+	// unique values fall back to plain
+	enc.Reset()
+	for i := 0; i < 100; i++ {
+		enc.Append(fmt.Sprintf("user-%d", i))
+	}
+	vs0, e := dict.DecodeStrings(nil, enc.Bytes())
+	if e != nil || len(vs0) != 100 || vs0[42] != "user-42" {
+		t.Errorf("BUG - e:%v vs:%q\n", e, vs0)
+	}
+	d, _ = dict.NewStringDecoder(enc.Bytes())
+	if _, e := d.NextCode(); e != dict.ErrorBlock {
+		t.Errorf("expected ErrorBlock - have:%v\n", e)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	enc := dict.NewStringEncoder(0)
+	for i := 0; i < 100; i++ {
+		enc.Append([]string{"a", "b"}[i%2])
+	}
+	block := enc.Bytes()
+	if _, e := dict.DecodeStrings(nil, block[:len(block)-1]); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+	// code 2 of a 2 entry dictionary
+	block[len(block)-1] = 2
+	if _, e := dict.DecodeStrings(nil, block); e != dict.ErrorCode {
+		t.Errorf("expected ErrorCode - have:%v\n", e)
+	}
+	for _, b := range [][]byte{{}, {3, 0}, {1, 100}} {
+		if _, e := dict.NewUint64Decoder(b); e != dict.ErrorBlock {
+			t.Errorf("expected ErrorBlock - b:%x have:%v\n", b, e)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package diff implements a binary delta (patch) format of copy, add and
+// run instructions, in the manner of VCDIFF (RFC 3284).
+//
+// A patch transforms a source into a target. Diff finds blocks of the
+// target in the source and runs of a repeated byte, and encodes the target
+// as instructions to copy a range of the source, add literal bytes, or
+// repeat a byte. Patches carry the length and CRC32C checksum of both the
+// source and the target, and Apply verifies them. The image is:
+//
+//      magic       4 bytes     "UDIF"
+//      source      UNUM-64     length of source
+//      checksum    4 bytes     CRC32C of source (big-endian)
+//      target      UNUM-64     length of target
+//      checksum    4 bytes     CRC32C of target (big-endian)
+//      instructions:  until the target length is produced
+//      op          UNUM-64     length << 2 | Op
+//      data        Add:  [length]byte  literal bytes
+//                  Copy: UNUM-64       offset in source
+//                  Run:  1 byte        repeated byte
+package diff
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorMagic   = fmt.Errorf("diff.ErrorMagic")
+	ErrorCorrupt = fmt.Errorf("diff.ErrorCorrupt")
+	ErrorRange   = fmt.Errorf("diff.ErrorRange")
+	ErrorSource  = fmt.Errorf("diff.ErrorSource")
+	ErrorTarget  = fmt.Errorf("diff.ErrorTarget")
+)
+
+// Op is the kind of an instruction.
+type Op uint8
+
+const (
+	Add Op = iota
+	Copy
+	Run
+)
+
+// Instruction produces Length bytes of the target.
+type Instruction struct {
+	Op     Op
+	Length int
+	Offset int    // Copy: offset in source
+	Data   []byte // Add: literal bytes
+	Byte   byte   // Run: repeated byte
+}
+
+const (
+	magic = "UDIF"
+	// length of blocks of the source index, and minimum copy length
+	blockSize = 16
+	// minimum run length
+	minRun = 16
+	// buffer size of Apply
+	bufferSize = 32 << 10
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// rolling hash multiplier, and its power blockSize-1
+const prime = 16777619
+
+var primePow = func() uint32 {
+	p := uint32(1)
+	for i := 1; i < blockSize; i++ {
+		p *= prime
+	}
+	return p
+}()
+
+// Returns the hash of the block b.
+func hash(b []byte) uint32 {
+	var h uint32
+	for _, c := range b[:blockSize] {
+		h = h*prime + uint32(c)
+	}
+	return h
+}
+
+// Returns the length of the run of b[0] at b.
+func runLength(b []byte) int {
+	n := 1
+	for n < len(b) && b[n] == b[0] {
+		n++
+	}
+	return n
+}
+
+// Returns the instructions producing target from source.
+//
+// Blocks of the source at multiples of the block size are indexed by hash,
+// and the target is scanned with a rolling hash for matching blocks, which
+// are extended forward and backward into Copy instructions. Runs of a
+// repeated byte are Run instructions, and the remaining bytes Add
+// instructions, referencing target.
+func Instructions(source, target []byte) []Instruction {
+	index := make(map[uint32]int, len(source)/blockSize)
+	for i := 0; i+blockSize <= len(source); i += blockSize {
+		h := hash(source[i:])
+		if _, ok := index[h]; !ok {
+			index[h] = i
+		}
+	}
+	var ins []Instruction
+	add := 0 // start of pending Add
+	flush := func(end int) {
+		if end > add {
+			ins = append(ins, Instruction{Op: Add, Length: end - add, Data: target[add:end]})
+		}
+	}
+	var h uint32
+	hashed := false
+	for i := 0; i < len(target); {
+		if i+1 < len(target) && target[i] == target[i+1] {
+			if n := runLength(target[i:]); n >= minRun {
+				flush(i)
+				ins = append(ins, Instruction{Op: Run, Length: n, Byte: target[i]})
+				i += n
+				add, hashed = i, false
+				continue
+			}
+		}
+		if i+blockSize > len(target) {
+			break
+		}
+		if !hashed {
+			h, hashed = hash(target[i:]), true
+		}
+		if j, ok := index[h]; ok && bytes.Equal(source[j:j+blockSize], target[i:i+blockSize]) {
+			n := blockSize
+			for j+n < len(source) && i+n < len(target) && source[j+n] == target[i+n] {
+				n++
+			}
+			k := 0
+			for i-k > add && j-k > 0 && source[j-k-1] == target[i-k-1] {
+				k++
+			}
+			flush(i - k)
+			ins = append(ins, Instruction{Op: Copy, Length: n + k, Offset: j - k})
+			i += n
+			add, hashed = i, false
+			continue
+		}
+		if i+blockSize < len(target) {
+			h = (h-uint32(target[i])*primePow)*prime + uint32(target[i+blockSize])
+		}
+		i++
+	}
+	flush(len(target))
+	return ins
+}
+
+// Returns the patch producing target from source.
+func Diff(source, target []byte) []byte {
+	ins := Instructions(source, target)
+	var sum [4]byte
+	b := make([]byte, 0, 32+len(target)/4)
+	b = append(b, magic...)
+	for _, p := range [][]byte{source, target} {
+		b, _ = unum.AppendUnum64(b, uint64(len(p)))
+		binary.BigEndian.PutUint32(sum[:], crc32.Checksum(p, castagnoli))
+		b = append(b, sum[:]...)
+	}
+	for _, in := range ins {
+		b, _ = unum.AppendUnum64(b, uint64(in.Length)<<2|uint64(in.Op))
+		switch in.Op {
+		case Add:
+			b = append(b, in.Data...)
+		case Copy:
+			b, _ = unum.AppendUnum64(b, uint64(in.Offset))
+		case Run:
+			b = append(b, in.Byte)
+		}
+	}
+	return b
+}
+
+// Returns the target of applying patch to source. See Apply.
+func Patch(source, patch []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, e := Apply(&buf, bytes.NewReader(source), int64(len(source)), bytes.NewReader(patch)); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+// checksumWriter writes to w, and computes the checksum and length of the
+// bytes written.
+type checksumWriter struct {
+	w   io.Writer
+	n   int64
+	crc uint32
+}
+
+func (cw *checksumWriter) Write(p []byte) (int, error) {
+	n, e := cw.w.Write(p)
+	cw.crc = crc32.Update(cw.crc, castagnoli, p[:n])
+	cw.n += int64(n)
+	return n, e
+}
+
+// Applies patch to the source of size bytes at r, writing the target to w,
+// and returns the number of bytes written. The source is verified before,
+// and the target after, the target is written. Memory is bounded: the
+// patch, source and target are streamed through fixed size buffers.
+//
+// On error returns (n, e) where e is:
+//    ErrorMagic    -- invalid arg patch : not a patch
+//    ErrorCorrupt  -- invalid arg patch : non-conformant (or truncated) patch
+//    ErrorRange    -- invalid arg patch : copy outside the source
+//    ErrorSource   -- invalid arg r : source length or checksum mismatch
+//    ErrorTarget   -- target checksum mismatch
+//    <other>       -- propagated io error
+func Apply(w io.Writer, r io.ReaderAt, size int64, patch io.Reader) (n int64, e error) {
+	br := bufio.NewReader(patch)
+	var h [4]byte
+	if _, e := io.ReadFull(br, h[:]); e != nil || string(h[:]) != magic {
+		return 0, ErrorMagic
+	}
+	srcLen, srcSum, e := readHeader(br)
+	if e != nil {
+		return 0, e
+	}
+	dstLen, dstSum, e := readHeader(br)
+	if e != nil {
+		return 0, e
+	}
+	if srcLen != size {
+		return 0, ErrorSource
+	}
+	buf := make([]byte, bufferSize)
+	sum := crc32.New(castagnoli)
+	if _, e := io.CopyBuffer(sum, io.NewSectionReader(r, 0, size), buf); e != nil {
+		return 0, e
+	}
+	if sum.Sum32() != srcSum {
+		return 0, ErrorSource
+	}
+
+	cw := &checksumWriter{w: w}
+	for cw.n < dstLen {
+		op, _, e := unum.ReadUint(br)
+		if e != nil {
+			return cw.n, ErrorCorrupt
+		}
+		length := int64(op >> 2)
+		if length == 0 || length > dstLen-cw.n {
+			return cw.n, ErrorCorrupt
+		}
+		switch Op(op & 3) {
+		case Add:
+			var m int64
+			if m, e = io.CopyBuffer(cw, io.LimitReader(br, length), buf); e == nil && m < length {
+				return cw.n, ErrorCorrupt
+			}
+		case Copy:
+			offset, _, e0 := unum.ReadUint(br)
+			if e0 != nil {
+				return cw.n, ErrorCorrupt
+			}
+			if offset > uint64(size) || length > size-int64(offset) {
+				return cw.n, ErrorRange
+			}
+			var m int64
+			if m, e = io.CopyBuffer(cw, io.NewSectionReader(r, int64(offset), length), buf); e == nil && m < length {
+				return cw.n, ErrorSource
+			}
+		case Run:
+			c, e0 := br.ReadByte()
+			if e0 != nil {
+				return cw.n, ErrorCorrupt
+			}
+			fill := buf
+			if length < int64(len(fill)) {
+				fill = fill[:length]
+			}
+			for i := range fill {
+				fill[i] = c
+			}
+			for rem := length; rem > 0 && e == nil; rem -= int64(len(fill)) {
+				if rem < int64(len(fill)) {
+					fill = fill[:rem]
+				}
+				_, e = cw.Write(fill)
+			}
+		default:
+			return cw.n, ErrorCorrupt
+		}
+		if e != nil {
+			return cw.n, e
+		}
+	}
+	if cw.n != dstLen {
+		return cw.n, ErrorCorrupt
+	}
+	if _, e := br.ReadByte(); e != io.EOF {
+		return cw.n, ErrorCorrupt
+	}
+	if cw.crc != dstSum {
+		return cw.n, ErrorTarget
+	}
+	return cw.n, nil
+}
+
+// Reads a length and checksum of the patch header.
+func readHeader(br *bufio.Reader) (int64, uint32, error) {
+	length, _, e := unum.ReadUint(br)
+	if e != nil {
+		return 0, 0, ErrorCorrupt
+	}
+	var b [4]byte
+	if _, e := io.ReadFull(br, b[:]); e != nil {
+		return 0, 0, ErrorCorrupt
+	}
+	return int64(length), binary.BigEndian.Uint32(b[:]), nil
+}
@@ -0,0 +1,211 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package diff_test
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"testing"
+	"unum/diff"
+)
+
+// source and a target of edits of the source: inserts, deletes, moves and
+// runs of zeros
+func testPair(r *rand.Rand, n int) (source, target []byte) {
+	source = make([]byte, n)
+	r.Read(source)
+	target = append([]byte(nil), source...)
+	for i := 0; i < 20; i++ {
+		at := r.Intn(len(target))
+		switch r.Intn(4) {
+		case 0:
+			ins := make([]byte, r.Intn(100))
+			r.Read(ins)
+			target = append(target[:at], append(ins, target[at:]...)...)
+		case 1:
+			end := at + r.Intn(1000)
+			if end > len(target) {
+				end = len(target)
+			}
+			target = append(target[:at], target[end:]...)
+		case 2:
+			from := r.Intn(len(source) - 500)
+			target = append(target[:at], append(append([]byte(nil), source[from:from+500]...), target[at:]...)...)
+		case 3:
+			target = append(target[:at], append(make([]byte, 1000), target[at:]...)...)
+		}
+	}
+	return source, target
+}
+
+// produces the target of instructions ins
+func produce(source []byte, ins []diff.Instruction) []byte {
+	var b []byte
+	for _, in := range ins {
+		switch in.Op {
+		case diff.Add:
+			b = append(b, in.Data...)
+		case diff.Copy:
+			b = append(b, source[in.Offset:in.Offset+in.Length]...)
+		case diff.Run:
+			b = append(b, bytes.Repeat([]byte{in.Byte}, in.Length)...)
+		}
+	}
+	return b
+}
+
+func TestInstructions(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	source, target := testPair(r, 100000)
+	ins := diff.Instructions(source, target)
+	if !bytes.Equal(produce(source, ins), target) {
+		t.Fatalf("BUG - instructions do not produce target\n")
+	}
+	var ops [3]int
+	for _, in := range ins {
+		ops[in.Op]++
+		if in.Length <= 0 {
+			t.Errorf("BUG - instruction length:%d\n", in.Length)
+		}
+	}
+	if ops[diff.Add] == 0 || ops[diff.Copy] == 0 || ops[diff.Run] == 0 {
+		t.Errorf("BUG - ops:%v\n", ops)
+	}
+}
+
+func TestPatch(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, n := range []int{1000, 100000, 1000000} {
+		source, target := testPair(r, n)
+		patch := diff.Diff(source, target)
+		if len(patch) > len(target)/10 {
+			t.Errorf("patch not compact - target:%d patch:%d\n", len(target), len(patch))
+		}
+		b, e := diff.Patch(source, patch)
+		if e != nil {
+			t.Fatalf("error patching - e:%s\n", e.Error())
+		}
+		if !bytes.Equal(b, target) {
+			t.Fatalf("BUG - patched target differs\n")
+		}
+	}
+	for _, tc := range [][2][]byte{{nil, nil}, {nil, []byte("abc")}, {[]byte("abc"), nil}} {
+		b, e := diff.Patch(tc[0], diff.Diff(tc[0], tc[1]))
+		if e != nil || !bytes.Equal(b, tc[1]) {
+			t.Errorf("BUG - source:%q target:%q have:%q e:%v\n", tc[0], tc[1], b, e)
+		}
+	}
+}
+
+// writer failing after n bytes
+type failWriter struct{ n int }
+
+var errWrite = errors.New("write")
+
+func (w *failWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errWrite
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestApply(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	source, target := testPair(r, 200000)
+	patch := diff.Diff(source, target)
+	var buf bytes.Buffer
+	n, e := diff.Apply(&buf, bytes.NewReader(source), int64(len(source)), bytes.NewReader(patch))
+	if e != nil || n != int64(len(target)) || !bytes.Equal(buf.Bytes(), target) {
+		t.Fatalf("BUG - n:%d e:%v\n", n, e)
+	}
+	n, e = diff.Apply(&failWriter{n: 1000}, bytes.NewReader(source), int64(len(source)), bytes.NewReader(patch))
+	if e != errWrite || n != 1000 {
+		t.Errorf("expected write error - n:%d e:%v\n", n, e)
+	}
+}
+
+func TestApplyErrors(t *testing.T) {
+	source := bytes.Repeat([]byte("0123456789abcdef"), 8)
+	target := append(append([]byte("xyz"), source[16:64]...), bytes.Repeat([]byte{'!'}, 32)...)
+	patch := diff.Diff(source, target)
+
+	if _, e := diff.Patch(source[1:], patch); e != diff.ErrorSource {
+		t.Errorf("expected ErrorSource - have:%v\n", e)
+	}
+	other := append([]byte(nil), source...)
+	other[100] = 'x'
+	if _, e := diff.Patch(other, patch); e != diff.ErrorSource {
+		t.Errorf("expected ErrorSource - have:%v\n", e)
+	}
+	if _, e := diff.Patch(source, patch[1:]); e != diff.ErrorMagic {
+		t.Errorf("expected ErrorMagic - have:%v\n", e)
+	}
+	for i := 5; i < len(patch); i++ {
+		if _, e := diff.Patch(source, patch[:i]); e != diff.ErrorCorrupt {
+			t.Errorf("expected ErrorCorrupt - len:%d have:%v\n", i, e)
+		}
+	}
+	if _, e := diff.Patch(source, append(append([]byte(nil), patch...), 0)); e != diff.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+	// the add of "xyz" follows the 16 byte header: magic, lengths and
+	// checksums
+	c := append([]byte(nil), patch...)
+	c[17] = 'X'
+	if _, e := diff.Patch(source, c); e != diff.ErrorTarget {
+		t.Errorf("expected ErrorTarget - have:%v\n", e)
+	}
+	// copy past the end of the source
+	c = append([]byte(nil), patch[:16]...)
+	c = append(c, 0x40, 48<<2|byte(diff.Copy), 0x40, 100)
+	if _, e := diff.Patch(source, c); e != diff.ErrorRange {
+		t.Errorf("expected ErrorRange - have:%v\n", e)
+	}
+}
+
+func BenchmarkDiff(b *testing.B) {
+	r := rand.New(rand.NewSource(4))
+	source, target := testPair(r, 1<<20)
+	b.SetBytes(int64(len(target)))
+	var patch []byte
+	for i := 0; i < b.N; i++ {
+		patch = diff.Diff(source, target)
+	}
+	b.ReportMetric(float64(len(patch))/float64(len(target)), "patch/target")
+}
+
+func BenchmarkPatch(b *testing.B) {
+	r := rand.New(rand.NewSource(5))
+	source, target := testPair(r, 1<<20)
+	patch := diff.Diff(source, target)
+	b.SetBytes(int64(len(target)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		diff.Patch(source, patch)
+	}
+}
@@ -0,0 +1,173 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package framing implements length-delimited framing of messages over
+// streams such as net.Conn.
+//
+// Each frame is the UNUM-32 length of its payload, the payload, and (if
+// enabled on both ends) the CRC32C checksum of the payload:
+//
+//      length      UNUM-32     length of payload (< 2^30)
+//      payload     [length]byte
+//      checksum    4 bytes     CRC32C of payload (big-endian), optional
+//
+// Without checksums, frames are the same as those written by
+// unum.WriteUnum32(w, len(p)) followed by p.
+package framing
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorFrameSize = fmt.Errorf("framing.ErrorFrameSize")
+	ErrorTruncated = fmt.Errorf("framing.ErrorTruncated")
+	ErrorChecksum  = fmt.Errorf("framing.ErrorChecksum")
+)
+
+const (
+	// MaxFrameSize is the maximum payload length of a frame.
+	MaxFrameSize = int(unum.Unum32ValueBound) - 1
+	// DefaultMaxFrameSize is the maximum payload length of frames of a
+	// Framer with Options.MaxFrameSize 0.
+	DefaultMaxFrameSize = 4 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Options of a Framer.
+type Options struct {
+	// maximum payload length of frames read and written, in
+	// [1, MaxFrameSize], or 0 for DefaultMaxFrameSize
+	MaxFrameSize int
+	// frames have a CRC32C checksum of the payload
+	Checksum bool
+}
+
+// Framer reads and writes frames over an io.ReadWriter. The buffers of
+// frames are reused, and a Framer is not safe for concurrent use, but one
+// goroutine may read frames while another writes frames.
+type Framer struct {
+	w        io.Writer
+	r        *bufio.Reader
+	max      int
+	checksum bool
+	rbuf     []byte
+	wbuf     []byte
+	hdr      [unum.Unum32Size]byte
+}
+
+// Returns a new Framer of rw with options opts. Reads of rw are buffered.
+func NewFramer(rw io.ReadWriter, opts Options) *Framer {
+	max := opts.MaxFrameSize
+	if max <= 0 {
+		max = DefaultMaxFrameSize
+	}
+	if max > MaxFrameSize {
+		max = MaxFrameSize
+	}
+	return &Framer{w: rw, r: bufio.NewReader(rw), max: max, checksum: opts.Checksum}
+}
+
+// Returns the maximum payload length of frames.
+func (f *Framer) MaxFrameSize() int {
+	return f.max
+}
+
+// Writes a frame of payload p, with a single Write of the underlying
+// writer.
+//
+// On error returns e:
+//    ErrorFrameSize  -- invalid arg p : len(p) > MaxFrameSize()
+//    <other>         -- propagated io.Writer.Write error
+func (f *Framer) WriteFrame(p []byte) error {
+	if len(p) > f.max {
+		return ErrorFrameSize
+	}
+	var h [unum.Unum32Size]byte
+	n, _ := unum.EncodeUnum32(h[:], uint32(len(p)))
+	b := append(append(f.wbuf[:0], h[:n]...), p...)
+	if f.checksum {
+		var c [4]byte
+		binary.BigEndian.PutUint32(c[:], crc32.Checksum(p, castagnoli))
+		b = append(b, c[:]...)
+	}
+	f.wbuf = b
+	_, e := f.w.Write(b)
+	return e
+}
+
+// Reads a frame, and returns its payload. The payload is valid until the
+// next ReadFrame.
+//
+// On error returns (nil, e) where e is:
+//    io.EOF          -- end of stream, at a frame boundary
+//    ErrorTruncated  -- end of stream, within a frame
+//    ErrorFrameSize  -- frame length > MaxFrameSize()
+//    ErrorChecksum   -- payload checksum mismatch
+//    <other>         -- propagated io.Reader.Read error
+func (f *Framer) ReadFrame() ([]byte, error) {
+	// the header is read in place of unum.ReadUnum32, to not allocate
+	c, e := f.r.ReadByte()
+	if e != nil {
+		return nil, e
+	}
+	f.hdr[0] = c
+	if vlen := int(c>>6) + 1; vlen > 1 {
+		if _, e := io.ReadFull(f.r, f.hdr[1:vlen]); e != nil {
+			if e == io.EOF || e == io.ErrUnexpectedEOF {
+				e = ErrorTruncated
+			}
+			return nil, e
+		}
+	}
+	length, _, _ := unum.DecodeUnum32(f.hdr[:])
+	if int(length) > f.max {
+		return nil, ErrorFrameSize
+	}
+	n := int(length)
+	if f.checksum {
+		n += 4
+	}
+	if cap(f.rbuf) < n {
+		f.rbuf = make([]byte, n)
+	}
+	b := f.rbuf[:n]
+	if _, e := io.ReadFull(f.r, b); e != nil {
+		if e == io.EOF || e == io.ErrUnexpectedEOF {
+			e = ErrorTruncated
+		}
+		return nil, e
+	}
+	p := b[:length]
+	if f.checksum && crc32.Checksum(p, castagnoli) != binary.BigEndian.Uint32(b[length:]) {
+		return nil, ErrorChecksum
+	}
+	return p, nil
+}
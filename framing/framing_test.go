@@ -0,0 +1,171 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package framing_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net"
+	"testing"
+	"unum"
+	"unum/framing"
+)
+
+// io.ReadWriter of separate reader and writer
+type readWriter struct {
+	io.Reader
+	io.Writer
+}
+
+func testFrames(r *rand.Rand, n int) [][]byte {
+	frames := make([][]byte, n)
+	for i := range frames {
+		frames[i] = make([]byte, r.Intn(1<<r.Intn(16)))
+		r.Read(frames[i])
+	}
+	return frames
+}
+
+func TestPipe(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	frames := testFrames(r, 500)
+	for _, checksum := range []bool{false, true} {
+		c0, c1 := net.Pipe()
+		opts := framing.Options{Checksum: checksum}
+		go func() {
+			f := framing.NewFramer(c0, opts)
+			for _, p := range frames {
+				if e := f.WriteFrame(p); e != nil {
+					t.Errorf("error writing - e:%s\n", e.Error())
+					break
+				}
+			}
+			c0.Close()
+		}()
+		f := framing.NewFramer(c1, opts)
+		for i, expected := range frames {
+			p, e := f.ReadFrame()
+			if e != nil {
+				t.Fatalf("error reading - frame:%d e:%s\n", i, e.Error())
+			}
+			if !bytes.Equal(p, expected) {
+				t.Fatalf("BUG - frame:%d differs\n", i)
+			}
+		}
+		if _, e := f.ReadFrame(); e != io.EOF {
+			t.Errorf("expected io.EOF - have:%v\n", e)
+		}
+		c1.Close()
+	}
+}
+
+func TestCompatible(t *testing.T) {
+	// frames written by hand with WriteUnum32
+	var buf bytes.Buffer
+	for _, s := range []string{"", "a", string(make([]byte, 100))} {
+		unum.WriteUnum32(&buf, uint32(len(s)))
+		buf.WriteString(s)
+	}
+	f := framing.NewFramer(readWriter{&buf, nil}, framing.Options{})
+	for _, expected := range []int{0, 1, 100} {
+		if p, e := f.ReadFrame(); e != nil || len(p) != expected {
+			t.Errorf("BUG - len:%d expected:%d e:%v\n", len(p), expected, e)
+		}
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	var buf bytes.Buffer
+	f := framing.NewFramer(readWriter{nil, &buf}, framing.Options{Checksum: true})
+	f.WriteFrame(make([]byte, 1000))
+	b := buf.Bytes()
+	for i := 1; i < len(b); i++ {
+		f := framing.NewFramer(readWriter{bytes.NewReader(b[:i]), nil}, framing.Options{Checksum: true})
+		if _, e := f.ReadFrame(); e != framing.ErrorTruncated {
+			t.Fatalf("expected ErrorTruncated - len:%d have:%v\n", i, e)
+		}
+	}
+	f = framing.NewFramer(readWriter{bytes.NewReader(nil), nil}, framing.Options{})
+	if _, e := f.ReadFrame(); e != io.EOF {
+		t.Errorf("expected io.EOF - have:%v\n", e)
+	}
+	c := append([]byte(nil), b...)
+	c[100] ^= 1
+	f = framing.NewFramer(readWriter{bytes.NewReader(c), nil}, framing.Options{Checksum: true})
+	if _, e := f.ReadFrame(); e != framing.ErrorChecksum {
+		t.Errorf("expected ErrorChecksum - have:%v\n", e)
+	}
+}
+
+func TestFrameSize(t *testing.T) {
+	var buf bytes.Buffer
+	f := framing.NewFramer(&buf, framing.Options{MaxFrameSize: 100})
+	if f.MaxFrameSize() != 100 {
+		t.Errorf("BUG - max:%d\n", f.MaxFrameSize())
+	}
+	if e := f.WriteFrame(make([]byte, 101)); e != framing.ErrorFrameSize {
+		t.Errorf("expected ErrorFrameSize - have:%v\n", e)
+	}
+	if e := f.WriteFrame(make([]byte, 100)); e != nil {
+		t.Fatalf("error writing - e:%s\n", e.Error())
+	}
+	unum.WriteUnum32(&buf, 101)
+	if p, e := f.ReadFrame(); e != nil || len(p) != 100 {
+		t.Errorf("BUG - len:%d e:%v\n", len(p), e)
+	}
+	if _, e := f.ReadFrame(); e != framing.ErrorFrameSize {
+		t.Errorf("expected ErrorFrameSize - have:%v\n", e)
+	}
+	if f := framing.NewFramer(&buf, framing.Options{}); f.MaxFrameSize() != framing.DefaultMaxFrameSize {
+		t.Errorf("BUG - max:%d\n", f.MaxFrameSize())
+	}
+	if f := framing.NewFramer(&buf, framing.Options{MaxFrameSize: framing.MaxFrameSize + 1}); f.MaxFrameSize() != framing.MaxFrameSize {
+		t.Errorf("BUG - max:%d\n", f.MaxFrameSize())
+	}
+}
+
+func TestReuse(t *testing.T) {
+	var buf bytes.Buffer
+	f := framing.NewFramer(&buf, framing.Options{Checksum: true})
+	p := make([]byte, 1000)
+	allocs := testing.AllocsPerRun(100, func() {
+		f.WriteFrame(p)
+		f.ReadFrame()
+	})
+	if allocs != 0 {
+		t.Errorf("BUG - allocs per frame:%g\n", allocs)
+	}
+}
+
+func BenchmarkFramer(b *testing.B) {
+	var buf bytes.Buffer
+	f := framing.NewFramer(&buf, framing.Options{Checksum: true})
+	p := make([]byte, 256)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		f.WriteFrame(p)
+		f.ReadFrame()
+	}
+}
@@ -0,0 +1,303 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package frontcode implements front coding of sorted string dictionaries.
+//
+// Sorted keys (terms, URLs, paths) share long prefixes with their
+// predecessors. A front coded entry records the length of the prefix it
+// shares with the previous key and the remaining suffix. Every k-th entry
+// is a restart point, coded with no shared prefix, so that any entry can be
+// decoded from the nearest preceding restart. The image is:
+//
+//      count       UNUM-32         number of entries
+//      interval    UNUM-32         restart interval k
+//      restarts    [n]uint32       offset in data of entries 0, k, 2k, ..
+//                                  (big-endian)
+//      data:       per entry
+//      shared      UNUM-32         length of prefix shared with previous key
+//      length      UNUM-32         length of suffix
+//      suffix      [length]byte
+//
+// A Dict works directly on the image; restart keys are compared in place,
+// and only the entries of one restart interval are decoded per lookup.
+package frontcode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorOrder   = fmt.Errorf("frontcode.ErrorOrder")
+	ErrorCorrupt = fmt.Errorf("frontcode.ErrorCorrupt")
+)
+
+// DefaultInterval is the restart interval of Encode with k <= 0.
+const DefaultInterval = 16
+
+// Returns the front coded image of the strictly increasing keys, with
+// restarts every k entries (DefaultInterval if k <= 0).
+//
+// On error returns (nil, e) where e is:
+//    unum.ErrorMaxValue  -- invalid arg keys : len(key) >= 2^30, or
+//                           image data exceeds 2^32 bytes
+//    ErrorOrder          -- invalid arg keys : not strictly increasing
+func Encode(keys []string, k int) ([]byte, error) {
+	if k <= 0 {
+		k = DefaultInterval
+	}
+	if k >= int(unum.Unum32ValueBound) {
+		k = int(unum.Unum32ValueBound - 1)
+	}
+	var data []byte
+	restarts := make([]uint32, 0, (len(keys)+k-1)/k)
+	for i, key := range keys {
+		if len(key) >= int(unum.Unum32ValueBound) {
+			return nil, unum.ErrorMaxValue
+		}
+		shared := 0
+		if i > 0 {
+			prev := keys[i-1]
+			if key <= prev {
+				return nil, ErrorOrder
+			}
+			if i%k != 0 {
+				for shared < len(prev) && shared < len(key) && prev[shared] == key[shared] {
+					shared++
+				}
+			}
+		}
+		if i%k == 0 {
+			if uint64(len(data)) > 1<<32-1 {
+				return nil, unum.ErrorMaxValue
+			}
+			restarts = append(restarts, uint32(len(data)))
+		}
+		data, _ = unum.AppendUnum32(data, uint32(shared))
+		data, _ = unum.AppendUnum32(data, uint32(len(key)-shared))
+		data = append(data, key[shared:]...)
+	}
+	b, _ := unum.AppendUnum32(nil, uint32(len(keys)))
+	b, _ = unum.AppendUnum32(b, uint32(k))
+	for _, off := range restarts {
+		b = append(b, byte(off>>24), byte(off>>16), byte(off>>8), byte(off))
+	}
+	return append(b, data...), nil
+}
+
+// Dict is a front coded dictionary image.
+type Dict struct {
+	count    int
+	k        int
+	restarts []byte // restart table of image
+	data     []byte // entries of image
+}
+
+// Returns the Dict of image b. The dictionary refers to b, which must not
+// be modified while the dictionary is in use. The image is checked, but
+// not decoded.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCorrupt  -- invalid arg b : non-conformant image
+func Open(b []byte) (*Dict, error) {
+	count, n, e := unum.DecodeUnum32(b)
+	if e != nil {
+		return nil, ErrorCorrupt
+	}
+	k, n0, e := unum.DecodeUnum32(b[n:])
+	if e != nil || k == 0 || int(count) > len(b) {
+		return nil, ErrorCorrupt
+	}
+	n += n0
+	nr := (int(count) + int(k) - 1) / int(k)
+	if len(b)-n < 4*nr {
+		return nil, ErrorCorrupt
+	}
+	d := &Dict{count: int(count), k: int(k), restarts: b[n : n+4*nr], data: b[n+4*nr:]}
+	if !d.check() {
+		return nil, ErrorCorrupt
+	}
+	return d, nil
+}
+
+// returns true if the entries are well formed, with strictly increasing
+// keys and restarts at the offsets of the restart table.
+func (d *Dict) check() bool {
+	var key, prev []byte
+	off := 0
+	for i := 0; i < d.count; i++ {
+		shared, suffix, next, ok := d.entry(off)
+		if !ok || shared > len(key) {
+			return false
+		}
+		if i%d.k == 0 && (shared != 0 || d.restart(i/d.k) != off) {
+			return false
+		}
+		prev, key = append(prev[:0], key...), append(key[:shared], suffix...)
+		if i > 0 && bytes.Compare(prev, key) >= 0 {
+			return false
+		}
+		off = next
+	}
+	return off == len(d.data)
+}
+
+// returns the offset of restart entry r.
+func (d *Dict) restart(r int) int {
+	return int(binary.BigEndian.Uint32(d.restarts[4*r:]))
+}
+
+// decodes the entry at offset off. returns its shared length, suffix and
+// the offset of the next entry.
+func (d *Dict) entry(off int) (shared int, suffix []byte, next int, ok bool) {
+	if off > len(d.data) {
+		return
+	}
+	s, n, e := unum.DecodeUnum32(d.data[off:])
+	if e != nil {
+		return
+	}
+	l, n0, e := unum.DecodeUnum32(d.data[off+n:])
+	if e != nil {
+		return
+	}
+	start := off + n + n0
+	if len(d.data)-start < int(l) {
+		return
+	}
+	return int(s), d.data[start : start+int(l)], start + int(l), true
+}
+
+// returns the key of restart entry r, in place.
+func (d *Dict) restartKey(r int) []byte {
+	_, suffix, _, _ := d.entry(d.restart(r))
+	return suffix
+}
+
+// Returns the number of entries.
+func (d *Dict) Len() int {
+	return d.count
+}
+
+// Returns the restart interval.
+func (d *Dict) Interval() int {
+	return d.k
+}
+
+// Returns key i. Panics if i is not in [0, Len()).
+func (d *Dict) Get(i int) string {
+	if i < 0 || i >= d.count {
+		panic(fmt.Sprintf("frontcode.Get: index out of range [%d] with length %d", i, d.count))
+	}
+	it := d.Iterator(i)
+	it.Next()
+	return string(it.key)
+}
+
+// Returns the index of the first key not less than key (Len() if there is
+// none), and true if that key is equal to key.
+func (d *Dict) Search(key string) (int, bool) {
+	k := []byte(key)
+	// last restart with key <= key
+	nr := len(d.restarts) / 4
+	r := sort.Search(nr, func(r int) bool { return bytes.Compare(d.restartKey(r), k) > 0 }) - 1
+	if r < 0 {
+		return 0, d.count > 0 && bytes.Equal(d.restartKey(0), k)
+	}
+	it := d.Iterator(r * d.k)
+	for it.Next() {
+		if c := bytes.Compare(it.key, k); c >= 0 {
+			return it.i, c == 0
+		}
+	}
+	return d.count, false
+}
+
+// Iterator iterates over the keys of a Dict in order.
+type Iterator struct {
+	d      *Dict
+	i      int // index of current key
+	end    int // index of first key not iterated
+	off    int // offset of next entry
+	key    []byte
+	prefix []byte // keys must have prefix (if not nil)
+}
+
+// Returns an iterator positioned before key i.
+func (d *Dict) Iterator(i int) *Iterator {
+	if i < 0 {
+		i = 0
+	}
+	if i > d.count {
+		i = d.count
+	}
+	it := &Iterator{d: d, i: -1, end: d.count}
+	if i < d.count {
+		r := i / d.k
+		it.i, it.off = r*d.k-1, d.restart(r)
+		for it.i < i-1 {
+			it.Next()
+		}
+	} else {
+		it.i = d.count - 1
+	}
+	return it
+}
+
+// Returns an iterator over the keys with prefix p.
+func (d *Dict) Prefix(p string) *Iterator {
+	i, _ := d.Search(p)
+	it := d.Iterator(i)
+	it.prefix = []byte(p)
+	return it
+}
+
+// Advances to the next key. Returns false at the end of the iteration.
+func (it *Iterator) Next() bool {
+	if it.i+1 >= it.end {
+		return false
+	}
+	shared, suffix, next, _ := it.d.entry(it.off)
+	it.key = append(it.key[:shared], suffix...)
+	it.off = next
+	it.i++
+	if it.prefix != nil && !bytes.HasPrefix(it.key, it.prefix) {
+		it.end = it.i
+		return false
+	}
+	return true
+}
+
+// Returns the current key. The key is valid until the next call to Next.
+func (it *Iterator) Key() []byte {
+	return it.key
+}
+
+// Returns the index of the current key.
+func (it *Iterator) Index() int {
+	return it.i
+}
@@ -0,0 +1,180 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package frontcode_test
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+	"unum/frontcode"
+)
+
+// sorted unique URL-like keys
+func testKeys(n int) []string {
+	r := rand.New(rand.NewSource(int64(n)))
+	hosts := []string{"https://example.com/", "https://example.org/docs/", "http://a.io/"}
+	m := map[string]bool{}
+	for len(m) < n {
+		m[fmt.Sprintf("%s%s/%d", hosts[r.Intn(len(hosts))], []string{"a", "b", "users"}[r.Intn(3)], r.Intn(100000))] = true
+	}
+	keys := make([]string, 0, n)
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func open(t *testing.T, keys []string, k int) *frontcode.Dict {
+	b, e := frontcode.Encode(keys, k)
+	if e != nil {
+		t.Fatalf("error encoding - e:%s\n", e.Error())
+	}
+	d, e := frontcode.Open(b)
+	if e != nil {
+		t.Fatalf("error opening - e:%s\n", e.Error())
+	}
+	return d
+}
+
+func TestGet(t *testing.T) {
+	for _, k := range []int{0, 1, 3, 64} {
+		keys := testKeys(1000)
+		d := open(t, keys, k)
+		if d.Len() != len(keys) {
+			t.Errorf("expected len %d - have:%d\n", len(keys), d.Len())
+		}
+		for i, key := range keys {
+			if key0 := d.Get(i); key0 != key {
+				t.Fatalf("BUG - k:%d Get(%d) - expected:%q have:%q\n", k, i, key, key0)
+			}
+		}
+		it := d.Iterator(0)
+		for i := 0; it.Next(); i++ {
+			if string(it.Key()) != keys[i] || it.Index() != i {
+				t.Fatalf("BUG - k:%d iterator at %d - have:%q\n", k, i, it.Key())
+			}
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	keys := testKeys(10000)
+	size := 0
+	for _, key := range keys {
+		size += len(key)
+	}
+	b, _ := frontcode.Encode(keys, 0)
+	if len(b) > size/2 {
+		t.Errorf("image not compact - len:%d keys:%d\n", len(b), size)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	keys := testKeys(2000)
+	d := open(t, keys, 8)
+	for i, key := range keys {
+		if j, ok := d.Search(key); j != i || !ok {
+			t.Fatalf("BUG - Search(%q) - expected:%d have:%d %t\n", key, i, j, ok)
+		}
+		// a key between keys[i-1] and keys[i]
+		probe := key[:len(key)-1]
+		expected := sort.SearchStrings(keys, probe)
+		if j, ok := d.Search(probe); j != expected || ok != (keys[expected] == probe) {
+			t.Fatalf("BUG - Search(%q) - expected:%d have:%d\n", probe, expected, j)
+		}
+	}
+	if j, ok := d.Search(""); j != 0 || ok {
+		t.Errorf("BUG - Search(\"\") - have:%d %t\n", j, ok)
+	}
+	if j, ok := d.Search("zzz"); j != len(keys) || ok {
+		t.Errorf("BUG - Search(zzz) - have:%d %t\n", j, ok)
+	}
+	empty := open(t, nil, 0)
+	if j, ok := empty.Search("a"); j != 0 || ok {
+		t.Errorf("BUG - empty Search - have:%d %t\n", j, ok)
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	keys := testKeys(3000)
+	d := open(t, keys, 0)
+	for _, p := range []string{"https://example.org/docs/users/1", "http://a.io/", "https://", "", "x", "https://example.com/b/9"} {
+		var expected, have []string
+		for _, key := range keys {
+			if strings.HasPrefix(key, p) {
+				expected = append(expected, key)
+			}
+		}
+		it := d.Prefix(p)
+		for it.Next() {
+			have = append(have, string(it.Key()))
+		}
+		if fmt.Sprint(have) != fmt.Sprint(expected) {
+			t.Errorf("BUG - Prefix(%q) - expected:%d have:%d keys\n", p, len(expected), len(have))
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	if _, e := frontcode.Encode([]string{"b", "a"}, 0); e != frontcode.ErrorOrder {
+		t.Errorf("expected ErrorOrder - have:%v\n", e)
+	}
+	if _, e := frontcode.Encode([]string{"a", "a"}, 0); e != frontcode.ErrorOrder {
+		t.Errorf("expected ErrorOrder - have:%v\n", e)
+	}
+	b, _ := frontcode.Encode([]string{"apple", "applet", "apply"}, 2)
+	for _, p := range [][]byte{
+		b[:len(b)-1],
+		append(append([]byte(nil), b...), 0),
+		{},
+		{1, 0},
+		{2, 2, 0, 0, 0, 0, 0, 1, 'b', 0, 1, 'a'}, // unordered
+		{2, 2, 0, 0, 0, 0, 0, 1, 'b', 2, 1, 'a'}, // shared > previous key
+		{2, 1, 0, 0, 0, 0, 0, 0, 0, 3, 0, 1, 'a'}, // bad restart offset
+	} {
+		if _, e := frontcode.Open(p); e != frontcode.ErrorCorrupt {
+			t.Errorf("expected ErrorCorrupt - b:%x have:%v\n", p, e)
+		}
+	}
+	d, _ := frontcode.Open(b)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected panic on Get out of range\n")
+		}
+	}()
+	d.Get(3)
+}
+
+func BenchmarkSearch(b *testing.B) {
+	keys := testKeys(100000)
+	p, _ := frontcode.Encode(keys, 0)
+	d, _ := frontcode.Open(p)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		d.Search(keys[i%len(keys)])
+	}
+}
@@ -0,0 +1,225 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package graph implements compressed adjacency (CSR) storage of directed
+// graphs, readable in place through an io.ReaderAt.
+//
+// Nodes are the integers [0, nodes), and each node's out-neighbors are
+// stored as a sorted, gap encoded list. An index of fixed width offsets
+// locates each list, so a lookup costs one read of the index and one read
+// of the list, and the graph need not be loaded. Lists longer than
+// SkipInterval have skip entries (the first neighbor and offset of each
+// subsequent block of SkipInterval neighbors) for galloping search. The
+// image is:
+//
+//      magic       4 bytes     "UGRF"
+//      encoding    1 byte      0: UNUM-32 (nodes <= 2^30), 1: UNUM-64
+//      reserved    3 bytes     0
+//      nodes       8 bytes     number of nodes (big-endian)
+//      edges       8 bytes     number of edges (big-endian)
+//      index       [nodes+1]8 bytes  offset of each list in the lists
+//                              (big-endian); the last is the length of the
+//                              lists
+//      lists:      per node
+//      degree      UNUM        number of neighbors
+//      skips       [(degree-1)/SkipInterval] per block after the first
+//      first       4 bytes     first neighbor of the block (big-endian)
+//      offset      4 bytes     offset of the block in the values (big-endian)
+//      values      [degree]UNUM  first neighbor of each block, then the
+//                              gap to the previous neighbor
+//
+// where UNUM is UNUM-32 or UNUM-64 per the encoding.
+package graph
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorNode    = fmt.Errorf("graph.ErrorNode")
+	ErrorDegree  = fmt.Errorf("graph.ErrorDegree")
+	ErrorMagic   = fmt.Errorf("graph.ErrorMagic")
+	ErrorCorrupt = fmt.Errorf("graph.ErrorCorrupt")
+)
+
+const (
+	// MaxNodes is the maximum number of nodes of a graph.
+	MaxNodes = 1 << 32
+	// MaxDegree is the maximum number of neighbors of a node, bounding the
+	// size of a list to the 4 byte skip offsets.
+	MaxDegree = 1 << 28
+	// SkipInterval is the number of neighbors per skip entry.
+	SkipInterval = 64
+)
+
+const (
+	magic      = "UGRF"
+	headerSize = 24
+)
+
+// Edge is a directed edge.
+type Edge struct {
+	From, To uint32
+}
+
+// Writes the image of the graph of nodes nodes and edges edges to w, and
+// returns the number of bytes written. Edges are sorted in place, and
+// duplicate edges are stored once. Undirected graphs are stored with an
+// edge in each direction.
+//
+// On error returns (n, e) where e is:
+//    ErrorNode    -- invalid arg nodes : not in [0, MaxNodes]
+//    ErrorNode    -- invalid arg edges : node >= nodes
+//    ErrorDegree  -- invalid arg edges : node has more than MaxDegree neighbors
+//    <other>      -- propagated io.Writer.Write error
+func Build(w io.Writer, nodes int, edges []Edge) (n int64, e error) {
+	if nodes < 0 || int64(nodes) > MaxNodes {
+		return 0, ErrorNode
+	}
+	for _, edge := range edges {
+		if int64(edge.From) >= int64(nodes) || int64(edge.To) >= int64(nodes) {
+			return 0, ErrorNode
+		}
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		a, b := edges[i], edges[j]
+		return a.From < b.From || (a.From == b.From && a.To < b.To)
+	})
+	count := 0
+	for i := range edges {
+		if i == 0 || edges[i] != edges[i-1] {
+			count++
+		}
+	}
+	wide := nodes > int(unum.Unum32ValueBound)
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var h [headerSize]byte
+	copy(h[:], magic)
+	if wide {
+		h[4] = 1
+	}
+	binary.BigEndian.PutUint64(h[8:], uint64(nodes))
+	binary.BigEndian.PutUint64(h[16:], uint64(count))
+	bw.Write(h[:])
+
+	// index, encoding each list to learn its size
+	var b [8]byte
+	var buf []byte
+	var off uint64
+	bw.Write(b[:])
+	for i, node := 0, 0; node < nodes; node++ {
+		j := next(edges, i, uint32(node))
+		if buf, e = appendList(buf[:0], wide, edges[i:j]); e != nil {
+			return cw.n, e
+		}
+		off += uint64(len(buf))
+		binary.BigEndian.PutUint64(b[:], off)
+		bw.Write(b[:])
+		i = j
+	}
+	// lists
+	for i, node := 0, 0; node < nodes; node++ {
+		j := next(edges, i, uint32(node))
+		buf, _ = appendList(buf[:0], wide, edges[i:j])
+		bw.Write(buf)
+		i = j
+	}
+	e = bw.Flush()
+	return cw.n, e
+}
+
+// Returns the end of the edges from node at edges[i:].
+func next(edges []Edge, i int, node uint32) int {
+	for i < len(edges) && edges[i].From == node {
+		i++
+	}
+	return i
+}
+
+// Appends the list of the neighbors of edges (of a single node, sorted) to b.
+func appendList(b []byte, wide bool, edges []Edge) ([]byte, error) {
+	degree := 0
+	for i := range edges {
+		if i == 0 || edges[i].To != edges[i-1].To {
+			degree++
+		}
+	}
+	if degree > MaxDegree {
+		return b, ErrorDegree
+	}
+	b = appendUnum(b, wide, uint64(degree))
+	skips := len(b)
+	if degree > 0 {
+		b = append(b, make([]byte, 8*((degree-1)/SkipInterval))...)
+	}
+	start := len(b)
+	var k int
+	for i, edge := range edges {
+		if i > 0 && edge.To == edges[i-1].To {
+			continue
+		}
+		switch {
+		case k%SkipInterval != 0:
+			b = appendUnum(b, wide, uint64(edge.To-edges[i-1].To))
+		case k > 0:
+			s := b[skips+8*(k/SkipInterval-1):]
+			binary.BigEndian.PutUint32(s, edge.To)
+			binary.BigEndian.PutUint32(s[4:], uint32(len(b)-start))
+			fallthrough
+		default:
+			b = appendUnum(b, wide, uint64(edge.To))
+		}
+		k++
+	}
+	return b, nil
+}
+
+// Appends the UNUM-32 (or UNUM-64 if wide) encoding of v to b.
+func appendUnum(b []byte, wide bool, v uint64) []byte {
+	if wide {
+		b, _ = unum.AppendUnum64(b, v)
+	} else {
+		b, _ = unum.AppendUnum32(b, uint32(v))
+	}
+	return b
+}
+
+// countWriter counts the bytes written to w.
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	n, e := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, e
+}
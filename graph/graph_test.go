@@ -0,0 +1,117 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graph_test
+
+import (
+	"bytes"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"unum/graph"
+)
+
+// random graph, with a few high degree nodes, and its adjacency lists
+func testGraph(r *rand.Rand, nodes, edges int) ([]graph.Edge, [][]uint32) {
+	es := make([]graph.Edge, edges)
+	for i := range es {
+		from := uint32(r.Intn(nodes))
+		if r.Intn(4) == 0 {
+			from = uint32(r.Intn(3))
+		}
+		es[i] = graph.Edge{From: from, To: uint32(r.Intn(nodes))}
+	}
+	adj := make([][]uint32, nodes)
+	seen := make(map[graph.Edge]bool)
+	for _, e := range es {
+		if !seen[e] {
+			seen[e] = true
+			adj[e.From] = append(adj[e.From], e.To)
+		}
+	}
+	for _, l := range adj {
+		sort.Slice(l, func(i, j int) bool { return l[i] < l[j] })
+	}
+	return es, adj
+}
+
+func TestBuild(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	es, adj := testGraph(r, 1000, 20000)
+	var buf bytes.Buffer
+	n, e := graph.Build(&buf, 1000, es)
+	if e != nil {
+		t.Fatalf("error building - e:%s\n", e.Error())
+	}
+	if n != int64(buf.Len()) {
+		t.Errorf("BUG - n:%d len:%d\n", n, buf.Len())
+	}
+	g, e := graph.Load(buf.Bytes())
+	if e != nil {
+		t.Fatalf("error loading - e:%s\n", e.Error())
+	}
+	edges := 0
+	for _, l := range adj {
+		edges += len(l)
+	}
+	if g.Nodes() != 1000 || g.Edges() != int64(edges) {
+		t.Errorf("BUG - nodes:%d edges:%d expected:%d\n", g.Nodes(), g.Edges(), edges)
+	}
+	for node, l := range adj {
+		vs, e := g.AppendNeighbors(nil, uint32(node))
+		if e != nil {
+			t.Fatalf("error reading neighbors - node:%d e:%s\n", node, e.Error())
+		}
+		if len(l) > 0 && !reflect.DeepEqual(vs, l) {
+			t.Fatalf("BUG - node:%d neighbors differ\n", node)
+		}
+		if d, e := g.Degree(uint32(node)); e != nil || d != len(l) {
+			t.Fatalf("BUG - node:%d degree:%d expected:%d e:%v\n", node, d, len(l), e)
+		}
+	}
+	if edges/g.Nodes() > 10 && buf.Len() > 3*edges {
+		t.Errorf("image not compact - edges:%d len:%d\n", edges, buf.Len())
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	var buf bytes.Buffer
+	if _, e := graph.Build(&buf, 10, []graph.Edge{{1, 10}}); e != graph.ErrorNode {
+		t.Errorf("expected ErrorNode - have:%v\n", e)
+	}
+	if _, e := graph.Build(&buf, -1, nil); e != graph.ErrorNode {
+		t.Errorf("expected ErrorNode - have:%v\n", e)
+	}
+	buf.Reset()
+	if _, e := graph.Build(&buf, 0, nil); e != nil {
+		t.Fatalf("error building empty graph - e:%s\n", e.Error())
+	}
+	g, e := graph.Load(buf.Bytes())
+	if e != nil || g.Nodes() != 0 {
+		t.Fatalf("BUG - empty graph - e:%v\n", e)
+	}
+	if _, e := g.Degree(0); e != graph.ErrorNode {
+		t.Errorf("expected ErrorNode - have:%v\n", e)
+	}
+}
@@ -0,0 +1,376 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graph
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"sort"
+	"unum"
+)
+
+// Graph reads the image of a graph through an io.ReaderAt. Each query
+// reads the node's index entries and list; Graph is safe for concurrent
+// use if the io.ReaderAt is.
+type Graph struct {
+	r     io.ReaderAt
+	nodes int
+	edges int64
+	wide  bool
+	base  int64 // offset of the lists
+	size  int64 // length of the lists
+}
+
+// Returns a new Graph of the image of size bytes at r, reading its header.
+//
+// On error returns (nil, e) where e is:
+//    ErrorMagic    -- not a graph image
+//    ErrorCorrupt  -- non-conformant (or truncated) image
+//    <other>       -- propagated io.ReaderAt error
+func Open(r io.ReaderAt, size int64) (*Graph, error) {
+	var h [headerSize]byte
+	if size < headerSize+8 {
+		return nil, ErrorMagic
+	}
+	if e := readAt(r, h[:], 0); e != nil {
+		return nil, e
+	}
+	if string(h[:4]) != magic {
+		return nil, ErrorMagic
+	}
+	nodes := binary.BigEndian.Uint64(h[8:])
+	edges := binary.BigEndian.Uint64(h[16:])
+	if h[4] > 1 || h[5]|h[6]|h[7] != 0 || nodes > MaxNodes ||
+		(h[4] == 0 && nodes > uint64(unum.Unum32ValueBound)) {
+		return nil, ErrorCorrupt
+	}
+	g := &Graph{r: r, nodes: int(nodes), wide: h[4] == 1}
+	g.base = headerSize + 8*int64(nodes+1)
+	if g.base > size {
+		return nil, ErrorCorrupt
+	}
+	var b [8]byte
+	if e := readAt(r, b[:], headerSize); e != nil {
+		return nil, e
+	}
+	first := binary.BigEndian.Uint64(b[:])
+	if e := readAt(r, b[:], g.base-8); e != nil {
+		return nil, e
+	}
+	g.size = int64(binary.BigEndian.Uint64(b[:]))
+	if first != 0 || g.size != size-g.base || edges > uint64(g.size) {
+		return nil, ErrorCorrupt
+	}
+	g.edges = int64(edges)
+	return g, nil
+}
+
+// Returns a new Graph of image b. See Open.
+func Load(b []byte) (*Graph, error) {
+	return Open(bytes.NewReader(b), int64(len(b)))
+}
+
+// Reads len(p) bytes at off of r.
+func readAt(r io.ReaderAt, p []byte, off int64) error {
+	n, e := r.ReadAt(p, off)
+	if n == len(p) {
+		return nil
+	}
+	if e == nil || e == io.EOF {
+		e = io.ErrUnexpectedEOF
+	}
+	return e
+}
+
+// Returns the number of nodes.
+func (g *Graph) Nodes() int {
+	return g.nodes
+}
+
+// Returns the number of edges.
+func (g *Graph) Edges() int64 {
+	return g.edges
+}
+
+// Returns the offset and length of the list of node n.
+func (g *Graph) locate(n uint32) (off, length int64, e error) {
+	if int64(n) >= int64(g.nodes) {
+		return 0, 0, ErrorNode
+	}
+	var b [16]byte
+	if e := readAt(g.r, b[:], headerSize+8*int64(n)); e != nil {
+		return 0, 0, e
+	}
+	o0, o1 := binary.BigEndian.Uint64(b[:]), binary.BigEndian.Uint64(b[8:])
+	if o0 > o1 || o1 > uint64(g.size) {
+		return 0, 0, ErrorCorrupt
+	}
+	return g.base + int64(o0), int64(o1 - o0), nil
+}
+
+// Returns the number of neighbors of node n.
+//
+// On error returns (0, e) where e is:
+//    ErrorNode     -- invalid arg n : n >= Nodes()
+//    ErrorCorrupt  -- non-conformant image
+//    <other>       -- propagated io.ReaderAt error
+func (g *Graph) Degree(n uint32) (int, error) {
+	off, length, e := g.locate(n)
+	if e != nil {
+		return 0, e
+	}
+	var b [unum.Unum64Size]byte
+	head := b[:]
+	if length < int64(len(b)) {
+		head = b[:length]
+	}
+	if e := readAt(g.r, head, off); e != nil {
+		return 0, e
+	}
+	degree, _, e := decodeUnum(head, g.wide)
+	if e != nil || degree > uint64(length) {
+		return 0, ErrorCorrupt
+	}
+	return int(degree), nil
+}
+
+// Returns an Iterator of the neighbors of node n, reading its list.
+//
+// On error returns (nil, e) where e is:
+//    ErrorNode     -- invalid arg n : n >= Nodes()
+//    ErrorCorrupt  -- non-conformant image
+//    <other>       -- propagated io.ReaderAt error
+func (g *Graph) Neighbors(n uint32) (*Iterator, error) {
+	off, length, e := g.locate(n)
+	if e != nil {
+		return nil, e
+	}
+	b := make([]byte, length)
+	if e := readAt(g.r, b, off); e != nil {
+		return nil, e
+	}
+	degree, n0, e := decodeUnum(b, g.wide)
+	if e != nil || degree > uint64(len(b)) {
+		return nil, ErrorCorrupt
+	}
+	it := &Iterator{degree: int(degree), wide: g.wide, nodes: uint64(g.nodes)}
+	if degree > 0 {
+		skips := 8 * int((degree-1)/SkipInterval)
+		if skips > len(b)-n0 {
+			return nil, ErrorCorrupt
+		}
+		it.skips, it.data = b[n0:n0+skips], b[n0+skips:]
+	} else if n0 != len(b) {
+		return nil, ErrorCorrupt
+	}
+	return it, nil
+}
+
+// Returns the neighbors of node n appended to dst. See Neighbors.
+func (g *Graph) AppendNeighbors(dst []uint32, n uint32) ([]uint32, error) {
+	it, e := g.Neighbors(n)
+	if e != nil {
+		return dst, e
+	}
+	for it.Next() {
+		dst = append(dst, it.Node())
+	}
+	return dst, it.Err()
+}
+
+// Returns true if the graph has the edge (from, to). The neighbors of from
+// are searched by galloping over its skip entries, and only the skip
+// entries and a single block of the list are read.
+//
+// On error returns (false, e) where e is:
+//    ErrorNode     -- invalid arg from : from >= Nodes()
+//    ErrorCorrupt  -- non-conformant image
+//    <other>       -- propagated io.ReaderAt error
+func (g *Graph) HasEdge(from, to uint32) (bool, error) {
+	off, length, e := g.locate(from)
+	if e != nil {
+		return false, e
+	}
+	var b [unum.Unum64Size]byte
+	head := b[:]
+	if length < int64(len(b)) {
+		head = b[:length]
+	}
+	if e := readAt(g.r, head, off); e != nil {
+		return false, e
+	}
+	degree, n0, e := decodeUnum(head, g.wide)
+	if e != nil || degree > uint64(length) {
+		return false, ErrorCorrupt
+	}
+	if degree == 0 {
+		return false, nil
+	}
+	nskips := int((degree - 1) / SkipInterval)
+	size := length - int64(n0) - 8*int64(nskips) // of the values
+	if size < 0 {
+		return false, ErrorCorrupt
+	}
+	skips := make([]byte, 8*nskips)
+	if e := readAt(g.r, skips, off+int64(n0)); e != nil {
+		return false, e
+	}
+
+	// read and search the block of to
+	c := gallop(skips, 0, to)
+	start, end := int64(0), size
+	if c > 0 {
+		start = int64(binary.BigEndian.Uint32(skips[8*(c-1)+4:]))
+	}
+	if c < nskips {
+		end = int64(binary.BigEndian.Uint32(skips[8*c+4:]))
+	}
+	if start >= end || end > size {
+		return false, ErrorCorrupt
+	}
+	block := make([]byte, end-start)
+	if e := readAt(g.r, block, off+length-size+start); e != nil {
+		return false, e
+	}
+	it := &Iterator{degree: SkipInterval, wide: g.wide, nodes: uint64(g.nodes), data: block}
+	if c == nskips {
+		it.degree = int(degree) - c*SkipInterval
+	}
+	if !it.Next() || (c > 0 && it.v != binary.BigEndian.Uint32(skips[8*(c-1):])) {
+		return false, ErrorCorrupt
+	}
+	if it.Seek(to) {
+		return it.v == to, nil
+	}
+	return false, it.Err()
+}
+
+// Iterator iterates the neighbors of a node in increasing order.
+type Iterator struct {
+	degree int
+	wide   bool
+	nodes  uint64
+	skips  []byte
+	data   []byte
+	i      int    // number of neighbors decoded
+	off    int    // offset of the next neighbor in data
+	v      uint32 // current neighbor
+	e      error
+}
+
+// Returns the number of neighbors.
+func (it *Iterator) Len() int {
+	return it.degree
+}
+
+// Advances to the next neighbor, and returns false at the end of the
+// neighbors or on error (see Err).
+func (it *Iterator) Next() bool {
+	if it.e != nil || it.i == it.degree {
+		return false
+	}
+	x, n, e := decodeUnum(it.data[it.off:], it.wide)
+	if e != nil {
+		it.e = ErrorCorrupt
+		return false
+	}
+	if it.i%SkipInterval != 0 {
+		x += uint64(it.v)
+	} else if it.i > 0 && x != uint64(skip(it.skips, it.i/SkipInterval-1)) {
+		it.e = ErrorCorrupt
+		return false
+	}
+	it.off += n
+	if x >= it.nodes || (it.i > 0 && x <= uint64(it.v)) ||
+		(it.i == it.degree-1 && it.off != len(it.data)) {
+		it.e = ErrorCorrupt
+		return false
+	}
+	it.v = uint32(x)
+	it.i++
+	return true
+}
+
+// Advances to the first neighbor >= target, unless the current neighbor
+// is >= target, and returns false if there is none or on error (see Err).
+func (it *Iterator) Seek(target uint32) bool {
+	if it.i > 0 && it.v >= target {
+		return it.e == nil
+	}
+	cur := it.i / SkipInterval
+	if c := gallop(it.skips, cur, target); c > 0 {
+		off := int(binary.BigEndian.Uint32(it.skips[8*(cur+c-1)+4:]))
+		if off > len(it.data) {
+			it.e = ErrorCorrupt
+			return false
+		}
+		it.i, it.off, it.v = (cur+c)*SkipInterval, off, 0
+	}
+	for it.Next() {
+		if it.v >= target {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the current neighbor.
+func (it *Iterator) Node() uint32 {
+	return it.v
+}
+
+// Returns the first error of the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.e
+}
+
+// Returns the first neighbor of block i+1 of skip entries skips.
+func skip(skips []byte, i int) uint32 {
+	return binary.BigEndian.Uint32(skips[8*i:])
+}
+
+// Returns the number of skip entries of skips, from entry i, with first
+// neighbor <= target, galloping then binary searching the entries.
+func gallop(skips []byte, i int, target uint32) int {
+	n := len(skips)/8 - i
+	bound := 1
+	for bound <= n && skip(skips, i+bound-1) <= target {
+		bound *= 2
+	}
+	lo, hi := bound/2, bound
+	if hi > n {
+		hi = n
+	}
+	return lo + sort.Search(hi-lo, func(k int) bool { return skip(skips, i+lo+k) > target })
+}
+
+// Decodes a UNUM-32 (or UNUM-64 if wide) value of b.
+func decodeUnum(b []byte, wide bool) (uint64, int, error) {
+	if wide {
+		return unum.DecodeUnum64(b)
+	}
+	v, n, e := unum.DecodeUnum32(b)
+	return uint64(v), n, e
+}
@@ -0,0 +1,156 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package graph_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"sort"
+	"testing"
+	"unum/graph"
+)
+
+// countingReader counts the bytes read through ReadAt.
+type countingReader struct {
+	r *bytes.Reader
+	n int
+}
+
+func (cr *countingReader) ReadAt(p []byte, off int64) (int, error) {
+	n, e := cr.r.ReadAt(p, off)
+	cr.n += n
+	return n, e
+}
+
+func build(t testing.TB, nodes int, es []graph.Edge) []byte {
+	var buf bytes.Buffer
+	if _, e := graph.Build(&buf, nodes, es); e != nil {
+		t.Fatalf("error building - e:%s\n", e.Error())
+	}
+	return buf.Bytes()
+}
+
+func TestHasEdge(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	es, adj := testGraph(r, 5000, 50000)
+	g, e := graph.Load(build(t, 5000, es))
+	if e != nil {
+		t.Fatalf("error loading - e:%s\n", e.Error())
+	}
+	for _, node := range []int{0, 1, 2, 100, 4999} {
+		l := adj[node]
+		for to := 0; to < 5000; to++ {
+			i := sort.Search(len(l), func(i int) bool { return l[i] >= uint32(to) })
+			expected := i < len(l) && l[i] == uint32(to)
+			if ok, e := g.HasEdge(uint32(node), uint32(to)); e != nil || ok != expected {
+				t.Fatalf("BUG - edge (%d, %d) - expected:%t have:%t e:%v\n", node, to, expected, ok, e)
+			}
+		}
+	}
+	if _, e := g.HasEdge(5000, 0); e != graph.ErrorNode {
+		t.Errorf("expected ErrorNode - have:%v\n", e)
+	}
+}
+
+func TestSeek(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	es, adj := testGraph(r, 5000, 50000)
+	g, _ := graph.Load(build(t, 5000, es))
+	l := adj[0]
+	it, e := g.Neighbors(0)
+	if e != nil || it.Len() != len(l) {
+		t.Fatalf("BUG - len:%d expected:%d e:%v\n", it.Len(), len(l), e)
+	}
+	// increasing targets, from the current position
+	for target := uint32(0); target < 5000; target += uint32(r.Intn(200)) {
+		i := sort.Search(len(l), func(i int) bool { return l[i] >= target })
+		ok := it.Seek(target)
+		if ok != (i < len(l)) || (ok && it.Node() != l[i]) {
+			t.Fatalf("BUG - seek:%d expected:%d have:%d\n", target, l[i], it.Node())
+		}
+		if ok && i+1 < len(l) && r.Intn(2) == 0 {
+			if !it.Next() || it.Node() != l[i+1] {
+				t.Fatalf("BUG - next after seek:%d\n", target)
+			}
+		}
+	}
+	if it.Err() != nil {
+		t.Errorf("BUG - e:%v\n", it.Err())
+	}
+}
+
+func TestReadAt(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	es, _ := testGraph(r, 100000, 400000)
+	b := build(t, 100000, es)
+	cr := &countingReader{r: bytes.NewReader(b)}
+	g, e := graph.Open(cr, int64(len(b)))
+	if e != nil {
+		t.Fatalf("error opening - e:%s\n", e.Error())
+	}
+	cr.n = 0
+	g.Degree(100)
+	g.HasEdge(100, 7)
+	if cr.n > 100 {
+		t.Errorf("BUG - read %d bytes of %d\n", cr.n, len(b))
+	}
+}
+
+func TestOpenErrors(t *testing.T) {
+	b := build(t, 4, []graph.Edge{{0, 1}, {0, 2}, {3, 0}})
+	if _, e := graph.Load(b[:len(b)-1]); e != graph.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+	if _, e := graph.Load(append([]byte("XGRF"), b[4:]...)); e != graph.ErrorMagic {
+		t.Errorf("expected ErrorMagic - have:%v\n", e)
+	}
+	// overlapping index entries
+	c := append([]byte(nil), b...)
+	binary.BigEndian.PutUint64(c[24+8:], 100)
+	g, e := graph.Load(c)
+	if e != nil {
+		t.Fatalf("error loading - e:%s\n", e.Error())
+	}
+	if _, e := g.Neighbors(0); e != graph.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+	// neighbor out of range
+	c = append([]byte(nil), b...)
+	c[len(c)-1] = 9
+	g, _ = graph.Load(c)
+	if _, e := g.AppendNeighbors(nil, 3); e != graph.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+}
+
+func BenchmarkHasEdge(b *testing.B) {
+	r := rand.New(rand.NewSource(5))
+	es, _ := testGraph(r, 100000, 1000000)
+	g, _ := graph.Load(build(b, 100000, es))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.HasEdge(uint32(i%3), uint32(i%100000))
+	}
+}
@@ -0,0 +1,45 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum
+
+// Marshaler is implemented by types that can encode themselves using
+// the unum encodings. Implementations are typically generated by
+// cmd/unumgen.
+//
+// SizeUnum returns the exact length of the image MarshalUnum would
+// produce for the current state of the value.
+type Marshaler interface {
+	MarshalUnum() ([]byte, error)
+	SizeUnum() int
+}
+
+// Unmarshaler is implemented by types that can decode an image produced
+// by the corresponding Marshaler. The entire buffer must be consumed.
+//
+// UnmarshalUnum must not retain b, which callers may reuse once it
+// returns: data of the image retained by the value must be copied (as the
+// code generated by cmd/unumgen does).
+type Unmarshaler interface {
+	UnmarshalUnum(b []byte) error
+}
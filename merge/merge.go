@@ -0,0 +1,259 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package merge implements streaming merge and set operations over delta
+// encoded sequences.
+//
+// Inputs and output are (unsigned) delta encoded UNUM-64 streams (see
+// unum.DeltaEncoder), which are non-decreasing by construction. Operators
+// hold the current value of each input, and (bounded) read and write
+// buffers, so memory use is independent of the length of the streams.
+//
+// Merge keeps duplicates. Union, Intersect and Difference treat the inputs
+// as sets: their output is strictly increasing.
+package merge
+
+import (
+	"bufio"
+	"container/heap"
+	"io"
+	"unum"
+)
+
+// input stream and its current value.
+type source struct {
+	dec *unum.DeltaDecoder
+	v   uint64
+}
+
+// reads the next value of the source. returns false at the end of the
+// stream.
+func (s *source) next() (bool, error) {
+	v, _, e := s.dec.Decode()
+	if e == unum.ErrorBufferEOF {
+		return false, nil
+	}
+	if e != nil {
+		return false, e
+	}
+	s.v = v
+	return true, nil
+}
+
+// sources is a min heap of sources, ordered by current value.
+type sources []*source
+
+func (h sources) Len() int            { return len(h) }
+func (h sources) Less(i, j int) bool  { return h[i].v < h[j].v }
+func (h sources) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *sources) Push(x interface{}) { *h = append(*h, x.(*source)) }
+func (h *sources) Pop() interface{} {
+	old := *h
+	s := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return s
+}
+
+// returns the heap of the non-empty streams of rs.
+func open(rs []io.Reader) (sources, error) {
+	h := make(sources, 0, len(rs))
+	for _, r := range rs {
+		s := &source{dec: unum.NewDeltaDecoder(bufio.NewReader(r))}
+		ok, e := s.next()
+		if e != nil {
+			return nil, e
+		}
+		if ok {
+			h = append(h, s)
+		}
+	}
+	heap.Init(&h)
+	return h, nil
+}
+
+// pops the minimum value of the heap, advancing its source.
+func (h *sources) pop() (uint64, error) {
+	s := (*h)[0]
+	v := s.v
+	ok, e := s.next()
+	if e != nil {
+		return 0, e
+	}
+	if ok {
+		heap.Fix(h, 0)
+	} else {
+		heap.Pop(h)
+	}
+	return v, nil
+}
+
+// output stream.
+type sink struct {
+	w     *bufio.Writer
+	enc   *unum.DeltaEncoder
+	count int
+	last  uint64
+}
+
+func newSink(w io.Writer) *sink {
+	bw := bufio.NewWriter(w)
+	return &sink{w: bw, enc: unum.NewDeltaEncoder(bw)}
+}
+
+func (s *sink) write(v uint64) error {
+	if _, e := s.enc.Encode(v); e != nil {
+		return e
+	}
+	s.count++
+	s.last = v
+	return nil
+}
+
+// writes v unless it is the last value written.
+func (s *sink) writeUnique(v uint64) error {
+	if s.count > 0 && v == s.last {
+		return nil
+	}
+	return s.write(v)
+}
+
+func (s *sink) flush() (int, error) {
+	return s.count, s.w.Flush()
+}
+
+// Writes the k-way merge of the streams rs to w, keeping duplicates.
+// Returns the number of values written, if there are no errors.
+//
+// On error returns (n, e) where e is:
+//    unum.ErrorInvalidBuffer   -- non-conformant input stream
+//    unum.ErrorDeltaOverflow   -- input value >= 2^62
+//    <other>                   -- propagated io.Reader or io.Writer error
+func Merge(w io.Writer, rs ...io.Reader) (n int, e error) {
+	return union(w, rs, false)
+}
+
+// Writes the union of the streams rs to w. See Merge.
+func Union(w io.Writer, rs ...io.Reader) (n int, e error) {
+	return union(w, rs, true)
+}
+
+func union(w io.Writer, rs []io.Reader, unique bool) (n int, e error) {
+	out := newSink(w)
+	h, e := open(rs)
+	if e != nil {
+		return 0, e
+	}
+	for len(h) > 0 {
+		v, e := h.pop()
+		if e != nil {
+			return out.count, e
+		}
+		if unique {
+			e = out.writeUnique(v)
+		} else {
+			e = out.write(v)
+		}
+		if e != nil {
+			return out.count, e
+		}
+	}
+	return out.flush()
+}
+
+// Writes the intersection of the streams rs to w. See Merge.
+func Intersect(w io.Writer, rs ...io.Reader) (n int, e error) {
+	out := newSink(w)
+	h, e := open(rs)
+	if e != nil || len(h) < len(rs) || len(rs) == 0 {
+		// (some input is empty)
+		return 0, e
+	}
+	for {
+		// advance all sources to the maximum current value
+		max := h[0].v
+		for _, s := range h {
+			if s.v > max {
+				max = s.v
+			}
+		}
+		match := true
+		for _, s := range h {
+			for s.v < max {
+				ok, e := s.next()
+				if e != nil {
+					return out.count, e
+				}
+				if !ok {
+					return out.flush()
+				}
+			}
+			if s.v != max {
+				match = false
+			}
+		}
+		if !match {
+			continue
+		}
+		if e := out.writeUnique(max); e != nil {
+			return out.count, e
+		}
+		ok, e := h[0].next()
+		if e != nil {
+			return out.count, e
+		}
+		if !ok {
+			return out.flush()
+		}
+	}
+}
+
+// Writes the values of stream a not in any of the streams rs to w. See
+// Merge.
+func Difference(w io.Writer, a io.Reader, rs ...io.Reader) (n int, e error) {
+	out := newSink(w)
+	src := &source{dec: unum.NewDeltaDecoder(bufio.NewReader(a))}
+	h, e := open(rs)
+	if e != nil {
+		return 0, e
+	}
+	for {
+		ok, e := src.next()
+		if e != nil {
+			return out.count, e
+		}
+		if !ok {
+			return out.flush()
+		}
+		for len(h) > 0 && h[0].v < src.v {
+			if _, e := h.pop(); e != nil {
+				return out.count, e
+			}
+		}
+		if len(h) > 0 && h[0].v == src.v {
+			continue
+		}
+		if e := out.writeUnique(src.v); e != nil {
+			return out.count, e
+		}
+	}
+}
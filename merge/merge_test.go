@@ -0,0 +1,262 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package merge_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"sort"
+	"testing"
+	"unum"
+	"unum/merge"
+)
+
+func encode(vs []uint64) *bytes.Buffer {
+	var buf bytes.Buffer
+	enc := unum.NewDeltaEncoder(&buf)
+	for _, v := range vs {
+		enc.Encode(v)
+	}
+	return &buf
+}
+
+func decode(t *testing.T, b []byte) []uint64 {
+	vs := make([]uint64, 0)
+	dec := unum.NewDeltaDecoder(bytes.NewReader(b))
+	for {
+		v, _, e := dec.Decode()
+		if e == unum.ErrorBufferEOF {
+			return vs
+		}
+		if e != nil {
+			t.Fatalf("error decoding output - e:%s\n", e.Error())
+		}
+		vs = append(vs, v)
+	}
+}
+
+// sorted random values, with duplicates
+func testValues(r *rand.Rand, n int, span int64) []uint64 {
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = 1<<40 + uint64(r.Int63n(span))
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i] < vs[j] })
+	return vs
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func readers(inputs [][]uint64) []io.Reader {
+	rs := make([]io.Reader, len(inputs))
+	for i, vs := range inputs {
+		rs[i] = encode(vs)
+	}
+	return rs
+}
+
+func TestOperators(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for trial := 0; trial < 20; trial++ {
+		k := 1 + r.Intn(5)
+		inputs := make([][]uint64, k)
+		counts := make([]map[uint64]int, k)
+		for i := range inputs {
+			inputs[i] = testValues(r, r.Intn(2000), 3000)
+			counts[i] = map[uint64]int{}
+			for _, v := range inputs[i] {
+				counts[i][v]++
+			}
+		}
+		var all []uint64
+		for _, vs := range inputs {
+			all = append(all, vs...)
+		}
+		sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+		var union, inter, diff []uint64
+		for i, v := range all {
+			if i > 0 && all[i-1] == v {
+				continue
+			}
+			union = append(union, v)
+			in, inRest := true, false
+			for j := range counts {
+				in = in && counts[j][v] > 0
+				inRest = inRest || j > 0 && counts[j][v] > 0
+			}
+			if in {
+				inter = append(inter, v)
+			}
+			if counts[0][v] > 0 && !inRest {
+				diff = append(diff, v)
+			}
+		}
+
+		for _, tc := range []struct {
+			name     string
+			op       func(io.Writer) (int, error)
+			expected []uint64
+		}{
+			{"merge", func(w io.Writer) (int, error) { return merge.Merge(w, readers(inputs)...) }, all},
+			{"union", func(w io.Writer) (int, error) { return merge.Union(w, readers(inputs)...) }, union},
+			{"intersect", func(w io.Writer) (int, error) { return merge.Intersect(w, readers(inputs)...) }, inter},
+			{"difference", func(w io.Writer) (int, error) {
+				rs := readers(inputs)
+				return merge.Difference(w, rs[0], rs[1:]...)
+			}, diff},
+		} {
+			var out bytes.Buffer
+			n, e := tc.op(&out)
+			if e != nil {
+				t.Fatalf("%s - error - e:%s\n", tc.name, e.Error())
+			}
+			vs := decode(t, out.Bytes())
+			if n != len(tc.expected) || !equal(vs, tc.expected) {
+				t.Fatalf("BUG - %s - k:%d n:%d expected:%d\n", tc.name, k, n, len(tc.expected))
+			}
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if n, e := merge.Union(&out); n != 0 || e != nil || out.Len() != 0 {
+		t.Errorf("BUG - empty union - n:%d e:%v\n", n, e)
+	}
+	if n, e := merge.Intersect(&out, encode([]uint64{1, 2}), encode(nil)); n != 0 || e != nil {
+		t.Errorf("BUG - empty intersect - n:%d e:%v\n", n, e)
+	}
+	if n, e := merge.Difference(&out, encode([]uint64{1, 2})); n != 2 || e != nil {
+		t.Errorf("BUG - difference of nothing - n:%d e:%v\n", n, e)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	// truncated 2 byte image
+	bad := []byte{1, 0x40}
+	for _, op := range []func() (int, error){
+		func() (int, error) {
+			return merge.Merge(io.Discard, encode([]uint64{1, 2, 3}), bytes.NewReader(bad))
+		},
+		func() (int, error) {
+			return merge.Intersect(io.Discard, encode([]uint64{1, 2, 3}), bytes.NewReader(bad))
+		},
+		func() (int, error) {
+			return merge.Difference(io.Discard, bytes.NewReader(bad), encode([]uint64{0}))
+		},
+	} {
+		if _, e := op(); e != unum.ErrorInvalidBuffer {
+			t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+		}
+	}
+	// values written before the error are counted
+	for _, rs := range [][]io.Reader{
+		{encode([]uint64{1, 2, 3}), bytes.NewReader(bad)},
+		{bytes.NewReader(bad), encode([]uint64{1, 2, 3})},
+	} {
+		if n, e := merge.Intersect(io.Discard, rs...); n != 1 || e != unum.ErrorInvalidBuffer {
+			t.Errorf("intersect - expected (1, ErrorInvalidBuffer) - have:(%d, %v)\n", n, e)
+		}
+	}
+}
+
+// seq generates the delta encoded stream of n values start, start+step, ..
+// without holding it in memory.
+type seq struct {
+	v, step uint64
+	n       int
+	buf     []byte
+	first   bool
+}
+
+func newSeq(start, step uint64, n int) *seq {
+	return &seq{v: start, step: step, n: n, first: true}
+}
+
+func (s *seq) Read(p []byte) (int, error) {
+	for len(s.buf) < len(p) && s.n > 0 {
+		var b [unum.Unum64Size]byte
+		d := s.step
+		if s.first {
+			d, s.first = s.v, false
+		}
+		n, _ := unum.EncodeUnum64(b[:], d)
+		s.buf = append(s.buf, b[:n]...)
+		s.n--
+	}
+	if len(s.buf) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, s.buf)
+	s.buf = s.buf[n:]
+	return n, nil
+}
+
+type countWriter int
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	*c += countWriter(len(p))
+	return len(p), nil
+}
+
+func TestLargeStreams(t *testing.T) {
+	// multiples of 2, 3 and 5 below 3,000,000
+	const max = 3000000
+	var w countWriter
+	n, e := merge.Union(&w, newSeq(0, 2, max/2), newSeq(0, 3, max/3), newSeq(0, 5, max/5))
+	if e != nil || n != max*11/15 {
+		t.Errorf("BUG - union - n:%d e:%v\n", n, e)
+	}
+	n, e = merge.Intersect(&w, newSeq(0, 2, max/2), newSeq(0, 3, max/3), newSeq(0, 5, max/5))
+	if e != nil || n != max/30 {
+		t.Errorf("BUG - intersect - n:%d e:%v\n", n, e)
+	}
+}
+
+func BenchmarkUnion(b *testing.B) {
+	r := rand.New(rand.NewSource(2))
+	var images [][]byte
+	for i := 0; i < 8; i++ {
+		images = append(images, encode(testValues(r, 100000, 1<<30)).Bytes())
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rs := make([]io.Reader, len(images))
+		for j, p := range images {
+			rs[j] = bytes.NewReader(p)
+		}
+		merge.Union(io.Discard, rs...)
+	}
+}
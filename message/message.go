@@ -0,0 +1,409 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package message implements a tag-length-value wire format for structured
+// messages, built on the unum integer encodings.
+//
+// A message is a sequence of fields. Each field is a header followed by a
+// value. The header is the UNUM-32 image of the field number and the wire
+// type of the value:
+//
+//      header: UNUM-32( field-number << 3 | wire-type )
+//
+//      wire-type    | code | value image
+//      -------------+------+------------------------------------------
+//      WireUint     | 0    | UNUM-64
+//      WireInt      | 1    | zigzag UNUM-64
+//      WireFixed64  | 2    | 8 bytes, big-endian
+//      WireBytes    | 3    | UNUM-32 length, followed by length bytes
+//      WireMessage  | 4    | UNUM-32 length, followed by a nested message
+//
+// Field numbers are in range [1, MaxField]. The length of every value is
+// determined by its wire type, so a Parser skips fields it does not know
+// about. Old readers can therefore read messages written by new writers
+// that have added fields, provided field numbers are never reused.
+package message
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unum"
+)
+
+type WireType uint8
+
+const (
+	WireUint    WireType = 0
+	WireInt     WireType = 1
+	WireFixed64 WireType = 2
+	WireBytes   WireType = 3
+	WireMessage WireType = 4
+)
+
+func (t WireType) String() string {
+	switch t {
+	case WireUint:
+		return "uint"
+	case WireInt:
+		return "int"
+	case WireFixed64:
+		return "fixed64"
+	case WireBytes:
+		return "bytes"
+	case WireMessage:
+		return "message"
+	}
+	return fmt.Sprintf("WireType(%d)", uint8(t))
+}
+
+// Maximum field number
+const MaxField = int(unum.Unum32ValueBound>>3) - 1
+
+// Errors
+var (
+	ErrorFieldNumber = fmt.Errorf("message.ErrorFieldNumber")
+	ErrorWireType    = fmt.Errorf("message.ErrorWireType")
+)
+
+// ----------------------------------------------------------------------------
+// Builder
+// ----------------------------------------------------------------------------
+
+// Builder appends fields to a message buffer.
+type Builder struct {
+	buf []byte
+}
+
+// Returns a new Builder appending to buf (which may be nil).
+func NewBuilder(buf []byte) *Builder {
+	return &Builder{buf: buf}
+}
+
+// Returns the message image. The slice aliases the Builder's buffer.
+func (b *Builder) Image() []byte {
+	return b.buf
+}
+
+// Returns the length of the message image.
+func (b *Builder) Len() int {
+	return len(b.buf)
+}
+
+// Truncates the message to zero length, retaining the buffer.
+func (b *Builder) Reset() {
+	b.buf = b.buf[:0]
+}
+
+func (b *Builder) header(field int, t WireType) error {
+	if field < 1 || field > MaxField {
+		return ErrorFieldNumber
+	}
+	return b.appendUnum32(uint32(field)<<3 | uint32(t))
+}
+
+func (b *Builder) appendUnum32(v uint32) (e error) {
+	b.buf, e = unum.AppendUnum32(b.buf, v)
+	return e
+}
+
+func (b *Builder) appendUnum64(v uint64) (e error) {
+	b.buf, e = unum.AppendUnum64(b.buf, v)
+	return e
+}
+
+// Appends an unsigned integer field. The message is unchanged on error.
+//
+// On error returns e:
+//    ErrorFieldNumber     -- invalid arg field
+//    unum.ErrorMaxValue   -- invalid arg v : v >= 2^62
+func (b *Builder) Uint(field int, v uint64) error {
+	if v >= unum.Unum64ValueBound {
+		return unum.ErrorMaxValue
+	}
+	if e := b.header(field, WireUint); e != nil {
+		return e
+	}
+	return b.appendUnum64(v)
+}
+
+// Appends a signed integer field. The message is unchanged on error.
+//
+// On error returns e:
+//    ErrorFieldNumber     -- invalid arg field
+//    unum.ErrorMaxValue   -- invalid arg v : v < -2^61 or v >= 2^61
+func (b *Builder) Int(field int, v int64) error {
+	if v < -unum.Int64ValueBound || v >= unum.Int64ValueBound {
+		return unum.ErrorMaxValue
+	}
+	if e := b.header(field, WireInt); e != nil {
+		return e
+	}
+	return b.appendUnum64(unum.Zigzag64(v))
+}
+
+// Appends a fixed 64-bit field.
+//
+// On error returns e:
+//    ErrorFieldNumber     -- invalid arg field
+func (b *Builder) Fixed64(field int, v uint64) error {
+	if e := b.header(field, WireFixed64); e != nil {
+		return e
+	}
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	b.buf = append(b.buf, tmp[:]...)
+	return nil
+}
+
+// Appends a float64 as a fixed 64-bit field.
+func (b *Builder) Float64(field int, v float64) error {
+	return b.Fixed64(field, math.Float64bits(v))
+}
+
+// Appends a length-delimited bytes field. The message is unchanged on error.
+//
+// On error returns e:
+//    ErrorFieldNumber     -- invalid arg field
+//    unum.ErrorMaxValue   -- invalid arg p : len(p) >= 2^30
+func (b *Builder) Bytes(field int, p []byte) error {
+	return b.delimited(field, WireBytes, p)
+}
+
+// Appends a string as a length-delimited bytes field.
+func (b *Builder) String(field int, s string) error {
+	if uint64(len(s)) >= uint64(unum.Unum32ValueBound) {
+		return unum.ErrorMaxValue
+	}
+	if e := b.header(field, WireBytes); e != nil {
+		return e
+	}
+	b.appendUnum32(uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	return nil
+}
+
+// Appends the message built by m as a nested message field.
+// The message is unchanged on error.
+//
+// On error returns e:
+//    ErrorFieldNumber     -- invalid arg field
+//    unum.ErrorMaxValue   -- invalid arg m : m.Len() >= 2^30
+func (b *Builder) Message(field int, m *Builder) error {
+	return b.delimited(field, WireMessage, m.buf)
+}
+
+func (b *Builder) delimited(field int, t WireType, p []byte) error {
+	if uint64(len(p)) >= uint64(unum.Unum32ValueBound) {
+		return unum.ErrorMaxValue
+	}
+	if e := b.header(field, t); e != nil {
+		return e
+	}
+	b.appendUnum32(uint32(len(p)))
+	b.buf = append(b.buf, p...)
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// Parser
+// ----------------------------------------------------------------------------
+
+// Parser iterates over the fields of a message image.
+//
+//      p := message.NewParser(b)
+//      for p.Next() {
+//              switch p.Field() {
+//              case 1:
+//                      id, e = p.Uint()
+//              case 2:
+//                      name, e = p.String()
+//              }
+//      }
+//      if e := p.Err(); e != nil {
+//              ...
+//      }
+//
+// Fields that are not read (e.g. unknown fields) are skipped by Next.
+type Parser struct {
+	b     []byte
+	off   int      // offset of next field header
+	field int      // current field number
+	wt    WireType // current field wire type
+	v     []byte   // current field value image (excluding length prefix)
+	err   error
+}
+
+// Returns a new Parser for message image b.
+func NewParser(b []byte) *Parser {
+	return &Parser{b: b}
+}
+
+// Advances to the next field. Returns false at the end of the message or
+// on error; see Err.
+func (p *Parser) Next() bool {
+	if p.err != nil || p.off == len(p.b) {
+		p.field, p.v = 0, nil
+		return false
+	}
+
+	key, n, e := unum.DecodeUnum32(p.b[p.off:])
+	if e != nil {
+		return p.fail(unum.ErrorInvalidBuffer)
+	}
+	field, wt := int(key>>3), WireType(key&0x7)
+	if field == 0 {
+		return p.fail(ErrorFieldNumber)
+	}
+	off := p.off + n
+
+	var vlen int
+	switch wt {
+	case WireUint, WireInt:
+		if off == len(p.b) {
+			return p.fail(unum.ErrorInvalidBuffer)
+		}
+		vlen = 1 << (p.b[off] >> 6)
+	case WireFixed64:
+		vlen = 8
+	case WireBytes, WireMessage:
+		l, n, e := unum.DecodeUnum32(p.b[off:])
+		if e != nil {
+			return p.fail(unum.ErrorInvalidBuffer)
+		}
+		off += n
+		vlen = int(l)
+	default:
+		return p.fail(ErrorWireType)
+	}
+	if len(p.b)-off < vlen {
+		return p.fail(unum.ErrorInvalidBuffer)
+	}
+
+	p.field, p.wt = field, wt
+	p.v = p.b[off : off+vlen]
+	p.off = off + vlen
+	return true
+}
+
+func (p *Parser) fail(e error) bool {
+	p.err = e
+	p.field, p.v = 0, nil
+	return false
+}
+
+// Returns the first error encountered by Next, if any.
+func (p *Parser) Err() error {
+	return p.err
+}
+
+// Returns the field number of the current field.
+func (p *Parser) Field() int {
+	return p.field
+}
+
+// Returns the wire type of the current field.
+func (p *Parser) Type() WireType {
+	return p.wt
+}
+
+// Returns the offset of the next field in the message image.
+func (p *Parser) Offset() int {
+	return p.off
+}
+
+// Returns the raw value image of the current field. For length-delimited
+// fields, the length prefix is not included. The slice aliases the message.
+func (p *Parser) Raw() []byte {
+	return p.v
+}
+
+// Returns the value of the current WireUint field.
+//
+// On error returns (0, e) where e is:
+//    ErrorWireType  -- current field is not WireUint
+func (p *Parser) Uint() (uint64, error) {
+	if p.wt != WireUint || p.field == 0 {
+		return 0, ErrorWireType
+	}
+	v, _, e := unum.DecodeUnum64(p.v)
+	return v, e
+}
+
+// Returns the value of the current WireInt field.
+//
+// On error returns (0, e) where e is:
+//    ErrorWireType  -- current field is not WireInt
+func (p *Parser) Int() (int64, error) {
+	if p.wt != WireInt || p.field == 0 {
+		return 0, ErrorWireType
+	}
+	v, _, e := unum.DecodeInt64(p.v)
+	return v, e
+}
+
+// Returns the value of the current WireFixed64 field.
+//
+// On error returns (0, e) where e is:
+//    ErrorWireType  -- current field is not WireFixed64
+func (p *Parser) Fixed64() (uint64, error) {
+	if p.wt != WireFixed64 || p.field == 0 {
+		return 0, ErrorWireType
+	}
+	return binary.BigEndian.Uint64(p.v), nil
+}
+
+// Returns the value of the current WireFixed64 field as a float64.
+func (p *Parser) Float64() (float64, error) {
+	v, e := p.Fixed64()
+	return math.Float64frombits(v), e
+}
+
+// Returns the value of the current WireBytes field. The slice aliases the
+// message.
+//
+// On error returns (nil, e) where e is:
+//    ErrorWireType  -- current field is not WireBytes
+func (p *Parser) Bytes() ([]byte, error) {
+	if p.wt != WireBytes || p.field == 0 {
+		return nil, ErrorWireType
+	}
+	return p.v, nil
+}
+
+// Returns the value of the current WireBytes field as a string.
+func (p *Parser) String() (string, error) {
+	v, e := p.Bytes()
+	return string(v), e
+}
+
+// Returns a Parser for the nested message of the current WireMessage field.
+//
+// On error returns (nil, e) where e is:
+//    ErrorWireType  -- current field is not WireMessage
+func (p *Parser) Message() (*Parser, error) {
+	if p.wt != WireMessage || p.field == 0 {
+		return nil, ErrorWireType
+	}
+	return NewParser(p.v), nil
+}
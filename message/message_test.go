@@ -0,0 +1,241 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package message_test
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"testing/quick"
+	"unum"
+	"unum/message"
+)
+
+type record struct {
+	id    uint64
+	delta int64
+	score float64
+	name  string
+	data  []byte
+}
+
+// version 1 of the record schema
+func (r *record) build(b *message.Builder) error {
+	if e := b.Uint(1, r.id); e != nil {
+		return e
+	}
+	if e := b.Int(2, r.delta); e != nil {
+		return e
+	}
+	if e := b.Float64(3, r.score); e != nil {
+		return e
+	}
+	if e := b.String(4, r.name); e != nil {
+		return e
+	}
+	return b.Bytes(5, r.data)
+}
+
+func (r *record) parse(p *message.Parser) (e error) {
+	for p.Next() {
+		switch p.Field() {
+		case 1:
+			r.id, e = p.Uint()
+		case 2:
+			r.delta, e = p.Int()
+		case 3:
+			r.score, e = p.Float64()
+		case 4:
+			r.name, e = p.String()
+		case 5:
+			r.data, e = p.Bytes()
+		}
+		if e != nil {
+			return e
+		}
+	}
+	return p.Err()
+}
+
+func TestRoundTrip(t *testing.T) {
+	f := func(id uint64, delta int64, score float64, name string, data []byte) bool {
+		r := record{id >> 2, delta >> 3, score, name, data}
+		b := message.NewBuilder(nil)
+		if e := r.build(b); e != nil {
+			t.Errorf("error building - e:%s\n", e.Error())
+			return false
+		}
+		var r0 record
+		if e := r0.parse(message.NewParser(b.Image())); e != nil {
+			t.Errorf("error parsing - e:%s\n", e.Error())
+			return false
+		}
+		if r0.id != r.id || r0.delta != r.delta || r0.name != r.name || !bytes.Equal(r0.data, r.data) ||
+			math.Float64bits(r0.score) != math.Float64bits(r.score) {
+			t.Errorf("BUG - r:%v - r0:%v\n", r, r0)
+			return false
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+// version 1 readers must read version 2 messages
+func TestSkipUnknownFields(t *testing.T) {
+	r := record{id: 7, delta: -3, score: 0.5, name: "seven", data: []byte{1, 2, 3}}
+
+	nested := message.NewBuilder(nil)
+	nested.Uint(1, 99)
+	nested.String(2, "nested")
+
+	b := message.NewBuilder(nil)
+	b.Fixed64(100, 0xdeadbeef) // new fields, interleaved
+	b.Uint(1, r.id)
+	b.Message(101, nested)
+	b.Int(2, r.delta)
+	b.Bytes(102, bytes.Repeat([]byte{0xff}, 300))
+	b.Float64(3, r.score)
+	b.Int(103, -1<<40)
+	b.String(4, r.name)
+	b.Uint(message.MaxField, unum.Unum64ValueBound-1)
+	b.Bytes(5, r.data)
+
+	var r0 record
+	if e := r0.parse(message.NewParser(b.Image())); e != nil {
+		t.Fatalf("error parsing - e:%s\n", e.Error())
+	}
+	if r0.id != r.id || r0.delta != r.delta || r0.score != r.score || r0.name != r.name || !bytes.Equal(r0.data, r.data) {
+		t.Errorf("BUG - r:%v - r0:%v\n", r, r0)
+	}
+}
+
+func TestNestedMessage(t *testing.T) {
+	inner := message.NewBuilder(nil)
+	inner.Uint(1, 42)
+	inner.String(2, "inner")
+	outer := message.NewBuilder(nil)
+	outer.Uint(1, 1)
+	outer.Message(2, inner)
+	outer.Uint(3, 3)
+
+	p := message.NewParser(outer.Image())
+	var fields []int
+	for p.Next() {
+		fields = append(fields, p.Field())
+		if p.Field() != 2 {
+			continue
+		}
+		if p.Type() != message.WireMessage {
+			t.Fatalf("expected WireMessage - have:%s\n", p.Type())
+		}
+		np, e := p.Message()
+		if e != nil {
+			t.Fatalf("error - e:%s\n", e.Error())
+		}
+		if !np.Next() || np.Field() != 1 {
+			t.Fatalf("expected field 1 - have:%d e:%v\n", np.Field(), np.Err())
+		}
+		if v, e := np.Uint(); e != nil || v != 42 {
+			t.Errorf("expected 42 - have:%d e:%v\n", v, e)
+		}
+		if !np.Next() {
+			t.Fatalf("expected field 2 - e:%v\n", np.Err())
+		}
+		if s, e := np.String(); e != nil || s != "inner" {
+			t.Errorf("expected inner - have:%q e:%v\n", s, e)
+		}
+		if np.Next() || np.Err() != nil {
+			t.Errorf("expected end of nested message - e:%v\n", np.Err())
+		}
+	}
+	if p.Err() != nil || len(fields) != 3 || fields[2] != 3 {
+		t.Errorf("unexpected fields - have:%v e:%v\n", fields, p.Err())
+	}
+}
+
+func TestBuilderErrors(t *testing.T) {
+	b := message.NewBuilder(nil)
+	if e := b.Uint(0, 1); e != message.ErrorFieldNumber {
+		t.Errorf("expected ErrorFieldNumber - have:%v\n", e)
+	}
+	if e := b.Uint(message.MaxField+1, 1); e != message.ErrorFieldNumber {
+		t.Errorf("expected ErrorFieldNumber - have:%v\n", e)
+	}
+	if e := b.Uint(1, unum.Unum64ValueBound); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if e := b.Int(1, unum.Int64ValueBound); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if b.Len() != 0 {
+		t.Errorf("expected unchanged message on error - len:%d\n", b.Len())
+	}
+}
+
+func TestParserErrors(t *testing.T) {
+	r := record{id: 1 << 40, delta: -5, score: 1, name: "name", data: []byte("data")}
+	b := message.NewBuilder(nil)
+	r.build(b)
+	image := b.Image()
+
+	// every proper prefix that does not end on a field boundary is invalid
+	boundaries := map[int]bool{0: true}
+	p := message.NewParser(image)
+	for p.Next() {
+		boundaries[p.Offset()] = true
+	}
+	for i := 0; i < len(image); i++ {
+		var r0 record
+		e := r0.parse(message.NewParser(image[:i]))
+		if boundaries[i] && e != nil {
+			t.Errorf("unexpected error - len:%d e:%s\n", i, e.Error())
+		} else if !boundaries[i] && e != unum.ErrorInvalidBuffer {
+			t.Errorf("expected ErrorInvalidBuffer - len:%d have:%v\n", i, e)
+		}
+	}
+
+	// reserved wire type
+	p = message.NewParser([]byte{1<<3 | 7, 0})
+	if p.Next() || p.Err() != message.ErrorWireType {
+		t.Errorf("expected ErrorWireType - have:%v\n", p.Err())
+	}
+
+	// field 0
+	p = message.NewParser([]byte{0, 0})
+	if p.Next() || p.Err() != message.ErrorFieldNumber {
+		t.Errorf("expected ErrorFieldNumber - have:%v\n", p.Err())
+	}
+
+	// wire type mismatch
+	p = message.NewParser(image)
+	p.Next()
+	if _, e := p.Int(); e != message.ErrorWireType {
+		t.Errorf("expected ErrorWireType - have:%v\n", e)
+	}
+	if _, e := p.Bytes(); e != message.ErrorWireType {
+		t.Errorf("expected ErrorWireType - have:%v\n", e)
+	}
+}
@@ -0,0 +1,378 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package pfor implements frame-of-reference encoding of blocks of unsigned
+// integer values, with bit packing and patched exceptions.
+//
+// Clustered values (e.g. ids in [10000000, 10000900]) are encoded as their
+// difference from the block minimum (the frame of reference), packed at the
+// bit width w that minimizes the block size. Differences that do not fit w
+// bits (outliers) are exceptions: their low w bits are packed with the rest
+// and their high bits are patched in from the exception list. The block is:
+//
+//      count       UNUM-32         number of values, <= MaxBlockLen
+//      min         UNUM-64         frame of reference
+//      width       UNUM-16         bit width w, in [0, 62]
+//      exceptions  UNUM-32         number of exceptions
+//      packed      [ceil(count*w/8)]byte
+//                                  low w bits of each value - min, in
+//                                  little-endian bit order
+//      exception:  gap  UNUM-32    index - (previous exception index + 1)
+//                  high UNUM-64    (value - min) >> w
+package pfor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorBlock = fmt.Errorf("pfor.ErrorBlock")
+)
+
+const (
+	// BlockSize is the number of values per block of Encode.
+	BlockSize = 128
+	// MaxWidth is the maximum bit width of a block.
+	MaxWidth = 62
+	// MaxBlockLen is the maximum number of values of a block. It bounds
+	// the values decoded from blocks of width 0, which pack to 0 bytes.
+	MaxBlockLen = 1 << 16
+)
+
+// block layout of a sequence of values.
+type plan struct {
+	min        uint64
+	width      uint
+	exceptions int
+	size       int // encoded size
+}
+
+// returns the number of bytes of n values packed at width w.
+func packedLen(n int, w uint) int {
+	return int((uint64(n)*uint64(w) + 7) >> 3)
+}
+
+// returns the smallest block layout of vs.
+func planBlock(vs []uint64) (p plan, e error) {
+	if len(vs) > MaxBlockLen {
+		return p, unum.ErrorMaxValue
+	}
+	if len(vs) > 0 {
+		p.min = vs[0]
+	}
+	var max uint64
+	for _, v := range vs {
+		if v >= unum.Unum64ValueBound {
+			return p, unum.ErrorMaxValue
+		}
+		if v < p.min {
+			p.min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	maxWidth := uint(bits.Len64(max - p.min))
+	head := unum.SizeUnum32(uint32(len(vs))) + unum.SizeUnum64(p.min) + 1
+	p.size = -1
+	for w := maxWidth; ; w-- {
+		size, n := packedLen(len(vs), w), 0
+		if w < maxWidth {
+			prev := -1
+			for i, v := range vs {
+				if d := v - p.min; d>>w != 0 {
+					size += unum.SizeUnum32(uint32(i-prev-1)) + unum.SizeUnum64(d>>w)
+					prev = i
+					n++
+				}
+			}
+		}
+		size += head + unum.SizeUnum32(uint32(n))
+		if p.size < 0 || size < p.size {
+			p.width, p.exceptions, p.size = w, n, size
+		}
+		if w == 0 {
+			break
+		}
+	}
+	return p, nil
+}
+
+// Returns the encoded size of block vs, or 0 if vs is not encodable.
+func SizeBlock(vs []uint64) int {
+	p, e := planBlock(vs)
+	if e != nil {
+		return 0
+	}
+	return p.size
+}
+
+// Encodes the values vs as a single block in buffer b.
+// returns number of bytes written on nil error.
+//
+// On error returns (0, e) where e is:
+//    unum.ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    unum.ErrorMaxValue        -- invalid arg vs : value >= 2^62, or
+//                                 len(vs) > MaxBlockLen
+func EncodeBlock(b []byte, vs []uint64) (n int, e error) {
+	p, e := planBlock(vs)
+	if e != nil {
+		return 0, e
+	}
+	if len(b) < p.size {
+		return 0, unum.ErrorBufferOverflow
+	}
+	n, _ = unum.EncodeUnum32(b, uint32(len(vs)))
+	n0, _ := unum.EncodeUnum64(b[n:], p.min)
+	n += n0
+	n0, _ = unum.EncodeUnum16(b[n:], uint16(p.width))
+	n += n0
+	n0, _ = unum.EncodeUnum32(b[n:], uint32(p.exceptions))
+	n += n0
+
+	packed := b[n : n+packedLen(len(vs), p.width)]
+	pack(packed, vs, p.min, p.width)
+	n += len(packed)
+
+	if p.exceptions > 0 {
+		prev := -1
+		for i, v := range vs {
+			if d := v - p.min; d>>p.width != 0 {
+				n0, _ = unum.EncodeUnum32(b[n:], uint32(i-prev-1))
+				n += n0
+				n0, _ = unum.EncodeUnum64(b[n:], d>>p.width)
+				n += n0
+				prev = i
+			}
+		}
+	}
+	return n, nil
+}
+
+// packs the low w bits of (vs[i] - min) in p.
+func pack(p []byte, vs []uint64, min uint64, w uint) {
+	for i := range p {
+		p[i] = 0
+	}
+	if w == 0 {
+		return
+	}
+	mask := uint64(1)<<w - 1
+	var acc uint64 // pending bits, low first
+	var nb uint    // number of pending bits
+	var j int
+	for _, v := range vs {
+		d := (v - min) & mask
+		acc |= d << nb
+		if nb+w >= 64 {
+			// flush the full accumulator, carrying the bits of d that
+			// did not fit
+			binary.LittleEndian.PutUint64(p[j:], acc)
+			j += 8
+			nb = nb + w - 64
+			acc = 0
+			if nb > 0 {
+				acc = d >> (w - nb)
+			}
+			continue
+		}
+		nb += w
+	}
+	for ; nb > 0; j++ {
+		p[j] = byte(acc)
+		acc >>= 8
+		if nb < 8 {
+			break
+		}
+		nb -= 8
+	}
+}
+
+// unpacks len(dst) values of w bits from p.
+func unpack(dst []uint64, p []byte, w uint) {
+	switch w {
+	case 0:
+		for i := range dst {
+			dst[i] = 0
+		}
+	case 8:
+		for i := range dst {
+			dst[i] = uint64(p[i])
+		}
+	case 16:
+		for i := range dst {
+			dst[i] = uint64(binary.LittleEndian.Uint16(p[2*i:]))
+		}
+	case 32:
+		for i := range dst {
+			dst[i] = uint64(binary.LittleEndian.Uint32(p[4*i:]))
+		}
+	default:
+		unpackBits(dst, p, w)
+	}
+}
+
+// unpacks len(dst) values of w bits from p, reading 64-bit windows.
+func unpackBits(dst []uint64, p []byte, w uint) {
+	mask := uint64(1)<<w - 1
+	var pos uint
+	for i := range dst {
+		j, off := int(pos>>3), pos&7
+		var x uint64
+		if j+8 <= len(p) {
+			x = binary.LittleEndian.Uint64(p[j:]) >> off
+			if off+w > 64 {
+				x |= uint64(p[j+8]) << (64 - off)
+			}
+		} else {
+			// tail of p
+			for k := len(p) - 1; k >= j; k-- {
+				x = x<<8 | uint64(p[k])
+			}
+			x >>= off
+		}
+		dst[i] = x & mask
+		pos += w
+	}
+}
+
+// Decodes a block from b, appending its values to dst.
+// Returns the values and number of bytes read n, if there are no errors.
+//
+// On error returns (dst, 0, e) where e is:
+//    ErrorBlock               -- invalid block header or exceptions
+//    unum.ErrorInvalidBuffer  -- invalid arg b : non-conformant (or
+//                                truncated) byte sequence
+func DecodeBlock(dst []uint64, b []byte) (vs []uint64, n int, e error) {
+	count, n, e := unum.DecodeUnum32(b)
+	if e != nil {
+		return dst, 0, unum.ErrorInvalidBuffer
+	}
+	min, n0, e := unum.DecodeUnum64(b[n:])
+	if e != nil {
+		return dst, 0, unum.ErrorInvalidBuffer
+	}
+	n += n0
+	w16, n0, e := unum.DecodeUnum16(b[n:])
+	if e != nil {
+		return dst, 0, unum.ErrorInvalidBuffer
+	}
+	n += n0
+	xn, n0, e := unum.DecodeUnum32(b[n:])
+	if e != nil {
+		return dst, 0, unum.ErrorInvalidBuffer
+	}
+	n += n0
+	w := uint(w16)
+	if w > MaxWidth || xn > count || int(count) > MaxBlockLen {
+		return dst, 0, ErrorBlock
+	}
+	plen := packedLen(int(count), w)
+	if len(b)-n < plen {
+		return dst, 0, unum.ErrorInvalidBuffer
+	}
+	start := len(dst)
+	for i := 0; i < int(count); i++ {
+		dst = append(dst, 0)
+	}
+	vs = dst[start:]
+	unpack(vs, b[n:n+plen], w)
+	n += plen
+
+	pos := -1
+	for k := 0; k < int(xn); k++ {
+		gap, n0, e := unum.DecodeUnum32(b[n:])
+		if e != nil {
+			return dst[:start], 0, unum.ErrorInvalidBuffer
+		}
+		n += n0
+		high, n0, e := unum.DecodeUnum64(b[n:])
+		if e != nil {
+			return dst[:start], 0, unum.ErrorInvalidBuffer
+		}
+		n += n0
+		pos += int(gap) + 1
+		if pos >= len(vs) || high == 0 || high>>(MaxWidth-w) != 0 {
+			return dst[:start], 0, ErrorBlock
+		}
+		vs[pos] |= high << w
+	}
+	for i, d := range vs {
+		v := min + d
+		if v < min || v >= unum.Unum64ValueBound {
+			return dst[:start], 0, ErrorBlock
+		}
+		vs[i] = v
+	}
+	return dst, n, nil
+}
+
+// Returns the encoded size of vs in blocks of BlockSize values, or 0 if vs
+// is not encodable.
+func Size(vs []uint64) (n int) {
+	for i := 0; i < len(vs); i += BlockSize {
+		n0 := SizeBlock(vs[i:minInt(i+BlockSize, len(vs))])
+		if n0 == 0 {
+			return 0
+		}
+		n += n0
+	}
+	return n
+}
+
+// Encodes the values vs in buffer b, in blocks of BlockSize values.
+// returns number of bytes written on nil error. See EncodeBlock.
+func Encode(b []byte, vs []uint64) (n int, e error) {
+	for i := 0; i < len(vs); i += BlockSize {
+		n0, e := EncodeBlock(b[n:], vs[i:minInt(i+BlockSize, len(vs))])
+		if e != nil {
+			return 0, e
+		}
+		n += n0
+	}
+	return n, nil
+}
+
+// Decodes all blocks of b, appending their values to dst. See DecodeBlock.
+func Decode(dst []uint64, b []byte) ([]uint64, error) {
+	for len(b) > 0 {
+		var n int
+		var e error
+		if dst, n, e = DecodeBlock(dst, b); e != nil {
+			return dst, e
+		}
+		b = b[n:]
+	}
+	return dst, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
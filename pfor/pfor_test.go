@@ -0,0 +1,189 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package pfor_test
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"unum"
+	"unum/pfor"
+)
+
+// clustered ids with the given fraction of outliers
+func testIds(n int, outliers float64) []uint64 {
+	r := rand.New(rand.NewSource(int64(n)))
+	vs := make([]uint64, n)
+	for i := range vs {
+		vs[i] = 10000000 + uint64(r.Intn(900))
+		if r.Float64() < outliers {
+			vs[i] += uint64(r.Int63n(1 << 40))
+		}
+	}
+	return vs
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncodeDecode(t *testing.T) {
+	fn := func(vs []uint64, shift uint8) bool {
+		for i := range vs {
+			vs[i] >>= 2 + shift%62
+		}
+		b := make([]byte, pfor.Size(vs))
+		n, e := pfor.Encode(b, vs)
+		if e != nil {
+			t.Errorf("error encoding - e:%s\n", e.Error())
+			return false
+		}
+		if n != len(b) {
+			t.Errorf("BUG - n:%d size:%d\n", n, len(b))
+			return false
+		}
+		vs0, e := pfor.Decode(nil, b)
+		if e != nil {
+			t.Errorf("error decoding - e:%s\n", e.Error())
+			return false
+		}
+		return equal(vs, vs0)
+	}
+	if e := quick.Check(fn, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestWidths(t *testing.T) {
+	// every width, with block lengths that do not fill the last word
+	r := rand.New(rand.NewSource(1))
+	for w := uint(0); w <= pfor.MaxWidth; w++ {
+		for _, n := range []int{1, 7, 8, 9, 63, 64, 65, 127} {
+			vs := make([]uint64, n)
+			for i := range vs {
+				vs[i] = uint64(r.Int63()) & (1<<w - 1)
+			}
+			vs[0] = 1<<w - 1 // block uses all w bits
+			vs[n-1] = 0
+			b := make([]byte, pfor.SizeBlock(vs))
+			if _, e := pfor.EncodeBlock(b, vs); e != nil {
+				t.Fatalf("error encoding - w:%d e:%s\n", w, e.Error())
+			}
+			vs0, n0, e := pfor.DecodeBlock(nil, b)
+			if e != nil || n0 != len(b) || !equal(vs, vs0) {
+				t.Fatalf("BUG - w:%d n:%d e:%v\n", w, n, e)
+			}
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	// 900 distinct values need 10 bits
+	vs := testIds(pfor.BlockSize, 0)
+	if n := pfor.SizeBlock(vs); n > 10*pfor.BlockSize/8+8 {
+		t.Errorf("block not compact - size:%d\n", n)
+	}
+	// outliers are patched, not widening the block
+	vs = testIds(pfor.BlockSize, 0.02)
+	var u int
+	for _, v := range vs {
+		u += unum.SizeUnum64(v)
+	}
+	if n := pfor.SizeBlock(vs); n > u/2 {
+		t.Errorf("block not compact - size:%d unum:%d\n", n, u)
+	}
+	// constant blocks have no packed bits
+	vs = make([]uint64, 1000)
+	if n := pfor.SizeBlock(vs); n != 5 {
+		t.Errorf("expected size 5 - have:%d\n", n)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	vs := testIds(1000, 0.05)
+	b := make([]byte, pfor.Size(vs))
+	if _, e := pfor.Encode(b[:len(b)-1], vs); e != unum.ErrorBufferOverflow {
+		t.Errorf("expected ErrorBufferOverflow - have:%v\n", e)
+	}
+	if _, e := pfor.Encode(b, []uint64{unum.Unum64ValueBound}); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if n := pfor.SizeBlock(make([]uint64, pfor.MaxBlockLen+1)); n != 0 {
+		t.Errorf("BUG - size of block of MaxBlockLen+1 values:%d\n", n)
+	}
+	pfor.Encode(b, vs)
+	if _, e := pfor.Decode(nil, b[:len(b)-1]); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+	for _, b := range [][]byte{
+		{1, 0, 63, 0},                     // width > 62
+		{1, 0, 0, 2},                      // more exceptions than values
+		{1, 0, 0, 1, 1, 1},                // exception index out of range
+		{1, 0, 0, 1, 0, 0},                // zero exception
+		{0xff, 0xff, 0xff, 0xff, 0, 0, 0}, // 2^30-1 values of width 0
+		{0xc0, 0x01, 0x00, 0x01, 0, 0, 0}, // MaxBlockLen+1 values of width 0
+	} {
+		if _, e := pfor.Decode(nil, b); e != pfor.ErrorBlock {
+			t.Errorf("expected ErrorBlock - b:%x have:%v\n", b, e)
+		}
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	vs := testIds(4096, 0.01)
+	buf := make([]byte, pfor.Size(vs))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pfor.Encode(buf, vs)
+	}
+	b.ReportMetric(float64(len(buf))/float64(len(vs)), "bytes/value")
+}
+
+func benchmarkDecode(b *testing.B, w uint) {
+	vs := make([]uint64, 4096)
+	for i := range vs {
+		vs[i] = uint64(i*7919) & (1<<w - 1)
+	}
+	buf := make([]byte, pfor.Size(vs))
+	pfor.Encode(buf, vs)
+	dst := make([]uint64, 0, len(vs))
+	b.SetBytes(int64(8 * len(vs)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pfor.Decode(dst[:0], buf)
+	}
+}
+
+func BenchmarkDecode8(b *testing.B)  { benchmarkDecode(b, 8) }
+func BenchmarkDecode10(b *testing.B) { benchmarkDecode(b, 10) }
+func BenchmarkDecode16(b *testing.B) { benchmarkDecode(b, 16) }
+func BenchmarkDecode32(b *testing.B) { benchmarkDecode(b, 32) }
@@ -0,0 +1,404 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package polyline implements compact encoding of geographic polylines,
+// such as GPS traces.
+//
+// Latitude and longitude, and optionally elevation and time, are quantized
+// to fixed-point values of a configurable precision, and each point is
+// encoded as the zigzag deltas of its values from the previous point's.
+// Successive points of a trace are near each other, and their deltas
+// encode in one or two bytes, against the 16 bytes of a pair of float64s.
+// The image is:
+//
+//      flags       UNUM-16     bit 0: elevation, bit 1: time
+//      precision   UNUM-16     decimal digits of latitude and longitude
+//      elevation   UNUM-16     decimal digits of elevation (if flagged)
+//      resolution  UNUM-64     nanoseconds per time tick (if flagged)
+//      count       UNUM-64     number of points
+//      points:     per point, deltas from previous point (or 0)
+//      latitude    zigzag UNUM-32  delta of quantized latitude
+//      longitude   zigzag UNUM-32  delta of quantized longitude
+//      elevation   zigzag UNUM-64  delta of quantized elevation (if flagged)
+//      time        zigzag UNUM-64  delta of time in ticks (if flagged)
+package polyline
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorFormat = fmt.Errorf("polyline.ErrorFormat")
+	ErrorPoint  = fmt.Errorf("polyline.ErrorPoint")
+	ErrorBlock  = fmt.Errorf("polyline.ErrorBlock")
+)
+
+// MaxPrecision is the maximum number of decimal digits of quantized
+// values. At 6 digits latitude and longitude are quantized to about 0.1m.
+const MaxPrecision = 6
+
+// DefaultFormat encodes latitude and longitude to 5 decimal digits (about
+// 1m), without elevation or time.
+var DefaultFormat = Format{Precision: 5}
+
+const (
+	flagElevation = 1 << iota
+	flagTime
+)
+
+// Point is a point of a polyline. Elevation and Time are encoded only if
+// the Format specifies them.
+type Point struct {
+	Lat, Lng  float64 // degrees
+	Elevation float64
+	Time      time.Time
+}
+
+// Format specifies the quantization of the points of a polyline.
+type Format struct {
+	Precision          int           // decimal digits of latitude and longitude
+	Elevation          bool          // encode elevation
+	ElevationPrecision int           // decimal digits of elevation
+	TimeResolution     time.Duration // encode time, truncated to resolution, if > 0
+}
+
+// Returns ErrorFormat if the format is not valid.
+func (f Format) check() error {
+	if f.Precision < 0 || f.Precision > MaxPrecision || f.TimeResolution < 0 ||
+		(f.Elevation && (f.ElevationPrecision < 0 || f.ElevationPrecision > MaxPrecision)) {
+		return ErrorFormat
+	}
+	return nil
+}
+
+// Returns the header of the image of the format, with count points.
+func (f Format) appendHeader(b []byte, count int) []byte {
+	var flags uint16
+	if f.Elevation {
+		flags |= flagElevation
+	}
+	if f.TimeResolution > 0 {
+		flags |= flagTime
+	}
+	b, _ = unum.AppendUnum16(b, flags)
+	b, _ = unum.AppendUnum16(b, uint16(f.Precision))
+	if f.Elevation {
+		b, _ = unum.AppendUnum16(b, uint16(f.ElevationPrecision))
+	}
+	if f.TimeResolution > 0 {
+		b, _ = unum.AppendUnum64(b, uint64(f.TimeResolution))
+	}
+	b, _ = unum.AppendUnum64(b, uint64(count))
+	return b
+}
+
+// quantized values of a point
+type fixed struct {
+	lat, lng int32
+	elev     int64
+	ticks    int64
+}
+
+// bound of quantized elevation and time, so their deltas are encodable
+const bound = unum.Int64ValueBound / 2
+
+// Returns the quantized values of point p.
+func (f Format) quantize(p Point) (q fixed, e error) {
+	if !(p.Lat >= -90 && p.Lat <= 90 && p.Lng >= -180 && p.Lng <= 180) {
+		return q, ErrorPoint
+	}
+	scale := pow10[f.Precision]
+	q.lat = int32(math.Round(p.Lat * scale))
+	q.lng = int32(math.Round(p.Lng * scale))
+	if f.Elevation {
+		v := math.Round(p.Elevation * pow10[f.ElevationPrecision])
+		if !(v > -float64(bound) && v < float64(bound)) {
+			return q, ErrorPoint
+		}
+		q.elev = int64(v)
+	}
+	if f.TimeResolution > 0 {
+		q.ticks = p.Time.UnixNano() / int64(f.TimeResolution)
+		if q.ticks <= -bound || q.ticks >= bound {
+			return q, ErrorPoint
+		}
+	}
+	return q, nil
+}
+
+// Returns the point of quantized values q.
+func (f Format) point(q fixed) (p Point) {
+	scale := pow10[f.Precision]
+	p.Lat = float64(q.lat) / scale
+	p.Lng = float64(q.lng) / scale
+	if f.Elevation {
+		p.Elevation = float64(q.elev) / pow10[f.ElevationPrecision]
+	}
+	if f.TimeResolution > 0 {
+		p.Time = time.Unix(0, q.ticks*int64(f.TimeResolution)).UTC()
+	}
+	return p
+}
+
+var pow10 = [MaxPrecision + 1]float64{1, 1e1, 1e2, 1e3, 1e4, 1e5, 1e6}
+
+// Encoder encodes a polyline, a point at a time.
+type Encoder struct {
+	f       Format
+	payload []byte
+	count   int
+	prev    fixed
+}
+
+// Returns a new Encoder of format f.
+//
+// On error returns (nil, e) where e is:
+//    ErrorFormat  -- invalid arg f : precision not in [0, MaxPrecision] or
+//                    negative time resolution
+func NewEncoder(f Format) (*Encoder, error) {
+	if e := f.check(); e != nil {
+		return nil, e
+	}
+	return &Encoder{f: f}, nil
+}
+
+// Returns an Encoder appending to polyline b, of the format of b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock  -- invalid arg b : non-conformant image
+func Resume(b []byte) (*Encoder, error) {
+	d, e := NewDecoder(b)
+	if e != nil {
+		return nil, e
+	}
+	for d.i < d.count {
+		if _, e := d.Next(); e != nil {
+			return nil, ErrorBlock
+		}
+	}
+	enc := &Encoder{f: d.f, count: d.count, prev: d.prev}
+	enc.payload = append(enc.payload, d.payload[:d.off]...)
+	return enc, nil
+}
+
+// Appends point p. The encoder state is unchanged on error.
+//
+// On error returns e:
+//    ErrorPoint  -- invalid arg p : latitude not in [-90, 90], longitude not
+//                   in [-180, 180], or elevation or time out of range
+func (enc *Encoder) Append(p Point) error {
+	q, e := enc.f.quantize(p)
+	if e != nil {
+		return e
+	}
+	// deltas are in range for quantized values in range
+	var b [2*unum.Unum32Size + 2*unum.Unum64Size]byte
+	n, _ := unum.EncodeInt32(b[:], q.lat-enc.prev.lat)
+	n0, _ := unum.EncodeInt32(b[n:], q.lng-enc.prev.lng)
+	n += n0
+	if enc.f.Elevation {
+		n0, _ = unum.EncodeInt64(b[n:], q.elev-enc.prev.elev)
+		n += n0
+	}
+	if enc.f.TimeResolution > 0 {
+		n0, _ = unum.EncodeInt64(b[n:], q.ticks-enc.prev.ticks)
+		n += n0
+	}
+	enc.payload = append(enc.payload, b[:n]...)
+	enc.prev = q
+	enc.count++
+	return nil
+}
+
+// Returns the number of points appended.
+func (enc *Encoder) Len() int {
+	return enc.count
+}
+
+// Returns the format of the encoder.
+func (enc *Encoder) Format() Format {
+	return enc.f
+}
+
+// Returns the image of the polyline.
+func (enc *Encoder) Bytes() []byte {
+	b := enc.f.appendHeader(make([]byte, 0, 16+len(enc.payload)), enc.count)
+	return append(b, enc.payload...)
+}
+
+// Resets the encoder to begin a new polyline, retaining the format.
+func (enc *Encoder) Reset() {
+	enc.payload = enc.payload[:0]
+	enc.count, enc.prev = 0, fixed{}
+}
+
+// Returns the image of the polyline of points ps in format f.
+//
+// On error returns (nil, e) where e is:
+//    ErrorFormat  -- invalid arg f : see NewEncoder
+//    ErrorPoint   -- invalid arg ps : see Encoder.Append
+func Encode(f Format, ps []Point) ([]byte, error) {
+	enc, e := NewEncoder(f)
+	if e != nil {
+		return nil, e
+	}
+	for _, p := range ps {
+		if e := enc.Append(p); e != nil {
+			return nil, e
+		}
+	}
+	return enc.Bytes(), nil
+}
+
+// Decoder decodes a polyline produced by Encoder.
+type Decoder struct {
+	f       Format
+	count   int
+	payload []byte
+	i       int
+	off     int
+	prev    fixed
+}
+
+// Returns a new Decoder of polyline b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock  -- invalid arg b : non-conformant header
+func NewDecoder(b []byte) (*Decoder, error) {
+	var f Format
+	var n, off int
+	var e error
+	var flags, u16 uint16
+	var u64, count uint64
+	if flags, n, e = unum.DecodeUnum16(b); e != nil || flags > flagElevation|flagTime {
+		return nil, ErrorBlock
+	}
+	off += n
+	if u16, n, e = unum.DecodeUnum16(b[off:]); e != nil {
+		return nil, ErrorBlock
+	}
+	off += n
+	f.Precision = int(u16)
+	if flags&flagElevation != 0 {
+		if u16, n, e = unum.DecodeUnum16(b[off:]); e != nil {
+			return nil, ErrorBlock
+		}
+		off += n
+		f.Elevation, f.ElevationPrecision = true, int(u16)
+	}
+	if flags&flagTime != 0 {
+		if u64, n, e = unum.DecodeUnum64(b[off:]); e != nil || u64 == 0 || u64 > math.MaxInt64 {
+			return nil, ErrorBlock
+		}
+		off += n
+		f.TimeResolution = time.Duration(u64)
+	}
+	if count, n, e = unum.DecodeUnum64(b[off:]); e != nil {
+		return nil, ErrorBlock
+	}
+	off += n
+	// points are at least 2 bytes
+	if f.check() != nil || count > uint64(len(b)-off)/2 {
+		return nil, ErrorBlock
+	}
+	return &Decoder{f: f, count: int(count), payload: b[off:]}, nil
+}
+
+// Returns the format of the polyline.
+func (d *Decoder) Format() Format {
+	return d.f
+}
+
+// Returns the number of points of the polyline.
+func (d *Decoder) Len() int {
+	return d.count
+}
+
+// Returns the next point. Latitude, longitude and elevation are the
+// quantized values, and time is in UTC.
+//
+// On error returns (Point{}, e) where e is:
+//    unum.ErrorBufferEOF      -- end of polyline
+//    unum.ErrorInvalidBuffer  -- non-conformant polyline
+func (d *Decoder) Next() (Point, error) {
+	if d.i == d.count {
+		return Point{}, unum.ErrorBufferEOF
+	}
+	b := d.payload[d.off:]
+	q := d.prev
+	dlat, n, e := unum.DecodeInt32(b)
+	if e != nil {
+		return Point{}, unum.ErrorInvalidBuffer
+	}
+	dlng, n0, e := unum.DecodeInt32(b[n:])
+	if e != nil {
+		return Point{}, unum.ErrorInvalidBuffer
+	}
+	n += n0
+	q.lat += dlat
+	q.lng += dlng
+	if d.f.Elevation {
+		delta, n0, e := unum.DecodeInt64(b[n:])
+		if e != nil {
+			return Point{}, unum.ErrorInvalidBuffer
+		}
+		n += n0
+		q.elev += delta
+	}
+	if d.f.TimeResolution > 0 {
+		delta, n0, e := unum.DecodeInt64(b[n:])
+		if e != nil {
+			return Point{}, unum.ErrorInvalidBuffer
+		}
+		n += n0
+		q.ticks += delta
+	}
+	d.off += n
+	d.prev = q
+	d.i++
+	return d.f.point(q), nil
+}
+
+// Returns the points of polyline b, appended to dst.
+//
+// On error returns (dst, e) where e is:
+//    ErrorBlock               -- invalid arg b : non-conformant header
+//    unum.ErrorInvalidBuffer  -- invalid arg b : non-conformant points
+func Decode(dst []Point, b []byte) ([]Point, error) {
+	d, e := NewDecoder(b)
+	if e != nil {
+		return dst, e
+	}
+	for d.i < d.count {
+		p, e := d.Next()
+		if e != nil {
+			return dst, e
+		}
+		dst = append(dst, p)
+	}
+	return dst, nil
+}
@@ -0,0 +1,212 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package polyline_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+	"unum"
+	"unum/polyline"
+)
+
+// simulated GPS trace, a point per second at walking to driving speeds
+func testTrace(r *rand.Rand, n int) []polyline.Point {
+	ps := make([]polyline.Point, n)
+	p := polyline.Point{Lat: 37.7749, Lng: -122.4194, Elevation: 16, Time: time.Unix(1500000000, 0)}
+	heading, speed := r.Float64()*2*math.Pi, 1.5
+	for i := range ps {
+		heading += r.NormFloat64() * 0.1
+		speed = math.Max(0, speed+r.NormFloat64()*0.5)
+		// ~111km per degree
+		p.Lat += speed * math.Cos(heading) / 111000
+		p.Lng += speed * math.Sin(heading) / 88000
+		p.Elevation += r.NormFloat64() * 0.2
+		p.Time = p.Time.Add(time.Second)
+		ps[i] = p
+	}
+	return ps
+}
+
+var full = polyline.Format{Precision: 6, Elevation: true, ElevationPrecision: 1, TimeResolution: time.Second}
+
+func checkPoints(t *testing.T, f polyline.Format, expected, have []polyline.Point) {
+	if len(have) != len(expected) {
+		t.Fatalf("BUG - len:%d expected:%d\n", len(have), len(expected))
+	}
+	unit := math.Pow10(-f.Precision) / 2
+	for i, p := range expected {
+		q := have[i]
+		if math.Abs(p.Lat-q.Lat) > unit || math.Abs(p.Lng-q.Lng) > unit {
+			t.Fatalf("BUG - point:%d expected:%v have:%v\n", i, p, q)
+		}
+		if f.Elevation && math.Abs(p.Elevation-q.Elevation) > math.Pow10(-f.ElevationPrecision)/2 {
+			t.Fatalf("BUG - point:%d elevation expected:%g have:%g\n", i, p.Elevation, q.Elevation)
+		}
+		if f.TimeResolution > 0 && !q.Time.Equal(p.Time.Truncate(f.TimeResolution)) {
+			t.Fatalf("BUG - point:%d time expected:%v have:%v\n", i, p.Time, q.Time)
+		}
+	}
+}
+
+func TestEncodeDecode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	ps := testTrace(r, 5000)
+	for _, f := range []polyline.Format{polyline.DefaultFormat, {Precision: 0}, full} {
+		b, e := polyline.Encode(f, ps)
+		if e != nil {
+			t.Fatalf("error encoding - e:%s\n", e.Error())
+		}
+		d, e := polyline.NewDecoder(b)
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		if d.Format() != f || d.Len() != len(ps) {
+			t.Errorf("BUG - format:%v len:%d\n", d.Format(), d.Len())
+		}
+		qs, e := polyline.Decode(nil, b)
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		checkPoints(t, f, ps, qs)
+	}
+}
+
+func TestResume(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	ps := testTrace(r, 300)
+	b, _ := polyline.Encode(full, ps[:100])
+	enc, e := polyline.Resume(b)
+	if e != nil {
+		t.Fatalf("error resuming - e:%s\n", e.Error())
+	}
+	if enc.Len() != 100 || enc.Format() != full {
+		t.Errorf("BUG - len:%d format:%v\n", enc.Len(), enc.Format())
+	}
+	for _, p := range ps[100:] {
+		if e := enc.Append(p); e != nil {
+			t.Fatalf("error appending - e:%s\n", e.Error())
+		}
+	}
+	all, _ := polyline.Encode(full, ps)
+	if !bytes.Equal(enc.Bytes(), all) {
+		t.Errorf("BUG - resumed image differs\n")
+	}
+	if _, e := polyline.Resume(b[:len(b)-1]); e != polyline.ErrorBlock {
+		t.Errorf("expected ErrorBlock - have:%v\n", e)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	for _, f := range []polyline.Format{
+		{Precision: 7}, {Precision: -1}, {Elevation: true, ElevationPrecision: 7}, {TimeResolution: -1},
+	} {
+		if _, e := polyline.NewEncoder(f); e != polyline.ErrorFormat {
+			t.Errorf("expected ErrorFormat - format:%v have:%v\n", f, e)
+		}
+	}
+	enc, _ := polyline.NewEncoder(full)
+	for _, p := range []polyline.Point{
+		{Lat: 91}, {Lng: -180.5}, {Lat: math.NaN()}, {Elevation: math.Inf(1)}, {Elevation: 1e30},
+	} {
+		if e := enc.Append(p); e != polyline.ErrorPoint {
+			t.Errorf("expected ErrorPoint - point:%v have:%v\n", p, e)
+		}
+	}
+	if enc.Len() != 0 {
+		t.Errorf("BUG - len:%d after errors\n", enc.Len())
+	}
+	// extreme points
+	ps := []polyline.Point{{Lat: 90, Lng: 180}, {Lat: -90, Lng: -180}, {Lat: 90, Lng: 180}}
+	b, e := polyline.Encode(polyline.Format{Precision: polyline.MaxPrecision}, ps)
+	if e != nil {
+		t.Fatalf("error encoding - e:%s\n", e.Error())
+	}
+	if qs, e := polyline.Decode(nil, b); e != nil || qs[1] != ps[1] {
+		t.Errorf("BUG - points:%v e:%v\n", qs, e)
+	}
+	if _, e := polyline.Decode(nil, b[:len(b)-1]); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+	if _, e := polyline.NewDecoder([]byte{4, 5, 0}); e != polyline.ErrorBlock {
+		t.Errorf("expected ErrorBlock - have:%v\n", e)
+	}
+}
+
+// raw float64 pairs, the current approach
+func encodeRaw(ps []polyline.Point) []byte {
+	b := make([]byte, 16*len(ps))
+	for i, p := range ps {
+		binary.BigEndian.PutUint64(b[16*i:], math.Float64bits(p.Lat))
+		binary.BigEndian.PutUint64(b[16*i+8:], math.Float64bits(p.Lng))
+	}
+	return b
+}
+
+func TestSize(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	ps := testTrace(r, 10000)
+	raw := encodeRaw(ps)
+	b, _ := polyline.Encode(polyline.DefaultFormat, ps)
+	if 4*len(b) > len(raw) {
+		t.Errorf("polyline not compact - raw:%d encoded:%d\n", len(raw), len(b))
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	r := rand.New(rand.NewSource(4))
+	ps := testTrace(r, 10000)
+	for _, tc := range []struct {
+		name string
+		f    polyline.Format
+	}{{"default", polyline.DefaultFormat}, {"full", full}} {
+		b.Run(tc.name, func(b *testing.B) {
+			var p []byte
+			for i := 0; i < b.N; i++ {
+				p, _ = polyline.Encode(tc.f, ps)
+			}
+			b.ReportMetric(float64(len(p))/float64(len(ps)), "bytes/point")
+		})
+	}
+	b.Run("raw", func(b *testing.B) {
+		var p []byte
+		for i := 0; i < b.N; i++ {
+			p = encodeRaw(ps)
+		}
+		b.ReportMetric(float64(len(p))/float64(len(ps)), "bytes/point")
+	})
+}
+
+func BenchmarkDecode(b *testing.B) {
+	r := rand.New(rand.NewSource(5))
+	p, _ := polyline.Encode(full, testTrace(r, 10000))
+	ps := make([]polyline.Point, 0, 10000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ps, _ = polyline.Decode(ps[:0], p)
+	}
+}
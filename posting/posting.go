@@ -0,0 +1,330 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package posting implements compressed posting lists of document ids with
+// skip entries.
+//
+// Document ids are strictly increasing UNUM-32 (< 2^30) values, delta
+// encoded in blocks of a fixed number of ids. Each block has a skip entry
+// with the last id of the block and the offset of the block in the
+// payload, so iterators can advance to a target id by searching the skip
+// entries and decoding only the block that holds it. The image is:
+//
+//      count       UNUM-32     number of ids
+//      blocksize   UNUM-32     number of ids per block (last block may be short)
+//      skips:      per block
+//      last        UNUM-32     last id of the block
+//      offset      UNUM-32     offset of the block in the payload
+//      payload:    per block
+//      deltas      [n]UNUM-32  id - previous id, with the previous id of the
+//                              first block 0
+package posting
+
+import (
+	"fmt"
+	"sort"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorOrder   = fmt.Errorf("posting.ErrorOrder")
+	ErrorCorrupt = fmt.Errorf("posting.ErrorCorrupt")
+)
+
+// DefaultBlockSize is the number of ids per block of Encode with
+// blockSize <= 0.
+const DefaultBlockSize = 128
+
+// Encodes the strictly increasing ids in blocks of blockSize ids (or
+// DefaultBlockSize if blockSize <= 0), and returns the image.
+//
+// On error returns (nil, e) where e is:
+//    unum.ErrorMaxValue  -- invalid arg ids : id >= 2^30
+//    ErrorOrder          -- invalid arg ids : not strictly increasing
+func Encode(ids []uint32, blockSize int) ([]byte, error) {
+	if blockSize <= 0 {
+		blockSize = DefaultBlockSize
+	}
+	if blockSize >= int(unum.Unum32ValueBound) {
+		blockSize = int(unum.Unum32ValueBound - 1)
+	}
+	var payload, skips []byte
+	var prev uint32
+	var start int // offset of current block
+	for i, id := range ids {
+		if id >= unum.Unum32ValueBound {
+			return nil, unum.ErrorMaxValue
+		}
+		if i > 0 && id <= prev {
+			return nil, ErrorOrder
+		}
+		if i%blockSize == 0 {
+			if i > 0 {
+				skips, _ = unum.AppendUnum32(skips, prev)
+				skips, _ = unum.AppendUnum32(skips, uint32(start))
+			}
+			start = len(payload)
+			if start >= int(unum.Unum32ValueBound) {
+				return nil, unum.ErrorMaxValue
+			}
+		}
+		payload, _ = unum.AppendUnum32(payload, id-prev)
+		prev = id
+	}
+	if len(ids) > 0 {
+		skips, _ = unum.AppendUnum32(skips, prev)
+		skips, _ = unum.AppendUnum32(skips, uint32(start))
+	}
+	b, _ := unum.AppendUnum32(nil, uint32(len(ids)))
+	b, _ = unum.AppendUnum32(b, uint32(blockSize))
+	b = append(b, skips...)
+	return append(b, payload...), nil
+}
+
+// skip entry of a block.
+type skip struct {
+	last   uint32
+	offset int
+}
+
+// List is a posting list image, with its skip entries decoded.
+type List struct {
+	count     int
+	blockSize int
+	skips     []skip
+	payload   []byte
+}
+
+// Returns the List of image b. The list refers to b, which must not be
+// modified while the list is in use. The skip entries are checked, but the
+// blocks are not decoded; iterators report corrupt blocks.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCorrupt  -- invalid arg b : non-conformant image
+func NewList(b []byte) (*List, error) {
+	var vs [2]uint32
+	for i := range vs {
+		v, n, e := unum.DecodeUnum32(b)
+		if e != nil {
+			return nil, ErrorCorrupt
+		}
+		vs[i], b = v, b[n:]
+	}
+	l := &List{count: int(vs[0]), blockSize: int(vs[1])}
+	if l.blockSize == 0 || l.count > len(b) {
+		return nil, ErrorCorrupt
+	}
+	l.skips = make([]skip, (l.count+l.blockSize-1)/l.blockSize)
+	for i := range l.skips {
+		last, n, e := unum.DecodeUnum32(b)
+		if e != nil {
+			return nil, ErrorCorrupt
+		}
+		offset, n0, e := unum.DecodeUnum32(b[n:])
+		if e != nil {
+			return nil, ErrorCorrupt
+		}
+		b = b[n+n0:]
+		l.skips[i] = skip{last: last, offset: int(offset)}
+		if i > 0 && (last <= l.skips[i-1].last || l.skips[i].offset <= l.skips[i-1].offset) ||
+			i == 0 && offset != 0 {
+			return nil, ErrorCorrupt
+		}
+	}
+	if len(l.skips) > 0 && l.skips[len(l.skips)-1].offset >= len(b) {
+		return nil, ErrorCorrupt
+	}
+	l.payload = b
+	return l, nil
+}
+
+// Returns the number of ids in the list.
+func (l *List) Len() int {
+	return l.count
+}
+
+// Returns the ids of the list, appended to dst.
+//
+// On error returns (dst, e) where e is:
+//    ErrorCorrupt  -- non-conformant block
+func (l *List) Values(dst []uint32) ([]uint32, error) {
+	it := l.Iterator()
+	for it.Next() {
+		dst = append(dst, it.Doc())
+	}
+	return dst, it.Err()
+}
+
+// Iterator iterates over the ids of a List in increasing order.
+type Iterator struct {
+	l     *List
+	block int // current block
+	i     int // ids of current block read
+	off   int // offset of next delta in payload
+	doc   uint32
+	e     error
+}
+
+// Returns an iterator positioned before the first id of the list.
+func (l *List) Iterator() *Iterator {
+	return &Iterator{l: l, block: -1}
+}
+
+// returns the number of ids of block k.
+func (l *List) blockLen(k int) int {
+	if k == len(l.skips)-1 {
+		return l.count - k*l.blockSize
+	}
+	return l.blockSize
+}
+
+// positions the iterator before the first id of block k.
+func (it *Iterator) seekBlock(k int) {
+	it.block, it.i = k, 0
+	if k < len(it.l.skips) {
+		it.off = it.l.skips[k].offset
+		it.doc = 0
+		if k > 0 {
+			it.doc = it.l.skips[k-1].last
+		}
+	}
+}
+
+// Advances to the next id. Returns false at the end of the list, or on
+// error (see Err).
+func (it *Iterator) Next() bool {
+	if it.e != nil || it.block >= len(it.l.skips) {
+		return false
+	}
+	if it.block < 0 || it.i == it.l.blockLen(it.block) {
+		if it.block+1 >= len(it.l.skips) {
+			it.block = len(it.l.skips)
+			return false
+		}
+		it.seekBlock(it.block + 1)
+	}
+	d, n, e := unum.DecodeUnum32(it.l.payload[it.off:])
+	if e != nil || (d == 0 && (it.block > 0 || it.i > 0)) {
+		it.e = ErrorCorrupt
+		return false
+	}
+	it.off += n
+	it.doc += d
+	it.i++
+	if it.i == it.l.blockLen(it.block) && it.doc != it.l.skips[it.block].last {
+		it.e = ErrorCorrupt
+		return false
+	}
+	return true
+}
+
+// Advances to the first id not less than target, skipping the blocks that
+// precede it without decoding them. Returns false if there is no such id,
+// or on error (see Err). The iterator does not move backwards: if the
+// current id is not less than target, Advance returns true.
+func (it *Iterator) Advance(target uint32) bool {
+	if it.e != nil || it.block >= len(it.l.skips) {
+		return false
+	}
+	if it.block >= 0 && it.i > 0 && it.doc >= target {
+		return true
+	}
+	from := it.block
+	if from < 0 {
+		from = 0
+	}
+	skips := it.l.skips[from:]
+	k := from + sort.Search(len(skips), func(i int) bool { return skips[i].last >= target })
+	if k == len(it.l.skips) {
+		it.block = k
+		return false
+	}
+	if k != it.block {
+		it.seekBlock(k)
+	}
+	for it.Next() {
+		if it.doc >= target {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the current id.
+func (it *Iterator) Doc() uint32 {
+	return it.doc
+}
+
+// Returns the error that ended the iteration, if any.
+func (it *Iterator) Err() error {
+	return it.e
+}
+
+// Returns the ids common to all lists, appended to dst.
+//
+// The lists are intersected in order of length: each id of the shortest
+// list is a target to which the iterators of longer lists advance.
+//
+// On error returns (dst, e) where e is:
+//    ErrorCorrupt  -- non-conformant block
+func Intersect(dst []uint32, lists ...*List) ([]uint32, error) {
+	if len(lists) == 0 {
+		return dst, nil
+	}
+	its := make([]*Iterator, len(lists))
+	for i, l := range lists {
+		its[i] = l.Iterator()
+	}
+	sort.Slice(its, func(i, j int) bool { return its[i].l.count < its[j].l.count })
+
+	ok := its[0].Next()
+	for ok {
+		target := its[0].doc
+		match := true
+		for _, it := range its[1:] {
+			if !it.Advance(target) {
+				return dst, firstErr(its)
+			}
+			if it.doc != target {
+				// the next candidate is not less than it.doc
+				ok, match = its[0].Advance(it.doc), false
+				break
+			}
+		}
+		if match {
+			dst = append(dst, target)
+			ok = its[0].Next()
+		}
+	}
+	return dst, firstErr(its)
+}
+
+func firstErr(its []*Iterator) error {
+	for _, it := range its {
+		if it.e != nil {
+			return it.e
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package posting_test
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+	"unum"
+	"unum/posting"
+)
+
+// n random strictly increasing ids in [0, span)
+func testIds(r *rand.Rand, n int, span int32) []uint32 {
+	m := map[uint32]bool{}
+	for len(m) < n {
+		m[uint32(r.Int31n(span))] = true
+	}
+	ids := make([]uint32, 0, n)
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func equal(a, b []uint32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func newList(t *testing.T, ids []uint32, blockSize int) *posting.List {
+	b, e := posting.Encode(ids, blockSize)
+	if e != nil {
+		t.Fatalf("error encoding - e:%s\n", e.Error())
+	}
+	l, e := posting.NewList(b)
+	if e != nil {
+		t.Fatalf("error opening list - e:%s\n", e.Error())
+	}
+	return l
+}
+
+func TestEncodeDecode(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, tc := range []struct{ n, blockSize int }{
+		{0, 0}, {1, 0}, {128, 0}, {129, 0}, {1000, 1}, {5000, 64},
+	} {
+		ids := testIds(r, tc.n, 1<<29)
+		if tc.n > 0 {
+			ids[0] = 0
+		}
+		l := newList(t, ids, tc.blockSize)
+		if l.Len() != len(ids) {
+			t.Errorf("expected len %d - have:%d\n", len(ids), l.Len())
+		}
+		ids0, e := l.Values(nil)
+		if e != nil || !equal(ids, ids0) {
+			t.Errorf("BUG - n:%d blockSize:%d e:%v\n", tc.n, tc.blockSize, e)
+		}
+	}
+	if _, e := posting.Encode([]uint32{1, 1}, 0); e != posting.ErrorOrder {
+		t.Errorf("expected ErrorOrder - have:%v\n", e)
+	}
+	if _, e := posting.Encode([]uint32{unum.Unum32ValueBound}, 0); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+}
+
+func TestAdvance(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	ids := testIds(r, 10000, 1<<20)
+	l := newList(t, ids, 32)
+	it := l.Iterator()
+	for i := 0; i < len(ids); i += 1 + r.Intn(200) {
+		target := ids[i]
+		if r.Intn(2) == 0 && (i == 0 || ids[i-1] < target-1) {
+			target--
+		}
+		if !it.Advance(target) || it.Doc() != ids[i] {
+			t.Fatalf("BUG - Advance(%d) - expected:%d have:%d\n", target, ids[i], it.Doc())
+		}
+		// does not move backwards
+		if !it.Advance(0) || it.Doc() != ids[i] {
+			t.Fatalf("BUG - Advance(0) moved - expected:%d have:%d\n", ids[i], it.Doc())
+		}
+		if i+1 < len(ids) && (!it.Next() || it.Doc() != ids[i+1]) {
+			t.Fatalf("BUG - Next - expected:%d have:%d\n", ids[i+1], it.Doc())
+		}
+	}
+	if it.Advance(ids[len(ids)-1] + 1) {
+		t.Errorf("expected Advance past end to fail\n")
+	}
+	if it.Next() || it.Err() != nil {
+		t.Errorf("BUG - iterator not at end - e:%v\n", it.Err())
+	}
+}
+
+func TestSkipBlocks(t *testing.T) {
+	// blocks that are skipped are not decoded: clobbering them does not
+	// affect Advance
+	ids := make([]uint32, 1000)
+	for i := range ids {
+		ids[i] = uint32(10 * i)
+	}
+	b, _ := posting.Encode(ids, 100)
+	l, _ := posting.NewList(b)
+	payload := len(b) - 1000 // deltas of 10 are one byte each
+	for i := payload; i < payload+900; i++ {
+		b[i] = 0xff
+	}
+	it := l.Iterator()
+	if !it.Advance(9005) || it.Doc() != 9010 {
+		t.Errorf("BUG - Advance(9005) - have:%d e:%v\n", it.Doc(), it.Err())
+	}
+	if _, e := l.Values(nil); e != posting.ErrorCorrupt {
+		t.Errorf("expected ErrorCorrupt - have:%v\n", e)
+	}
+}
+
+func TestIntersect(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	short := testIds(r, 50, 1<<16)
+	long := testIds(r, 30000, 1<<16)
+	mid := testIds(r, 5000, 1<<16)
+	inLong, inMid := map[uint32]bool{}, map[uint32]bool{}
+	for _, id := range long {
+		inLong[id] = true
+	}
+	for _, id := range mid {
+		inMid[id] = true
+	}
+	var expected2, expected3 []uint32
+	for _, id := range short {
+		if inLong[id] {
+			expected2 = append(expected2, id)
+			if inMid[id] {
+				expected3 = append(expected3, id)
+			}
+		}
+	}
+	ls, ll, lm := newList(t, short, 0), newList(t, long, 0), newList(t, mid, 0)
+	if ids, e := posting.Intersect(nil, ll, ls); e != nil || !equal(ids, expected2) {
+		t.Errorf("BUG - 2-way - e:%v ids:%v expected:%v\n", e, ids, expected2)
+	}
+	if ids, e := posting.Intersect(nil, lm, ll, ls); e != nil || !equal(ids, expected3) {
+		t.Errorf("BUG - 3-way - e:%v ids:%v expected:%v\n", e, ids, expected3)
+	}
+	if ids, e := posting.Intersect(nil, ll); e != nil || !equal(ids, long) {
+		t.Errorf("BUG - 1-way - e:%v\n", e)
+	}
+	if ids, _ := posting.Intersect(nil, ll, newList(t, nil, 0)); len(ids) != 0 {
+		t.Errorf("BUG - empty - ids:%v\n", ids)
+	}
+}
+
+func TestNewListErrors(t *testing.T) {
+	b, _ := posting.Encode([]uint32{1, 2, 3, 400, 500}, 2)
+	for _, p := range [][]byte{
+		{},
+		{1},
+		{1, 0, 1, 0},
+		b[:len(b)-2],
+		{2, 1, 5, 0, 3, 0, 1, 1}, // decreasing skips
+		{1, 1, 5, 1, 5},          // first block offset not 0
+	} {
+		if _, e := posting.NewList(p); e != posting.ErrorCorrupt {
+			t.Errorf("expected ErrorCorrupt - b:%x have:%v\n", p, e)
+		}
+	}
+}
+
+func benchLists() (short, long []uint32) {
+	r := rand.New(rand.NewSource(4))
+	return testIds(r, 100, 1<<22), testIds(r, 500000, 1<<22)
+}
+
+func BenchmarkIntersect(b *testing.B) {
+	short, long := benchLists()
+	bs, _ := posting.Encode(short, 0)
+	bl, _ := posting.Encode(long, 0)
+	ls, _ := posting.NewList(bs)
+	ll, _ := posting.NewList(bl)
+	dst := make([]uint32, 0, len(short))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		posting.Intersect(dst[:0], ls, ll)
+	}
+}
+
+// intersection by decoding the long list as a plain delta UNUM-32 stream
+func BenchmarkIntersectPlain(b *testing.B) {
+	short, long := benchLists()
+	plain := make([]byte, 0, 4*len(long))
+	var prev uint32
+	for _, id := range long {
+		var p [4]byte
+		n, _ := unum.EncodeUnum32(p[:], id-prev)
+		plain = append(plain, p[:n]...)
+		prev = id
+	}
+	dst := make([]uint32, 0, len(short))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		dst = dst[:0]
+		var id uint32
+		j := 0
+		for off := 0; off < len(plain) && j < len(short); {
+			d, n, _ := unum.DecodeUnum32(plain[off:])
+			off += n
+			id += d
+			for j < len(short) && short[j] < id {
+				j++
+			}
+			if j < len(short) && short[j] == id {
+				dst = append(dst, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,753 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package quic implements the frames and transport parameters of QUIC (RFC
+// 9000), built on the UNUM-64 encoding, which is the QUIC variable-length
+// integer encoding.
+//
+// Frames are parsed from and appended to the payload of packets; packet
+// protection and connection state are out of scope. A frame is its type,
+// a variable-length integer (UNUM-64) in its shortest image, followed by
+// its type-specific fields (RFC 9000, section 19):
+//
+//      type        | code      | fields
+//      ------------+-----------+--------------------------------------------
+//      PADDING     | 0x00      | (consecutive PADDING frames are coalesced)
+//      PING        | 0x01      |
+//      ACK         | 0x02-0x03 | largest, delay, range count, first range,
+//                  |           | ranges (gap, length), ECN counts (0x03)
+//      RESET_STREAM| 0x04      | stream id, error code, final size
+//      STOP_SENDING| 0x05      | stream id, error code
+//      CRYPTO      | 0x06      | offset, length, data
+//      NEW_TOKEN   | 0x07      | length, token
+//      STREAM      | 0x08-0x0f | stream id, offset (OFF), length (LEN), data;
+//                  |           | type bits 0x04 OFF, 0x02 LEN, 0x01 FIN
+//      MAX_DATA    | 0x10      | maximum data
+//      MAX_STREAM_DATA | 0x11  | stream id, maximum data
+//      MAX_STREAMS | 0x12-0x13 | maximum streams (bidi, uni)
+//      DATA_BLOCKED| 0x14      | limit
+//      STREAM_DATA_BLOCKED | 0x15 | stream id, limit
+//      STREAMS_BLOCKED | 0x16-0x17 | limit (bidi, uni)
+//      NEW_CONNECTION_ID | 0x18 | sequence, retire prior to, length (1 byte),
+//                  |           | connection id, stateless reset token (16 bytes)
+//      RETIRE_CONNECTION_ID | 0x19 | sequence
+//      PATH_CHALLENGE | 0x1a   | data (8 bytes)
+//      PATH_RESPONSE | 0x1b    | data (8 bytes)
+//      CONNECTION_CLOSE | 0x1c-0x1d | error code, frame type (0x1c), reason
+//                  |           | length, reason
+//      HANDSHAKE_DONE | 0x1e   |
+//
+// All integer fields are variable-length integers, unless noted. Byte
+// fields of parsed frames alias the parsed buffer.
+package quic
+
+import (
+	"fmt"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorFrame     = fmt.Errorf("quic.ErrorFrame")
+	ErrorFrameType = fmt.Errorf("quic.ErrorFrameType")
+)
+
+// FrameType is the type of a frame.
+type FrameType uint64
+
+const (
+	TypePadding            FrameType = 0x00
+	TypePing               FrameType = 0x01
+	TypeAck                FrameType = 0x02
+	TypeAckECN             FrameType = 0x03
+	TypeResetStream        FrameType = 0x04
+	TypeStopSending        FrameType = 0x05
+	TypeCrypto             FrameType = 0x06
+	TypeNewToken           FrameType = 0x07
+	TypeStream             FrameType = 0x08 // to 0x0f, per type bits
+	TypeMaxData            FrameType = 0x10
+	TypeMaxStreamData      FrameType = 0x11
+	TypeMaxStreamsBidi     FrameType = 0x12
+	TypeMaxStreamsUni      FrameType = 0x13
+	TypeDataBlocked        FrameType = 0x14
+	TypeStreamDataBlocked  FrameType = 0x15
+	TypeStreamsBlockedBidi FrameType = 0x16
+	TypeStreamsBlockedUni  FrameType = 0x17
+	TypeNewConnectionID    FrameType = 0x18
+	TypeRetireConnectionID FrameType = 0x19
+	TypePathChallenge      FrameType = 0x1a
+	TypePathResponse       FrameType = 0x1b
+	TypeConnectionClose    FrameType = 0x1c
+	TypeApplicationClose   FrameType = 0x1d
+	TypeHandshakeDone      FrameType = 0x1e
+)
+
+// STREAM frame type bits
+const (
+	streamFin = 0x01
+	streamLen = 0x02
+	streamOff = 0x04
+)
+
+var frameTypeNames = [...]string{
+	"PADDING", "PING", "ACK", "ACK_ECN", "RESET_STREAM", "STOP_SENDING",
+	"CRYPTO", "NEW_TOKEN", "STREAM", "STREAM", "STREAM", "STREAM", "STREAM",
+	"STREAM", "STREAM", "STREAM", "MAX_DATA", "MAX_STREAM_DATA",
+	"MAX_STREAMS", "MAX_STREAMS", "DATA_BLOCKED", "STREAM_DATA_BLOCKED",
+	"STREAMS_BLOCKED", "STREAMS_BLOCKED", "NEW_CONNECTION_ID",
+	"RETIRE_CONNECTION_ID", "PATH_CHALLENGE", "PATH_RESPONSE",
+	"CONNECTION_CLOSE", "CONNECTION_CLOSE", "HANDSHAKE_DONE",
+}
+
+func (t FrameType) String() string {
+	if t < FrameType(len(frameTypeNames)) {
+		return frameTypeNames[t]
+	}
+	return fmt.Sprintf("FrameType(%#x)", uint64(t))
+}
+
+const (
+	// maximum number of streams of MAX_STREAMS and STREAMS_BLOCKED
+	maxStreams = 1 << 60
+	// maximum length of connection ids
+	maxConnectionIDLen = 20
+)
+
+// Frame is a QUIC frame.
+type Frame interface {
+	// Returns the frame type, as encoded.
+	Type() FrameType
+	// Appends the image of the frame to b. On error, returns b and
+	// unum.ErrorMaxValue (field >= 2^62) or ErrorFrame (invalid frame).
+	Append(b []byte) ([]byte, error)
+}
+
+// PaddingFrame is Length PADDING frames.
+type PaddingFrame struct {
+	Length int
+}
+
+// PingFrame is a PING frame.
+type PingFrame struct{}
+
+// AckRange is the range [Smallest, Largest] of acknowledged packet numbers.
+type AckRange struct {
+	Smallest, Largest uint64
+}
+
+// ECNCounts are the ECN counts of an ACK frame.
+type ECNCounts struct {
+	ECT0, ECT1, CE uint64
+}
+
+// AckFrame is an ACK frame. Ranges are in descending order, and neither
+// overlap nor are adjacent. The frame is of type TypeAckECN if ECN is set.
+type AckFrame struct {
+	Delay  uint64 // in units of 2^ack_delay_exponent microseconds
+	Ranges []AckRange
+	ECN    *ECNCounts
+}
+
+// ResetStreamFrame is a RESET_STREAM frame.
+type ResetStreamFrame struct {
+	StreamID, ErrorCode, FinalSize uint64
+}
+
+// StopSendingFrame is a STOP_SENDING frame.
+type StopSendingFrame struct {
+	StreamID, ErrorCode uint64
+}
+
+// CryptoFrame is a CRYPTO frame.
+type CryptoFrame struct {
+	Offset uint64
+	Data   []byte
+}
+
+// NewTokenFrame is a NEW_TOKEN frame. The token is not empty.
+type NewTokenFrame struct {
+	Token []byte
+}
+
+// StreamFrame is a STREAM frame. The frame has an offset field if Offset
+// is not 0, and a length field unless Implicit is set, in which case the
+// data extends to the end of the packet.
+type StreamFrame struct {
+	StreamID uint64
+	Offset   uint64
+	Fin      bool
+	Implicit bool
+	Data     []byte
+}
+
+// MaxDataFrame is a MAX_DATA frame.
+type MaxDataFrame struct {
+	Max uint64
+}
+
+// MaxStreamDataFrame is a MAX_STREAM_DATA frame.
+type MaxStreamDataFrame struct {
+	StreamID, Max uint64
+}
+
+// MaxStreamsFrame is a MAX_STREAMS frame, of bidirectional streams unless
+// Uni is set.
+type MaxStreamsFrame struct {
+	Uni bool
+	Max uint64
+}
+
+// DataBlockedFrame is a DATA_BLOCKED frame.
+type DataBlockedFrame struct {
+	Limit uint64
+}
+
+// StreamDataBlockedFrame is a STREAM_DATA_BLOCKED frame.
+type StreamDataBlockedFrame struct {
+	StreamID, Limit uint64
+}
+
+// StreamsBlockedFrame is a STREAMS_BLOCKED frame, of bidirectional streams
+// unless Uni is set.
+type StreamsBlockedFrame struct {
+	Uni   bool
+	Limit uint64
+}
+
+// NewConnectionIDFrame is a NEW_CONNECTION_ID frame.
+type NewConnectionIDFrame struct {
+	Sequence      uint64
+	RetirePriorTo uint64
+	ConnectionID  []byte // 1 to 20 bytes
+	ResetToken    [16]byte
+}
+
+// RetireConnectionIDFrame is a RETIRE_CONNECTION_ID frame.
+type RetireConnectionIDFrame struct {
+	Sequence uint64
+}
+
+// PathChallengeFrame is a PATH_CHALLENGE frame.
+type PathChallengeFrame struct {
+	Data [8]byte
+}
+
+// PathResponseFrame is a PATH_RESPONSE frame.
+type PathResponseFrame struct {
+	Data [8]byte
+}
+
+// ConnectionCloseFrame is a CONNECTION_CLOSE frame, of the transport
+// (with the type of the frame that triggered the error) unless Application
+// is set.
+type ConnectionCloseFrame struct {
+	Application bool
+	ErrorCode   uint64
+	FrameType   FrameType
+	Reason      string
+}
+
+// HandshakeDoneFrame is a HANDSHAKE_DONE frame.
+type HandshakeDoneFrame struct{}
+
+// encoder appends variable-length integers and bytes, retaining the first
+// error.
+type encoder struct {
+	b []byte
+	e error
+}
+
+func (enc *encoder) varint(v uint64) {
+	if enc.e != nil {
+		return
+	}
+	enc.b, enc.e = unum.AppendUnum64(enc.b, v)
+}
+
+func (enc *encoder) bytes(p []byte) {
+	enc.b = append(enc.b, p...)
+}
+
+// Returns the appended buffer, or b on error.
+func (enc *encoder) result(b []byte) ([]byte, error) {
+	if enc.e != nil {
+		return b, enc.e
+	}
+	return enc.b, nil
+}
+
+// Returns a new encoder appending the frame type t to b.
+func newEncoder(b []byte, t FrameType) *encoder {
+	enc := &encoder{b: b}
+	enc.varint(uint64(t))
+	return enc
+}
+
+// decoder decodes variable-length integers and bytes, retaining the first
+// error. Parsers report any error as ErrorFrame (or ErrorTransportParameter).
+type decoder struct {
+	unum.BufferDecoder
+}
+
+func newDecoder(b []byte) *decoder {
+	d := &decoder{}
+	d.Reset(b)
+	return d
+}
+
+func (d *decoder) varint() uint64 {
+	return d.Unum64()
+}
+
+func (d *decoder) bytes(n uint64) []byte {
+	if n > uint64(len(d.Rest())) {
+		d.Fail(ErrorFrame)
+		return nil
+	}
+	return d.Next(int(n))
+}
+
+// Sets the error ErrorFrame if invalid.
+func (d *decoder) check(invalid bool) {
+	if invalid {
+		d.Fail(ErrorFrame)
+	}
+}
+
+func (f *PaddingFrame) Type() FrameType { return TypePadding }
+
+func (f *PaddingFrame) Append(b []byte) ([]byte, error) {
+	if f.Length < 1 {
+		return b, ErrorFrame
+	}
+	return append(b, make([]byte, f.Length)...), nil
+}
+
+func (f *PingFrame) Type() FrameType { return TypePing }
+
+func (f *PingFrame) Append(b []byte) ([]byte, error) {
+	return append(b, byte(TypePing)), nil
+}
+
+func (f *AckFrame) Type() FrameType {
+	if f.ECN != nil {
+		return TypeAckECN
+	}
+	return TypeAck
+}
+
+func (f *AckFrame) Append(b []byte) ([]byte, error) {
+	if len(f.Ranges) == 0 {
+		return b, ErrorFrame
+	}
+	r := f.Ranges[0]
+	if r.Smallest > r.Largest {
+		return b, ErrorFrame
+	}
+	enc := newEncoder(b, f.Type())
+	enc.varint(r.Largest)
+	enc.varint(f.Delay)
+	enc.varint(uint64(len(f.Ranges) - 1))
+	enc.varint(r.Largest - r.Smallest)
+	for i, r := range f.Ranges[1:] {
+		prev := f.Ranges[i]
+		if r.Smallest > r.Largest || prev.Smallest < 2 || r.Largest > prev.Smallest-2 {
+			return b, ErrorFrame
+		}
+		enc.varint(prev.Smallest - r.Largest - 2)
+		enc.varint(r.Largest - r.Smallest)
+	}
+	if f.ECN != nil {
+		enc.varint(f.ECN.ECT0)
+		enc.varint(f.ECN.ECT1)
+		enc.varint(f.ECN.CE)
+	}
+	return enc.result(b)
+}
+
+func (f *ResetStreamFrame) Type() FrameType { return TypeResetStream }
+
+func (f *ResetStreamFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeResetStream)
+	enc.varint(f.StreamID)
+	enc.varint(f.ErrorCode)
+	enc.varint(f.FinalSize)
+	return enc.result(b)
+}
+
+func (f *StopSendingFrame) Type() FrameType { return TypeStopSending }
+
+func (f *StopSendingFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeStopSending)
+	enc.varint(f.StreamID)
+	enc.varint(f.ErrorCode)
+	return enc.result(b)
+}
+
+func (f *CryptoFrame) Type() FrameType { return TypeCrypto }
+
+func (f *CryptoFrame) Append(b []byte) ([]byte, error) {
+	// the end of the data is bound as well
+	if f.Offset >= unum.Unum64ValueBound-uint64(len(f.Data)) {
+		return b, unum.ErrorMaxValue
+	}
+	enc := newEncoder(b, TypeCrypto)
+	enc.varint(f.Offset)
+	enc.varint(uint64(len(f.Data)))
+	enc.bytes(f.Data)
+	return enc.result(b)
+}
+
+func (f *NewTokenFrame) Type() FrameType { return TypeNewToken }
+
+func (f *NewTokenFrame) Append(b []byte) ([]byte, error) {
+	if len(f.Token) == 0 {
+		return b, ErrorFrame
+	}
+	enc := newEncoder(b, TypeNewToken)
+	enc.varint(uint64(len(f.Token)))
+	enc.bytes(f.Token)
+	return enc.result(b)
+}
+
+func (f *StreamFrame) Type() FrameType {
+	t := TypeStream
+	if f.Offset != 0 {
+		t |= streamOff
+	}
+	if !f.Implicit {
+		t |= streamLen
+	}
+	if f.Fin {
+		t |= streamFin
+	}
+	return t
+}
+
+func (f *StreamFrame) Append(b []byte) ([]byte, error) {
+	if f.Offset >= unum.Unum64ValueBound-uint64(len(f.Data)) {
+		return b, unum.ErrorMaxValue
+	}
+	enc := newEncoder(b, f.Type())
+	enc.varint(f.StreamID)
+	if f.Offset != 0 {
+		enc.varint(f.Offset)
+	}
+	if !f.Implicit {
+		enc.varint(uint64(len(f.Data)))
+	}
+	enc.bytes(f.Data)
+	return enc.result(b)
+}
+
+func (f *MaxDataFrame) Type() FrameType { return TypeMaxData }
+
+func (f *MaxDataFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeMaxData)
+	enc.varint(f.Max)
+	return enc.result(b)
+}
+
+func (f *MaxStreamDataFrame) Type() FrameType { return TypeMaxStreamData }
+
+func (f *MaxStreamDataFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeMaxStreamData)
+	enc.varint(f.StreamID)
+	enc.varint(f.Max)
+	return enc.result(b)
+}
+
+func (f *MaxStreamsFrame) Type() FrameType {
+	if f.Uni {
+		return TypeMaxStreamsUni
+	}
+	return TypeMaxStreamsBidi
+}
+
+func (f *MaxStreamsFrame) Append(b []byte) ([]byte, error) {
+	if f.Max > maxStreams {
+		return b, ErrorFrame
+	}
+	enc := newEncoder(b, f.Type())
+	enc.varint(f.Max)
+	return enc.result(b)
+}
+
+func (f *DataBlockedFrame) Type() FrameType { return TypeDataBlocked }
+
+func (f *DataBlockedFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeDataBlocked)
+	enc.varint(f.Limit)
+	return enc.result(b)
+}
+
+func (f *StreamDataBlockedFrame) Type() FrameType { return TypeStreamDataBlocked }
+
+func (f *StreamDataBlockedFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeStreamDataBlocked)
+	enc.varint(f.StreamID)
+	enc.varint(f.Limit)
+	return enc.result(b)
+}
+
+func (f *StreamsBlockedFrame) Type() FrameType {
+	if f.Uni {
+		return TypeStreamsBlockedUni
+	}
+	return TypeStreamsBlockedBidi
+}
+
+func (f *StreamsBlockedFrame) Append(b []byte) ([]byte, error) {
+	if f.Limit > maxStreams {
+		return b, ErrorFrame
+	}
+	enc := newEncoder(b, f.Type())
+	enc.varint(f.Limit)
+	return enc.result(b)
+}
+
+func (f *NewConnectionIDFrame) Type() FrameType { return TypeNewConnectionID }
+
+func (f *NewConnectionIDFrame) Append(b []byte) ([]byte, error) {
+	if f.RetirePriorTo > f.Sequence || len(f.ConnectionID) < 1 || len(f.ConnectionID) > maxConnectionIDLen {
+		return b, ErrorFrame
+	}
+	enc := newEncoder(b, TypeNewConnectionID)
+	enc.varint(f.Sequence)
+	enc.varint(f.RetirePriorTo)
+	enc.bytes([]byte{byte(len(f.ConnectionID))})
+	enc.bytes(f.ConnectionID)
+	enc.bytes(f.ResetToken[:])
+	return enc.result(b)
+}
+
+func (f *RetireConnectionIDFrame) Type() FrameType { return TypeRetireConnectionID }
+
+func (f *RetireConnectionIDFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, TypeRetireConnectionID)
+	enc.varint(f.Sequence)
+	return enc.result(b)
+}
+
+func (f *PathChallengeFrame) Type() FrameType { return TypePathChallenge }
+
+func (f *PathChallengeFrame) Append(b []byte) ([]byte, error) {
+	return append(append(b, byte(TypePathChallenge)), f.Data[:]...), nil
+}
+
+func (f *PathResponseFrame) Type() FrameType { return TypePathResponse }
+
+func (f *PathResponseFrame) Append(b []byte) ([]byte, error) {
+	return append(append(b, byte(TypePathResponse)), f.Data[:]...), nil
+}
+
+func (f *ConnectionCloseFrame) Type() FrameType {
+	if f.Application {
+		return TypeApplicationClose
+	}
+	return TypeConnectionClose
+}
+
+func (f *ConnectionCloseFrame) Append(b []byte) ([]byte, error) {
+	enc := newEncoder(b, f.Type())
+	enc.varint(f.ErrorCode)
+	if !f.Application {
+		enc.varint(uint64(f.FrameType))
+	}
+	enc.varint(uint64(len(f.Reason)))
+	enc.b = append(enc.b, f.Reason...)
+	return enc.result(b)
+}
+
+func (f *HandshakeDoneFrame) Type() FrameType { return TypeHandshakeDone }
+
+func (f *HandshakeDoneFrame) Append(b []byte) ([]byte, error) {
+	return append(b, byte(TypeHandshakeDone)), nil
+}
+
+// Parses the frame at the start of b, and returns it and its length.
+// Consecutive PADDING frames are parsed as a single PaddingFrame.
+//
+// On error returns (nil, 0, e) where e is:
+//    ErrorFrame      -- invalid arg b : malformed (or truncated) frame
+//    ErrorFrameType  -- invalid arg b : unknown frame type
+func ParseFrame(b []byte) (f Frame, n int, e error) {
+	d := newDecoder(b)
+	v := d.varint()
+	t := FrameType(v)
+	// frame types are in their shortest image
+	d.check(d.Err() == nil && len(b)-len(d.Rest()) != unum.SizeUnum64(v))
+	if d.Err() != nil {
+		return nil, 0, ErrorFrame
+	}
+	switch {
+	case t == TypePadding:
+		n := 1
+		for n < len(b) && b[n] == 0 {
+			n++
+		}
+		d.Next(n - 1)
+		f = &PaddingFrame{Length: n}
+	case t == TypePing:
+		f = &PingFrame{}
+	case t == TypeAck || t == TypeAckECN:
+		f = parseAck(d, t)
+	case t == TypeResetStream:
+		f = &ResetStreamFrame{StreamID: d.varint(), ErrorCode: d.varint(), FinalSize: d.varint()}
+	case t == TypeStopSending:
+		f = &StopSendingFrame{StreamID: d.varint(), ErrorCode: d.varint()}
+	case t == TypeCrypto:
+		c := &CryptoFrame{Offset: d.varint()}
+		c.Data = d.bytes(d.varint())
+		d.check(c.Offset >= unum.Unum64ValueBound-uint64(len(c.Data)))
+		f = c
+	case t == TypeNewToken:
+		token := d.bytes(d.varint())
+		d.check(len(token) == 0)
+		f = &NewTokenFrame{Token: token}
+	case t >= TypeStream && t <= TypeStream|streamOff|streamLen|streamFin:
+		s := &StreamFrame{StreamID: d.varint(), Fin: t&streamFin != 0}
+		if t&streamOff != 0 {
+			s.Offset = d.varint()
+		}
+		if t&streamLen != 0 {
+			s.Data = d.bytes(d.varint())
+		} else {
+			s.Implicit = true
+			s.Data = d.bytes(uint64(len(d.Rest())))
+		}
+		d.check(s.Offset >= unum.Unum64ValueBound-uint64(len(s.Data)))
+		f = s
+	case t == TypeMaxData:
+		f = &MaxDataFrame{Max: d.varint()}
+	case t == TypeMaxStreamData:
+		f = &MaxStreamDataFrame{StreamID: d.varint(), Max: d.varint()}
+	case t == TypeMaxStreamsBidi || t == TypeMaxStreamsUni:
+		m := &MaxStreamsFrame{Uni: t == TypeMaxStreamsUni, Max: d.varint()}
+		d.check(m.Max > maxStreams)
+		f = m
+	case t == TypeDataBlocked:
+		f = &DataBlockedFrame{Limit: d.varint()}
+	case t == TypeStreamDataBlocked:
+		f = &StreamDataBlockedFrame{StreamID: d.varint(), Limit: d.varint()}
+	case t == TypeStreamsBlockedBidi || t == TypeStreamsBlockedUni:
+		s := &StreamsBlockedFrame{Uni: t == TypeStreamsBlockedUni, Limit: d.varint()}
+		d.check(s.Limit > maxStreams)
+		f = s
+	case t == TypeNewConnectionID:
+		c := &NewConnectionIDFrame{Sequence: d.varint(), RetirePriorTo: d.varint()}
+		if l := d.bytes(1); l != nil {
+			c.ConnectionID = d.bytes(uint64(l[0]))
+		}
+		copy(c.ResetToken[:], d.bytes(16))
+		d.check(c.RetirePriorTo > c.Sequence || len(c.ConnectionID) < 1 || len(c.ConnectionID) > maxConnectionIDLen)
+		f = c
+	case t == TypeRetireConnectionID:
+		f = &RetireConnectionIDFrame{Sequence: d.varint()}
+	case t == TypePathChallenge:
+		p := &PathChallengeFrame{}
+		copy(p.Data[:], d.bytes(8))
+		f = p
+	case t == TypePathResponse:
+		p := &PathResponseFrame{}
+		copy(p.Data[:], d.bytes(8))
+		f = p
+	case t == TypeConnectionClose || t == TypeApplicationClose:
+		c := &ConnectionCloseFrame{Application: t == TypeApplicationClose, ErrorCode: d.varint()}
+		if !c.Application {
+			c.FrameType = FrameType(d.varint())
+		}
+		c.Reason = string(d.bytes(d.varint()))
+		f = c
+	case t == TypeHandshakeDone:
+		f = &HandshakeDoneFrame{}
+	default:
+		return nil, 0, ErrorFrameType
+	}
+	if d.Err() != nil {
+		return nil, 0, ErrorFrame
+	}
+	return f, len(b) - len(d.Rest()), nil
+}
+
+// Parses the ACK frame of type t of d.
+func parseAck(d *decoder, t FrameType) *AckFrame {
+	largest := d.varint()
+	f := &AckFrame{Delay: d.varint()}
+	count := d.varint()
+	first := d.varint()
+	// ranges are at least 2 bytes
+	d.check(first > largest || count > uint64(len(d.Rest()))/2)
+	if d.Err() != nil {
+		return nil
+	}
+	f.Ranges = make([]AckRange, 1, count+1)
+	f.Ranges[0] = AckRange{Smallest: largest - first, Largest: largest}
+	for i := uint64(0); i < count && d.Err() == nil; i++ {
+		smallest := f.Ranges[len(f.Ranges)-1].Smallest
+		gap, length := d.varint(), d.varint()
+		d.check(gap+2 > smallest)
+		if d.Err() != nil {
+			break
+		}
+		r := AckRange{Largest: smallest - gap - 2}
+		d.check(length > r.Largest)
+		r.Smallest = r.Largest - length
+		f.Ranges = append(f.Ranges, r)
+	}
+	if t == TypeAckECN {
+		f.ECN = &ECNCounts{ECT0: d.varint(), ECT1: d.varint(), CE: d.varint()}
+	}
+	return f
+}
+
+// Parses the frames of payload b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorFrame      -- invalid arg b : malformed (or truncated) frame
+//    ErrorFrameType  -- invalid arg b : unknown frame type
+func ParseFrames(b []byte) ([]Frame, error) {
+	var frames []Frame
+	for len(b) > 0 {
+		f, n, e := ParseFrame(b)
+		if e != nil {
+			return nil, e
+		}
+		frames = append(frames, f)
+		b = b[n:]
+	}
+	return frames, nil
+}
+
+// Appends the images of frames to b. On error, returns b and the error of
+// the frame's Append.
+func AppendFrames(b []byte, frames ...Frame) ([]byte, error) {
+	p := b
+	for _, f := range frames {
+		var e error
+		if p, e = f.Append(p); e != nil {
+			return b, e
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,175 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package quic_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unum"
+	"unum/quic"
+)
+
+var testFrames = []quic.Frame{
+	&quic.PingFrame{},
+	&quic.AckFrame{Delay: 25, Ranges: []quic.AckRange{{Smallest: 90, Largest: 100}}},
+	&quic.AckFrame{Delay: 1 << 20, Ranges: []quic.AckRange{{100, 1000}, {50, 97}, {0, 0}},
+		ECN: &quic.ECNCounts{ECT0: 1, ECT1: 2, CE: 1 << 40}},
+	&quic.ResetStreamFrame{StreamID: 4, ErrorCode: 0x101, FinalSize: 1 << 33},
+	&quic.StopSendingFrame{StreamID: 8, ErrorCode: 7},
+	&quic.CryptoFrame{Offset: 1200, Data: []byte("client hello")},
+	&quic.NewTokenFrame{Token: []byte{1, 2, 3}},
+	&quic.StreamFrame{StreamID: 0, Data: []byte("GET /")},
+	&quic.StreamFrame{StreamID: 1 << 40, Offset: 1 << 20, Fin: true, Data: []byte{}},
+	&quic.MaxDataFrame{Max: 1 << 30},
+	&quic.MaxStreamDataFrame{StreamID: 3, Max: 65536},
+	&quic.MaxStreamsFrame{Max: 100},
+	&quic.MaxStreamsFrame{Uni: true, Max: 1 << 60},
+	&quic.DataBlockedFrame{Limit: 1 << 30},
+	&quic.StreamDataBlockedFrame{StreamID: 3, Limit: 65536},
+	&quic.StreamsBlockedFrame{Limit: 100},
+	&quic.StreamsBlockedFrame{Uni: true, Limit: 3},
+	&quic.NewConnectionIDFrame{Sequence: 2, RetirePriorTo: 1, ConnectionID: []byte{0xc0, 0xff, 0xee, 0x01},
+		ResetToken: [16]byte{15: 0xff}},
+	&quic.RetireConnectionIDFrame{Sequence: 1},
+	&quic.PathChallengeFrame{Data: [8]byte{1, 2, 3, 4, 5, 6, 7, 8}},
+	&quic.PathResponseFrame{Data: [8]byte{8, 7, 6, 5, 4, 3, 2, 1}},
+	&quic.ConnectionCloseFrame{ErrorCode: 0x07, FrameType: quic.TypeStream, Reason: "frame encoding error"},
+	&quic.ConnectionCloseFrame{Application: true, ErrorCode: 1 << 20},
+	&quic.HandshakeDoneFrame{},
+	&quic.StreamFrame{StreamID: 5, Offset: 100, Implicit: true, Data: []byte("to the end of the packet")},
+	&quic.PaddingFrame{Length: 10},
+}
+
+func TestFrames(t *testing.T) {
+	b, e := quic.AppendFrames(nil, testFrames...)
+	if e != nil {
+		t.Fatalf("error appending - e:%s\n", e.Error())
+	}
+	frames, e := quic.ParseFrames(b)
+	if e != nil {
+		t.Fatalf("error parsing - e:%s\n", e.Error())
+	}
+	// the implicit length STREAM frame runs to the end of the payload
+	last := testFrames[len(testFrames)-2].(*quic.StreamFrame)
+	if len(frames) != len(testFrames)-1 {
+		t.Fatalf("BUG - frames:%d expected:%d\n", len(frames), len(testFrames)-1)
+	}
+	for i, f := range frames[:len(frames)-1] {
+		if !reflect.DeepEqual(f, testFrames[i]) {
+			t.Errorf("BUG - frame:%d expected:%+v have:%+v\n", i, testFrames[i], f)
+		}
+		if f.Type() != testFrames[i].Type() {
+			t.Errorf("BUG - frame:%d type:%s expected:%s\n", i, f.Type(), testFrames[i].Type())
+		}
+	}
+	s := frames[len(frames)-1].(*quic.StreamFrame)
+	if !bytes.Equal(s.Data, append(append([]byte(nil), last.Data...), make([]byte, 10)...)) {
+		t.Errorf("BUG - implicit stream data:%q\n", s.Data)
+	}
+}
+
+// frames of hand built images
+func TestParseFrame(t *testing.T) {
+	for _, tc := range []struct {
+		b []byte
+		f quic.Frame
+	}{
+		// ACK largest 0x7bbd (2 byte image), delay 37, 1 range: [15290, 15293], gap 1 length 3
+		{[]byte{0x02, 0x7b, 0xbd, 0x25, 0x01, 0x03, 0x01, 0x03},
+			&quic.AckFrame{Delay: 37, Ranges: []quic.AckRange{{15290, 15293}, {15284, 15287}}}},
+		// non-minimal delay image 0x4025
+		{[]byte{0x02, 0x05, 0x40, 0x25, 0x00, 0x00},
+			&quic.AckFrame{Delay: 37, Ranges: []quic.AckRange{{5, 5}}}},
+		// STREAM with OFF, LEN and FIN bits
+		{[]byte{0x0f, 0x04, 0x9d, 0x7f, 0x3e, 0x7d, 0x02, 'h', 'i'},
+			&quic.StreamFrame{StreamID: 4, Offset: 494878333, Fin: true, Data: []byte("hi")}},
+		{[]byte{0x00, 0x00, 0x00}, &quic.PaddingFrame{Length: 3}},
+	} {
+		f, n, e := quic.ParseFrame(tc.b)
+		if e != nil || n != len(tc.b) || !reflect.DeepEqual(f, tc.f) {
+			t.Errorf("BUG - b:%x expected:%+v have:%+v n:%d e:%v\n", tc.b, tc.f, f, n, e)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	// every proper prefix of the frames is truncated
+	for _, f := range testFrames[:len(testFrames)-2] {
+		b, _ := f.Append(nil)
+		for i := 0; i < len(b); i++ {
+			if _, _, e := quic.ParseFrame(b[:i]); e != quic.ErrorFrame {
+				t.Errorf("expected ErrorFrame - type:%s len:%d have:%v\n", f.Type(), i, e)
+			}
+		}
+	}
+	for _, b := range [][]byte{
+		{0x40, 0x01},                               // non-minimal type
+		{0x02, 0x05, 0x00, 0x00, 0x06},             // first range > largest
+		{0x02, 0x05, 0x00, 0x01, 0x00, 0x04, 0x00}, // gap below 0
+		{0x07, 0x00},                               // empty token
+		{0x12, 0xd0, 0, 0, 0, 0, 0, 0, 1},          // streams > 2^60
+		{0x18, 0x01, 0x02, 0x01, 0xff},             // retire prior to > sequence
+	} {
+		if _, _, e := quic.ParseFrame(b); e != quic.ErrorFrame {
+			t.Errorf("expected ErrorFrame - b:%x have:%v\n", b, e)
+		}
+	}
+	if _, _, e := quic.ParseFrame([]byte{0x1f}); e != quic.ErrorFrameType {
+		t.Errorf("expected ErrorFrameType - have:%v\n", e)
+	}
+}
+
+func TestAppendErrors(t *testing.T) {
+	for _, f := range []quic.Frame{
+		&quic.PaddingFrame{},
+		&quic.AckFrame{},
+		&quic.AckFrame{Ranges: []quic.AckRange{{10, 20}, {5, 9}}}, // adjacent
+		&quic.AckFrame{Ranges: []quic.AckRange{{10, 20}, {30, 40}}},
+		&quic.NewTokenFrame{},
+		&quic.MaxStreamsFrame{Max: 1<<60 + 1},
+		&quic.NewConnectionIDFrame{Sequence: 1},
+		&quic.NewConnectionIDFrame{Sequence: 1, RetirePriorTo: 2, ConnectionID: []byte{1}},
+	} {
+		if b, e := f.Append([]byte{0xff}); e != quic.ErrorFrame || len(b) != 1 {
+			t.Errorf("expected ErrorFrame - frame:%+v have:%v\n", f, e)
+		}
+	}
+	for _, f := range []quic.Frame{
+		&quic.MaxDataFrame{Max: unum.Unum64ValueBound},
+		&quic.StreamFrame{Offset: unum.Unum64ValueBound - 1, Data: []byte{1}},
+	} {
+		if _, e := f.Append(nil); e != unum.ErrorMaxValue {
+			t.Errorf("expected ErrorMaxValue - frame:%+v have:%v\n", f, e)
+		}
+	}
+}
+
+func BenchmarkParseFrames(b *testing.B) {
+	p, _ := quic.AppendFrames(nil, testFrames...)
+	b.SetBytes(int64(len(p)))
+	for i := 0; i < b.N; i++ {
+		quic.ParseFrames(p)
+	}
+}
@@ -0,0 +1,249 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package quic
+
+import (
+	"fmt"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorTransportParameter = fmt.Errorf("quic.ErrorTransportParameter")
+)
+
+// transport parameter ids (RFC 9000, section 18.2)
+const (
+	paramOriginalDestinationConnectionID = 0x00
+	paramMaxIdleTimeout                  = 0x01
+	paramStatelessResetToken             = 0x02
+	paramMaxUDPPayloadSize               = 0x03
+	paramInitialMaxData                  = 0x04
+	paramInitialMaxStreamDataBidiLocal   = 0x05
+	paramInitialMaxStreamDataBidiRemote  = 0x06
+	paramInitialMaxStreamDataUni         = 0x07
+	paramInitialMaxStreamsBidi           = 0x08
+	paramInitialMaxStreamsUni            = 0x09
+	paramAckDelayExponent                = 0x0a
+	paramMaxAckDelay                     = 0x0b
+	paramDisableActiveMigration          = 0x0c
+	paramPreferredAddress                = 0x0d
+	paramActiveConnectionIDLimit         = 0x0e
+	paramInitialSourceConnectionID       = 0x0f
+	paramRetrySourceConnectionID         = 0x10
+)
+
+// TransportParameter is a transport parameter unknown to this package,
+// such as those of extensions and greasing.
+type TransportParameter struct {
+	ID    uint64
+	Value []byte
+}
+
+// TransportParameters are the transport parameters of an endpoint (RFC
+// 9000, section 18). An image is a sequence of parameters, each its
+// id and length (variable-length integers) and value.
+//
+// Parameters equal to their default (see DefaultTransportParameters) are
+// omitted from images, and absent parameters are parsed as their default.
+// Connection ids are absent if nil, and may be present and empty.
+type TransportParameters struct {
+	OriginalDestinationConnectionID []byte
+	MaxIdleTimeout                  uint64 // milliseconds, 0 for none
+	StatelessResetToken             []byte // 16 bytes
+	MaxUDPPayloadSize               uint64 // >= 1200
+	InitialMaxData                  uint64
+	InitialMaxStreamDataBidiLocal   uint64
+	InitialMaxStreamDataBidiRemote  uint64
+	InitialMaxStreamDataUni         uint64
+	InitialMaxStreamsBidi           uint64 // <= 2^60
+	InitialMaxStreamsUni            uint64 // <= 2^60
+	AckDelayExponent                uint64 // <= 20
+	MaxAckDelay                     uint64 // milliseconds, < 2^14
+	DisableActiveMigration          bool
+	PreferredAddress                []byte // value image, not interpreted
+	ActiveConnectionIDLimit         uint64 // >= 2
+	InitialSourceConnectionID       []byte
+	RetrySourceConnectionID         []byte
+	Unknown                         []TransportParameter
+}
+
+// Returns the transport parameters of defaults, those of an empty image.
+func DefaultTransportParameters() TransportParameters {
+	return TransportParameters{
+		MaxUDPPayloadSize:       65527,
+		AckDelayExponent:        3,
+		MaxAckDelay:             25,
+		ActiveConnectionIDLimit: 2,
+	}
+}
+
+// Returns ErrorTransportParameter if a parameter is out of range.
+func (p *TransportParameters) check() error {
+	if (p.StatelessResetToken != nil && len(p.StatelessResetToken) != 16) ||
+		p.MaxUDPPayloadSize < 1200 ||
+		p.InitialMaxStreamsBidi > maxStreams || p.InitialMaxStreamsUni > maxStreams ||
+		p.AckDelayExponent > 20 || p.MaxAckDelay >= 1<<14 ||
+		p.ActiveConnectionIDLimit < 2 ||
+		len(p.OriginalDestinationConnectionID) > maxConnectionIDLen ||
+		len(p.InitialSourceConnectionID) > maxConnectionIDLen ||
+		len(p.RetrySourceConnectionID) > maxConnectionIDLen {
+		return ErrorTransportParameter
+	}
+	return nil
+}
+
+// Appends the image of the parameters to b.
+//
+// On error returns (b, e) where e is:
+//    ErrorTransportParameter  -- parameter out of range
+//    unum.ErrorMaxValue       -- parameter (or unknown parameter id) >= 2^62
+func (p *TransportParameters) Append(b []byte) ([]byte, error) {
+	if e := p.check(); e != nil {
+		return b, e
+	}
+	def := DefaultTransportParameters()
+	enc := &encoder{b: b}
+	bytes := func(id uint64, v []byte) {
+		if v != nil {
+			enc.varint(id)
+			enc.varint(uint64(len(v)))
+			enc.bytes(v)
+		}
+	}
+	varint := func(id uint64, v, def uint64) {
+		if v != def {
+			enc.varint(id)
+			enc.varint(uint64(unum.SizeUnum64(v)))
+			enc.varint(v)
+		}
+	}
+	bytes(paramOriginalDestinationConnectionID, p.OriginalDestinationConnectionID)
+	varint(paramMaxIdleTimeout, p.MaxIdleTimeout, def.MaxIdleTimeout)
+	bytes(paramStatelessResetToken, p.StatelessResetToken)
+	varint(paramMaxUDPPayloadSize, p.MaxUDPPayloadSize, def.MaxUDPPayloadSize)
+	varint(paramInitialMaxData, p.InitialMaxData, def.InitialMaxData)
+	varint(paramInitialMaxStreamDataBidiLocal, p.InitialMaxStreamDataBidiLocal, def.InitialMaxStreamDataBidiLocal)
+	varint(paramInitialMaxStreamDataBidiRemote, p.InitialMaxStreamDataBidiRemote, def.InitialMaxStreamDataBidiRemote)
+	varint(paramInitialMaxStreamDataUni, p.InitialMaxStreamDataUni, def.InitialMaxStreamDataUni)
+	varint(paramInitialMaxStreamsBidi, p.InitialMaxStreamsBidi, def.InitialMaxStreamsBidi)
+	varint(paramInitialMaxStreamsUni, p.InitialMaxStreamsUni, def.InitialMaxStreamsUni)
+	varint(paramAckDelayExponent, p.AckDelayExponent, def.AckDelayExponent)
+	varint(paramMaxAckDelay, p.MaxAckDelay, def.MaxAckDelay)
+	if p.DisableActiveMigration {
+		bytes(paramDisableActiveMigration, []byte{})
+	}
+	bytes(paramPreferredAddress, p.PreferredAddress)
+	varint(paramActiveConnectionIDLimit, p.ActiveConnectionIDLimit, def.ActiveConnectionIDLimit)
+	bytes(paramInitialSourceConnectionID, p.InitialSourceConnectionID)
+	bytes(paramRetrySourceConnectionID, p.RetrySourceConnectionID)
+	for _, u := range p.Unknown {
+		if u.ID <= paramRetrySourceConnectionID {
+			return b, ErrorTransportParameter
+		}
+		enc.varint(u.ID)
+		enc.varint(uint64(len(u.Value)))
+		enc.bytes(u.Value)
+	}
+	return enc.result(b)
+}
+
+// Parses the image of transport parameters b. Byte parameters alias b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorTransportParameter  -- invalid arg b : malformed image, duplicate
+//                                or out of range parameter
+func ParseTransportParameters(b []byte) (*TransportParameters, error) {
+	p := DefaultTransportParameters()
+	seen := make(map[uint64]bool)
+	d := newDecoder(b)
+	for len(d.Rest()) > 0 {
+		id := d.varint()
+		value := d.bytes(d.varint())
+		if d.Err() != nil || seen[id] {
+			return nil, ErrorTransportParameter
+		}
+		seen[id] = true
+		if id > paramRetrySourceConnectionID {
+			p.Unknown = append(p.Unknown, TransportParameter{ID: id, Value: value})
+			continue
+		}
+		// the value of integer parameters
+		var v uint64
+		switch id {
+		case paramOriginalDestinationConnectionID, paramStatelessResetToken,
+			paramPreferredAddress, paramInitialSourceConnectionID, paramRetrySourceConnectionID:
+		case paramDisableActiveMigration:
+			if len(value) != 0 {
+				return nil, ErrorTransportParameter
+			}
+		default:
+			x, n, e := unum.DecodeUnum64(value)
+			if e != nil || n != len(value) {
+				return nil, ErrorTransportParameter
+			}
+			v = x
+		}
+		switch id {
+		case paramOriginalDestinationConnectionID:
+			p.OriginalDestinationConnectionID = value
+		case paramMaxIdleTimeout:
+			p.MaxIdleTimeout = v
+		case paramStatelessResetToken:
+			p.StatelessResetToken = value
+		case paramMaxUDPPayloadSize:
+			p.MaxUDPPayloadSize = v
+		case paramInitialMaxData:
+			p.InitialMaxData = v
+		case paramInitialMaxStreamDataBidiLocal:
+			p.InitialMaxStreamDataBidiLocal = v
+		case paramInitialMaxStreamDataBidiRemote:
+			p.InitialMaxStreamDataBidiRemote = v
+		case paramInitialMaxStreamDataUni:
+			p.InitialMaxStreamDataUni = v
+		case paramInitialMaxStreamsBidi:
+			p.InitialMaxStreamsBidi = v
+		case paramInitialMaxStreamsUni:
+			p.InitialMaxStreamsUni = v
+		case paramAckDelayExponent:
+			p.AckDelayExponent = v
+		case paramMaxAckDelay:
+			p.MaxAckDelay = v
+		case paramDisableActiveMigration:
+			p.DisableActiveMigration = true
+		case paramPreferredAddress:
+			p.PreferredAddress = value
+		case paramActiveConnectionIDLimit:
+			p.ActiveConnectionIDLimit = v
+		case paramInitialSourceConnectionID:
+			p.InitialSourceConnectionID = value
+		case paramRetrySourceConnectionID:
+			p.RetrySourceConnectionID = value
+		}
+	}
+	if e := p.check(); e != nil {
+		return nil, e
+	}
+	return &p, nil
+}
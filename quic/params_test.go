@@ -0,0 +1,94 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package quic_test
+
+import (
+	"reflect"
+	"testing"
+	"unum/quic"
+)
+
+func TestTransportParameters(t *testing.T) {
+	p := quic.DefaultTransportParameters()
+	p.OriginalDestinationConnectionID = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	p.MaxIdleTimeout = 30000
+	p.StatelessResetToken = make([]byte, 16)
+	p.MaxUDPPayloadSize = 1472
+	p.InitialMaxData = 1 << 20
+	p.InitialMaxStreamDataBidiLocal = 1 << 18
+	p.InitialMaxStreamDataUni = 1 << 16
+	p.InitialMaxStreamsBidi = 100
+	p.AckDelayExponent = 0
+	p.DisableActiveMigration = true
+	p.InitialSourceConnectionID = []byte{}
+	p.Unknown = []quic.TransportParameter{{ID: 0x1a2a, Value: []byte{}}, {ID: 31*7 + 27, Value: []byte("grease")}}
+	b, e := p.Append(nil)
+	if e != nil {
+		t.Fatalf("error appending - e:%s\n", e.Error())
+	}
+	p0, e := quic.ParseTransportParameters(b)
+	if e != nil {
+		t.Fatalf("error parsing - e:%s\n", e.Error())
+	}
+	if !reflect.DeepEqual(*p0, p) {
+		t.Errorf("BUG - expected:%+v\nhave:%+v\n", p, *p0)
+	}
+
+	// defaults
+	def := quic.DefaultTransportParameters()
+	if b, e := def.Append(nil); e != nil || len(b) != 0 {
+		t.Errorf("BUG - default image:%x e:%v\n", b, e)
+	}
+	if p, e := quic.ParseTransportParameters(nil); e != nil || !reflect.DeepEqual(*p, def) {
+		t.Errorf("BUG - default parameters:%+v e:%v\n", p, e)
+	}
+}
+
+func TestTransportParameterErrors(t *testing.T) {
+	for _, b := range [][]byte{
+		{0x01, 0x01},                         // truncated
+		{0x01, 0x01, 0x05, 0x01, 0x01, 0x05}, // duplicate
+		{0x01, 0x02, 0x05, 0x00},             // trailing bytes of integer
+		{0x03, 0x02, 0x43, 0xe8},             // max udp payload size 1000
+		{0x0a, 0x01, 0x15},                   // ack delay exponent 21
+		{0x0b, 0x04, 0x80, 0x00, 0x40, 0x00}, // max ack delay 2^14
+		{0x0c, 0x01, 0x00},                   // disable active migration with value
+		{0x0e, 0x01, 0x01},                   // active connection id limit 1
+		{0x02, 0x01, 0x00},                   // reset token length
+	} {
+		if _, e := quic.ParseTransportParameters(b); e != quic.ErrorTransportParameter {
+			t.Errorf("expected ErrorTransportParameter - b:%x have:%v\n", b, e)
+		}
+	}
+	p := quic.DefaultTransportParameters()
+	p.MaxAckDelay = 1 << 14
+	if _, e := p.Append(nil); e != quic.ErrorTransportParameter {
+		t.Errorf("expected ErrorTransportParameter - have:%v\n", e)
+	}
+	p = quic.DefaultTransportParameters()
+	p.Unknown = []quic.TransportParameter{{ID: 0x04}}
+	if _, e := p.Append(nil); e != quic.ErrorTransportParameter {
+		t.Errorf("expected ErrorTransportParameter - have:%v\n", e)
+	}
+}
@@ -0,0 +1,396 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package rle implements run-length encoding of unsigned integer sequences.
+//
+// The (plain) run encoding is a sequence of (value, run length) pairs:
+//
+//      value       UNUM-64     value of run, < 2^62
+//      run         UNUM-32     run length, in [1, 2^30)
+//
+// Values that do not repeat still cost a run length byte each. The hybrid
+// encoding is a sequence of run and literal sections, each chosen by which
+// is smaller for the values at hand:
+//
+//      header      UNUM-32     n<<1 | 1 for literal sections, n<<1 for runs,
+//                              with n in [1, 2^29)
+//      run:        UNUM-64     value repeated n times
+//      literal:    [n]UNUM-64  n values
+//
+// A sequence must be decoded with the encoding used to encode it.
+package rle
+
+import (
+	"bytes"
+	"io"
+	"unum"
+)
+
+const (
+	maxRun        = 1<<30 - 1 // plain run length bound
+	maxSection    = 1<<29 - 1 // hybrid section length bound
+	maxLiterals   = 4096      // buffered literals of hybrid encoder
+	literalFlag   = 1
+	sectionLenBit = 1
+)
+
+// output of an encoding: appended to b (Encoder), written to the fixed
+// buffer b (Encode), or only sized (Size).
+type output struct {
+	b     []byte
+	n     int  // image size
+	fixed bool // b does not grow
+	sized bool // nothing is written
+	e     error
+}
+
+func (o *output) unum32(v uint32) {
+	switch {
+	case o.sized:
+		o.n += unum.SizeUnum32(v)
+	case !o.fixed:
+		o.b, _ = unum.AppendUnum32(o.b, v)
+		o.n = len(o.b)
+	case o.e == nil:
+		n, e := unum.EncodeUnum32(o.b[o.n:], v)
+		o.n, o.e = o.n+n, e
+	}
+}
+
+func (o *output) unum64(v uint64) {
+	switch {
+	case o.sized:
+		o.n += unum.SizeUnum64(v)
+	case !o.fixed:
+		o.b, _ = unum.AppendUnum64(o.b, v)
+		o.n = len(o.b)
+	case o.e == nil:
+		n, e := unum.EncodeUnum64(o.b[o.n:], v)
+		o.n, o.e = o.n+n, e
+	}
+}
+
+// state of an encoding in progress.
+type state struct {
+	hybrid bool
+	v      uint64
+	run    int
+	lits   []uint64
+}
+
+// outputs the images of value v (if any).
+func (s *state) add(o *output, v uint64) {
+	if s.run > 0 && v != s.v {
+		s.endRun(o)
+	}
+	s.v = v
+	s.run++
+	if (s.hybrid && s.run == maxSection) || s.run == maxRun {
+		s.endRun(o)
+	}
+}
+
+// outputs the images of all pending values.
+func (s *state) flush(o *output) {
+	if s.run > 0 {
+		s.endRun(o)
+	}
+	s.endLiterals(o)
+}
+
+// outputs the image of the current run, or (for hybrid encodings) adds it
+// to the pending literals if that is smaller.
+func (s *state) endRun(o *output) {
+	run := s.run
+	s.run = 0
+	if !s.hybrid {
+		o.unum64(s.v)
+		o.unum32(uint32(run))
+		return
+	}
+	sz := unum.SizeUnum64(s.v)
+	if unum.SizeUnum32(uint32(run<<sectionLenBit))+sz < run*sz {
+		s.endLiterals(o)
+		o.unum32(uint32(run << sectionLenBit))
+		o.unum64(s.v)
+		return
+	}
+	for i := 0; i < run; i++ {
+		s.lits = append(s.lits, s.v)
+		if len(s.lits) == maxLiterals {
+			s.endLiterals(o)
+		}
+	}
+}
+
+// outputs the literal section of pending literals (if any).
+func (s *state) endLiterals(o *output) {
+	if len(s.lits) == 0 {
+		return
+	}
+	o.unum32(uint32(len(s.lits)<<sectionLenBit | literalFlag))
+	for _, v := range s.lits {
+		o.unum64(v)
+	}
+	s.lits = s.lits[:0]
+}
+
+func (s *state) reset() {
+	s.v, s.run, s.lits = 0, 0, s.lits[:0]
+}
+
+// Encoder writes a run-length encoded sequence to an io.Writer.
+//
+// Values are buffered until their run ends; Flush must be called after the
+// last value of a sequence.
+type Encoder struct {
+	w   io.Writer
+	s   state
+	buf []byte
+}
+
+// Returns a new Encoder of the plain run encoding, writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Returns a new Encoder of the hybrid run/literal encoding, writing to w.
+func NewHybridEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w, s: state{hybrid: true}}
+}
+
+// Encodes value v, the next value of the sequence.
+// Returns number of bytes written 'n' (possibly 0) if there are no errors.
+//
+// On error returns (n, e) where e is:
+//    unum.ErrorMaxValue   -- invalid arg v : v >= 2^62
+//    <other>              -- propagated io.Writer.Write error
+func (enc *Encoder) Encode(v uint64) (n int, e error) {
+	if v >= unum.Unum64ValueBound {
+		return 0, unum.ErrorMaxValue
+	}
+	o := output{b: enc.buf[:0]}
+	enc.s.add(&o, v)
+	enc.buf = o.b
+	return enc.write()
+}
+
+// Writes all pending values, ending the sequence.
+// Returns number of bytes written 'n' if there are no errors.
+//
+// On error returns (n, e) where e is:
+//    <other>              -- propagated io.Writer.Write error
+func (enc *Encoder) Flush() (n int, e error) {
+	o := output{b: enc.buf[:0]}
+	enc.s.flush(&o)
+	enc.buf = o.b
+	return enc.write()
+}
+
+func (enc *Encoder) write() (n int, e error) {
+	if len(enc.buf) == 0 {
+		return 0, nil
+	}
+	return enc.w.Write(enc.buf)
+}
+
+// Resets the encoder to begin a new sequence, discarding pending values.
+func (enc *Encoder) Reset() {
+	enc.s.reset()
+}
+
+// Decoder reads a run-length encoded sequence from an io.Reader.
+type Decoder struct {
+	r      io.Reader
+	hybrid bool
+	lit    bool
+	rem    int
+	v      uint64
+}
+
+// Returns a new Decoder of the plain run encoding, reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Returns a new Decoder of the hybrid run/literal encoding, reading from r.
+func NewHybridDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r, hybrid: true}
+}
+
+// Reads the next value of the sequence.
+// Returns value v, number of bytes read n (possibly 0), if there are no
+// errors.
+//
+// On error returns (0, n, e) where e is:
+//    unum.ErrorBufferEOF       -- end of sequence
+//    unum.ErrorInvalidBuffer   -- non-conformant byte sequence
+//    <other>                   -- propagated io.Reader.Read error
+func (dec *Decoder) Decode() (v uint64, n int, e error) {
+	if dec.rem == 0 {
+		if n, e = dec.section(); e != nil {
+			return 0, n, e
+		}
+	}
+	if dec.lit {
+		v, n0, e := unum.ReadUint(dec.r)
+		n += n0
+		if e != nil {
+			return 0, n, eof(e)
+		}
+		dec.v = v
+	}
+	dec.rem--
+	return dec.v, n, nil
+}
+
+// reads the next run or section header.
+func (dec *Decoder) section() (n int, e error) {
+	if !dec.hybrid {
+		v, n, e := unum.ReadUint(dec.r)
+		if e != nil {
+			return n, e
+		}
+		run, n0, e := unum.ReadUnum32(dec.r)
+		n += n0
+		if e != nil {
+			return n, eof(e)
+		}
+		if run == 0 {
+			return n, unum.ErrorInvalidBuffer
+		}
+		dec.v, dec.rem, dec.lit = v, int(run), false
+		return n, nil
+	}
+	h, n, e := unum.ReadUnum32(dec.r)
+	if e != nil {
+		return n, e
+	}
+	if h>>sectionLenBit == 0 {
+		return n, unum.ErrorInvalidBuffer
+	}
+	dec.rem, dec.lit = int(h>>sectionLenBit), h&literalFlag != 0
+	if !dec.lit {
+		v, n0, e := unum.ReadUint(dec.r)
+		n += n0
+		if e != nil {
+			return n, eof(e)
+		}
+		dec.v = v
+	}
+	return n, nil
+}
+
+// maps end of input within a run or section to ErrorInvalidBuffer.
+func eof(e error) error {
+	if e == unum.ErrorBufferEOF {
+		return unum.ErrorInvalidBuffer
+	}
+	return e
+}
+
+// Resets the decoder to begin a new sequence.
+func (dec *Decoder) Reset() {
+	dec.lit, dec.rem, dec.v = false, 0, 0
+}
+
+func encode(b []byte, vs []uint64, hybrid bool) (n int, e error) {
+	o := output{b: b, fixed: true}
+	if e := encodeTo(&o, vs, hybrid); e != nil {
+		return 0, e
+	}
+	return o.n, nil
+}
+
+func size(vs []uint64, hybrid bool) int {
+	o := output{sized: true}
+	if e := encodeTo(&o, vs, hybrid); e != nil {
+		return 0
+	}
+	return o.n
+}
+
+// outputs the image of vs.
+func encodeTo(o *output, vs []uint64, hybrid bool) error {
+	s := state{hybrid: hybrid}
+	for _, v := range vs {
+		if v >= unum.Unum64ValueBound {
+			return unum.ErrorMaxValue
+		}
+		s.add(o, v)
+	}
+	s.flush(o)
+	return o.e
+}
+
+func decode(dst []uint64, dec *Decoder) ([]uint64, error) {
+	for {
+		v, _, e := dec.Decode()
+		if e == unum.ErrorBufferEOF {
+			return dst, nil
+		}
+		if e != nil {
+			return dst, e
+		}
+		dst = append(dst, v)
+	}
+}
+
+// Run-length encodes the sequence vs in buffer b.
+// returns number of bytes written on nil error.
+//
+// On error returns (0, e) where e is:
+//    unum.ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    unum.ErrorMaxValue        -- invalid arg vs : value >= 2^62
+func Encode(b []byte, vs []uint64) (n int, e error) {
+	return encode(b, vs, false)
+}
+
+// Hybrid run/literal encodes the sequence vs in buffer b. See Encode.
+func EncodeHybrid(b []byte, vs []uint64) (n int, e error) {
+	return encode(b, vs, true)
+}
+
+// Returns the run-length encoded size of vs, or 0 if vs is not encodable.
+func Size(vs []uint64) int {
+	return size(vs, false)
+}
+
+// Returns the hybrid encoded size of vs, or 0 if vs is not encodable.
+func SizeHybrid(vs []uint64) int {
+	return size(vs, true)
+}
+
+// Decodes the run-length encoded sequence b, appending its values to dst.
+//
+// On error returns (dst, e) where e is:
+//    unum.ErrorInvalidBuffer   -- invalid arg b : non-conformant byte sequence
+func Decode(dst []uint64, b []byte) ([]uint64, error) {
+	return decode(dst, NewDecoder(bytes.NewReader(b)))
+}
+
+// Decodes the hybrid encoded sequence b, appending its values to dst.
+// See Decode.
+func DecodeHybrid(dst []uint64, b []byte) ([]uint64, error) {
+	return decode(dst, NewHybridDecoder(bytes.NewReader(b)))
+}
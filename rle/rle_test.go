@@ -0,0 +1,242 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package rle_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"testing/quick"
+	"unum"
+	"unum/rle"
+)
+
+// status-code like column: long runs of few values with occasional noise
+func testColumn(n int) []uint64 {
+	r := rand.New(rand.NewSource(int64(n)))
+	vs := make([]uint64, 0, n)
+	codes := []uint64{200, 200, 200, 404, 500, 1 << 40}
+	for len(vs) < n {
+		v := codes[r.Intn(len(codes))]
+		run := 1 + r.Intn(50)
+		if r.Intn(4) == 0 {
+			run = 1
+			v = uint64(r.Int63n(1 << 20))
+		}
+		for i := 0; i < run && len(vs) < n; i++ {
+			vs = append(vs, v)
+		}
+	}
+	return vs
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestEncodeDecode(t *testing.T) {
+	for _, hybrid := range []bool{false, true} {
+		encode, decode, size := rle.Encode, rle.Decode, rle.Size
+		if hybrid {
+			encode, decode, size = rle.EncodeHybrid, rle.DecodeHybrid, rle.SizeHybrid
+		}
+		fn := func(vs []uint64) bool {
+			for i := range vs {
+				vs[i] = (vs[i] >> 2) % uint64(1+i%3*1000)
+			}
+			b := make([]byte, 12*len(vs))
+			n, e := encode(b, vs)
+			if e != nil {
+				t.Errorf("error encoding - e:%s\n", e.Error())
+				return false
+			}
+			if n != size(vs) {
+				t.Errorf("BUG - n:%d size:%d\n", n, size(vs))
+				return false
+			}
+			vs0, e := decode(nil, b[:n])
+			if e != nil {
+				t.Errorf("error decoding - e:%s\n", e.Error())
+				return false
+			}
+			return equal(vs, vs0)
+		}
+		if e := quick.Check(fn, nil); e != nil {
+			t.Errorf("hybrid:%t - %s", hybrid, e)
+		}
+		vs := testColumn(10000)
+		b := make([]byte, size(vs))
+		if _, e := encode(b, vs); e != nil {
+			t.Fatalf("error encoding - e:%s\n", e.Error())
+		}
+		vs0, e := decode(nil, b)
+		if e != nil || !equal(vs, vs0) {
+			t.Errorf("BUG - hybrid:%t e:%v\n", hybrid, e)
+		}
+		if _, e := encode(b[:len(b)-1], vs); e != unum.ErrorBufferOverflow {
+			t.Errorf("expected ErrorBufferOverflow - have:%v\n", e)
+		}
+		if _, e := encode(b, []uint64{unum.Unum64ValueBound}); e != unum.ErrorMaxValue {
+			t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+		}
+	}
+}
+
+func TestSize(t *testing.T) {
+	// a single run of 1000 values
+	vs := make([]uint64, 1000)
+	if n := rle.Size(vs); n != 3 {
+		t.Errorf("expected size 3 - have:%d\n", n)
+	}
+	if n := rle.SizeHybrid(vs); n != 3 {
+		t.Errorf("expected size 3 - have:%d\n", n)
+	}
+	// no runs at all: hybrid pays one header, plain one byte per value
+	for i := range vs {
+		vs[i] = uint64(i % 50)
+	}
+	if n := rle.Size(vs); n != 2000 {
+		t.Errorf("expected size 2000 - have:%d\n", n)
+	}
+	if n := rle.SizeHybrid(vs); n != 1002 {
+		t.Errorf("expected size 1002 - have:%d\n", n)
+	}
+	// both beat unum encoding of a repetitive column
+	vs = testColumn(10000)
+	var u int
+	for _, v := range vs {
+		u += unum.SizeUnum64(v)
+	}
+	if h, p := rle.SizeHybrid(vs), rle.Size(vs); h*4 > u || p*4 > u {
+		t.Errorf("column not compact - hybrid:%d plain:%d unum:%d\n", h, p, u)
+	}
+}
+
+func TestStream(t *testing.T) {
+	vs := testColumn(10000)
+	for _, hybrid := range []bool{false, true} {
+		var buf bytes.Buffer
+		enc := rle.NewEncoder(&buf)
+		if hybrid {
+			enc = rle.NewHybridEncoder(&buf)
+		}
+		var wn int
+		for _, v := range vs {
+			n, e := enc.Encode(v)
+			if e != nil {
+				t.Fatalf("error encoding - e:%s\n", e.Error())
+			}
+			wn += n
+		}
+		n, e := enc.Flush()
+		if e != nil {
+			t.Fatalf("error flushing - e:%s\n", e.Error())
+		}
+		wn += n
+		if wn != buf.Len() {
+			t.Errorf("BUG - written:%d len:%d\n", wn, buf.Len())
+		}
+		b := make([]byte, buf.Len())
+		encode := rle.Encode
+		if hybrid {
+			encode = rle.EncodeHybrid
+		}
+		encode(b, vs)
+		if !bytes.Equal(b, buf.Bytes()) {
+			t.Errorf("stream and slice images differ - hybrid:%t\n", hybrid)
+		}
+
+		dec := rle.NewDecoder(&buf)
+		if hybrid {
+			dec = rle.NewHybridDecoder(&buf)
+		}
+		var rn int
+		for i, v := range vs {
+			v0, n, e := dec.Decode()
+			if e != nil {
+				t.Fatalf("error decoding - i:%d e:%s\n", i, e.Error())
+			}
+			if v0 != v {
+				t.Fatalf("BUG - i:%d v:%d v0:%d\n", i, v, v0)
+			}
+			rn += n
+		}
+		if rn != len(b) {
+			t.Errorf("BUG - read:%d len:%d\n", rn, len(b))
+		}
+		if _, _, e := dec.Decode(); e != unum.ErrorBufferEOF {
+			t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, b := range [][]byte{
+		{7, 0},    // zero run
+		{7},       // missing run
+		{7, 0x40}, // truncated run
+	} {
+		if _, e := rle.Decode(nil, b); e != unum.ErrorInvalidBuffer {
+			t.Errorf("expected ErrorInvalidBuffer - b:%x have:%v\n", b, e)
+		}
+	}
+	for _, b := range [][]byte{
+		{0},       // empty section
+		{4},       // missing run value
+		{5, 1},    // truncated literals
+		{5, 0x40}, // truncated literal value
+	} {
+		if _, e := rle.DecodeHybrid(nil, b); e != unum.ErrorInvalidBuffer {
+			t.Errorf("expected ErrorInvalidBuffer - b:%x have:%v\n", b, e)
+		}
+	}
+}
+
+func BenchmarkEncodeHybrid(b *testing.B) {
+	vs := testColumn(4096)
+	buf := make([]byte, rle.SizeHybrid(vs))
+	for i := 0; i < b.N; i++ {
+		rle.EncodeHybrid(buf, vs)
+	}
+	b.ReportMetric(float64(len(buf))/float64(len(vs)), "bytes/value")
+}
+
+func BenchmarkDecodeHybrid(b *testing.B) {
+	vs := testColumn(4096)
+	buf := make([]byte, rle.SizeHybrid(vs))
+	rle.EncodeHybrid(buf, vs)
+	dst := make([]uint64, 0, len(vs))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		rle.DecodeHybrid(dst[:0], buf)
+	}
+}
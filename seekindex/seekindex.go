@@ -0,0 +1,423 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package seekindex implements a sidecar index for random access to the
+// values of large UNUM-32 encoded streams.
+//
+// An Index is built by walking the stream once, reading only the tag of
+// each value, and records the byte offset of every k-th value (the
+// checkpoint interval). A SeekReader combines an io.ReaderAt for the stream
+// with its Index to position at any value index, walking at most k-1 tags.
+//
+// The Index records the length and the CRC32C of the stream, and the
+// CRC32C of its first and last QuickLen bytes. NewSeekReader checks the
+// length and the quick checksum; Verify checks the full checksum.
+//
+// sidecar format:
+//
+//      magic        4 bytes     "UIDX"
+//      version      1 byte      1
+//      interval     UNUM-32     values per checkpoint
+//      count        UNUM-64     number of values in the stream
+//      length       UNUM-64     stream length in bytes
+//      checksum     4 bytes     CRC32C of the stream
+//      quick        4 bytes     CRC32C of the first and last QuickLen bytes
+//      checkpoints  UNUM-64     number of checkpoints n, followed by n UNUM-64
+//                               offset deltas (the first offset is 0)
+//      trailer      4 bytes     CRC32C of the above
+//
+// Fixed width fields are big-endian.
+package seekindex
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"unum"
+)
+
+const (
+	Version = 1
+
+	// default number of values per checkpoint
+	DefaultInterval = 1024
+
+	// length of stream head and tail covered by the quick checksum
+	QuickLen = 4096
+)
+
+var magic = [4]byte{'U', 'I', 'D', 'X'}
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// Errors
+var (
+	ErrorStale        = fmt.Errorf("seekindex.ErrorStale")
+	ErrorInvalidIndex = fmt.Errorf("seekindex.ErrorInvalidIndex")
+	ErrorIndexRange   = fmt.Errorf("seekindex.ErrorIndexRange")
+)
+
+// Index maps value indexes of a UNUM-32 stream to byte offsets.
+type Index struct {
+	Interval int    // values per checkpoint
+	Count    uint64 // values in the stream
+	Length   int64  // stream length
+	Checksum uint32 // CRC32C of the stream
+	Quick    uint32 // CRC32C of the stream head and tail
+
+	offsets []int64 // offset of value i*Interval
+}
+
+// tail retains the last QuickLen bytes written.
+type tail struct {
+	buf []byte
+}
+
+func (t *tail) Write(p []byte) (int, error) {
+	n := len(p)
+	if len(p) >= QuickLen {
+		p = p[len(p)-QuickLen:]
+	}
+	if drop := len(t.buf) + len(p) - QuickLen; drop > 0 {
+		t.buf = append(t.buf[:0], t.buf[drop:]...)
+	}
+	t.buf = append(t.buf, p...)
+	return n, nil
+}
+
+// head retains the first QuickLen bytes written.
+type head struct {
+	buf []byte
+}
+
+func (h *head) Write(p []byte) (int, error) {
+	if room := QuickLen - len(h.buf); room > 0 {
+		if len(p) > room {
+			h.buf = append(h.buf, p[:room]...)
+		} else {
+			h.buf = append(h.buf, p...)
+		}
+	}
+	return len(p), nil
+}
+
+func quick(head, tail []byte) uint32 {
+	return crc32.Update(crc32.Checksum(head, castagnoli), castagnoli, tail)
+}
+
+// Builds the Index of the UNUM-32 stream r, with a checkpoint every
+// interval values. If interval <= 0, DefaultInterval is used.
+//
+// On error returns (nil, e) where e is:
+//    unum.ErrorInvalidBuffer  -- stream ends within a value
+//    <other>                  -- propagated io.Reader error
+func Build(r io.Reader, interval int) (*Index, error) {
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+	sum := crc32.New(castagnoli)
+	var h head
+	var t tail
+	br := bufio.NewReader(io.TeeReader(r, io.MultiWriter(sum, &h, &t)))
+
+	ix := &Index{Interval: interval}
+	var off int64
+	for {
+		tag, e := br.ReadByte()
+		if e == io.EOF {
+			break
+		} else if e != nil {
+			return nil, e
+		}
+		if ix.Count%uint64(interval) == 0 {
+			ix.offsets = append(ix.offsets, off)
+		}
+		vlen := int(tag>>6) + 1
+		if _, e := br.Discard(vlen - 1); e != nil {
+			if e == io.EOF {
+				return nil, unum.ErrorInvalidBuffer
+			}
+			return nil, e
+		}
+		off += int64(vlen)
+		ix.Count++
+	}
+	ix.Length = off
+	ix.Checksum = sum.Sum32()
+	ix.Quick = quick(h.buf, t.buf)
+	return ix, nil
+}
+
+// Writes the sidecar image of the Index to w.
+func (ix *Index) WriteTo(w io.Writer) (int64, error) {
+	var b bytes.Buffer
+	b.Write(magic[:])
+	b.WriteByte(Version)
+	if _, e := unum.WriteUnum32(&b, uint32(ix.Interval)); e != nil {
+		return 0, e
+	}
+	unum.WriteUnum64(&b, ix.Count)
+	unum.WriteUnum64(&b, uint64(ix.Length))
+	var u32 [4]byte
+	binary.BigEndian.PutUint32(u32[:], ix.Checksum)
+	b.Write(u32[:])
+	binary.BigEndian.PutUint32(u32[:], ix.Quick)
+	b.Write(u32[:])
+	unum.WriteUnum64(&b, uint64(len(ix.offsets)))
+	var prev int64
+	for _, off := range ix.offsets {
+		unum.WriteUnum64(&b, uint64(off-prev))
+		prev = off
+	}
+	binary.BigEndian.PutUint32(u32[:], crc32.Checksum(b.Bytes(), castagnoli))
+	b.Write(u32[:])
+
+	n, e := w.Write(b.Bytes())
+	return int64(n), e
+}
+
+// Reads the sidecar image of an Index from r.
+//
+// On error returns (nil, e) where e is:
+//    ErrorInvalidIndex  -- r is not a conformant index image
+//    <other>            -- propagated io.Reader error
+func ReadIndex(r io.Reader) (*Index, error) {
+	sum := crc32.New(castagnoli)
+	br := &reader{r: bufio.NewReader(r), sum: sum}
+
+	var fixed [5]byte
+	br.read(fixed[:])
+	if br.err == nil && (!bytes.Equal(fixed[:4], magic[:]) || fixed[4] != Version) {
+		return nil, ErrorInvalidIndex
+	}
+	ix := &Index{}
+	ix.Interval = int(br.unum32())
+	ix.Count = br.unum64()
+	ix.Length = int64(br.unum64())
+	ix.Checksum = br.uint32()
+	ix.Quick = br.uint32()
+	n := br.unum64()
+	if br.err == nil && (ix.Interval == 0 || n != (ix.Count+uint64(ix.Interval)-1)/uint64(ix.Interval)) {
+		return nil, ErrorInvalidIndex
+	}
+	var off int64
+	for i := uint64(0); i < n && br.err == nil; i++ {
+		off += int64(br.unum64())
+		if off > ix.Length || (i > 0 && off <= ix.offsets[i-1]) {
+			return nil, ErrorInvalidIndex
+		}
+		ix.offsets = append(ix.offsets, off)
+	}
+	expected := sum.Sum32()
+	if trailer := br.uint32(); br.err != nil {
+		if br.err == io.EOF || br.err == io.ErrUnexpectedEOF || br.err == unum.ErrorInvalidBuffer {
+			return nil, ErrorInvalidIndex
+		}
+		return nil, br.err
+	} else if trailer != expected {
+		return nil, ErrorInvalidIndex
+	}
+	if len(ix.offsets) > 0 && ix.offsets[0] != 0 {
+		return nil, ErrorInvalidIndex
+	}
+	return ix, nil
+}
+
+// reader reads index fields, accumulating the checksum and first error.
+type reader struct {
+	r   *bufio.Reader
+	sum hash.Hash32
+	err error
+}
+
+func (r *reader) read(b []byte) {
+	if r.err != nil {
+		return
+	}
+	if _, r.err = io.ReadFull(r.r, b); r.err == nil {
+		r.sum.Write(b)
+	}
+}
+
+func (r *reader) unum64() uint64 {
+	var b [unum.Unum64Size]byte
+	r.read(b[:1])
+	if r.err != nil {
+		return 0
+	}
+	r.read(b[1 : 1<<(b[0]>>6)])
+	v, _, _ := unum.DecodeUnum64(b[:])
+	return v
+}
+
+func (r *reader) unum32() uint32 {
+	var b [unum.Unum32Size]byte
+	r.read(b[:1])
+	if r.err != nil {
+		return 0
+	}
+	r.read(b[1 : int(b[0]>>6)+1])
+	v, _, _ := unum.DecodeUnum32(b[:])
+	return v
+}
+
+func (r *reader) uint32() uint32 {
+	var b [4]byte
+	r.read(b[:])
+	return binary.BigEndian.Uint32(b[:])
+}
+
+// Verifies that the Index is current for the stream ra of length size,
+// by comparing the length and the full checksum of the stream.
+//
+// On error returns e:
+//    ErrorStale  -- Index does not match the stream
+//    <other>     -- propagated io.ReaderAt error
+func (ix *Index) Verify(ra io.ReaderAt, size int64) error {
+	if size != ix.Length {
+		return ErrorStale
+	}
+	sum := crc32.New(castagnoli)
+	if _, e := io.Copy(sum, io.NewSectionReader(ra, 0, size)); e != nil {
+		return e
+	}
+	if sum.Sum32() != ix.Checksum {
+		return ErrorStale
+	}
+	return nil
+}
+
+// compares the length and quick checksum of the stream
+func (ix *Index) check(ra io.ReaderAt, size int64) error {
+	if size != ix.Length {
+		return ErrorStale
+	}
+	hlen := int64(QuickLen)
+	if hlen > size {
+		hlen = size
+	}
+	tlen := hlen
+	h := make([]byte, hlen)
+	t := make([]byte, tlen)
+	if _, e := ra.ReadAt(h, 0); e != nil && e != io.EOF {
+		return e
+	}
+	if _, e := ra.ReadAt(t, size-tlen); e != nil && e != io.EOF {
+		return e
+	}
+	if quick(h, t) != ix.Quick {
+		return ErrorStale
+	}
+	return nil
+}
+
+// ----------------------------------------------------------------------------
+// SeekReader
+// ----------------------------------------------------------------------------
+
+// SeekReader reads the values of a UNUM-32 stream from any value index.
+type SeekReader struct {
+	ra  io.ReaderAt
+	ix  *Index
+	br  *bufio.Reader
+	pos uint64 // index of next value
+}
+
+// Returns a new SeekReader for stream ra of length size, positioned at
+// value index 0.
+//
+// On error returns (nil, e) where e is:
+//    ErrorStale  -- Index length or quick checksum does not match the stream
+//    <other>     -- propagated io.ReaderAt error
+func NewSeekReader(ra io.ReaderAt, size int64, ix *Index) (*SeekReader, error) {
+	if e := ix.check(ra, size); e != nil {
+		return nil, e
+	}
+	s := &SeekReader{ra: ra, ix: ix, br: bufio.NewReader(io.NewSectionReader(ra, 0, size))}
+	return s, nil
+}
+
+// Positions the reader at value index i. Seeking to Count positions the
+// reader at the end of the stream.
+//
+// On error returns e:
+//    ErrorIndexRange          -- invalid arg i : i > Count
+//    unum.ErrorInvalidBuffer  -- stream does not conform to the Index
+//    <other>                  -- propagated io.ReaderAt error
+func (s *SeekReader) Seek(i uint64) error {
+	if i > s.ix.Count {
+		return ErrorIndexRange
+	}
+	ix := s.ix
+	var off int64
+	var skip uint64
+	if i == ix.Count {
+		off = ix.Length
+	} else {
+		off = ix.offsets[i/uint64(ix.Interval)]
+		skip = i % uint64(ix.Interval)
+	}
+	s.br.Reset(io.NewSectionReader(s.ra, off, ix.Length-off))
+	for ; skip > 0; skip-- {
+		tag, e := s.br.ReadByte()
+		if e != nil {
+			return s.eof(e)
+		}
+		if _, e := s.br.Discard(int(tag >> 6)); e != nil {
+			return s.eof(e)
+		}
+	}
+	s.pos = i
+	return nil
+}
+
+func (s *SeekReader) eof(e error) error {
+	if e == io.EOF {
+		return unum.ErrorInvalidBuffer
+	}
+	return e
+}
+
+// Returns the index of the next value.
+func (s *SeekReader) Pos() uint64 {
+	return s.pos
+}
+
+// Reads the next value.
+//
+// On error returns (0, e) where e is:
+//    unum.ErrorBufferEOF      -- end of stream
+//    unum.ErrorInvalidBuffer  -- non-conformant stream
+//    <other>                  -- propagated io.ReaderAt error
+func (s *SeekReader) Next() (uint32, error) {
+	v, _, e := unum.ReadUnum32(s.br)
+	if e != nil {
+		return 0, e
+	}
+	s.pos++
+	return v, nil
+}
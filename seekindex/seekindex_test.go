@@ -0,0 +1,154 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package seekindex_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"unum"
+	"unum/seekindex"
+)
+
+func testStream(n int) ([]uint32, []byte) {
+	r := rand.New(rand.NewSource(int64(n)))
+	vs := make([]uint32, n)
+	var buf bytes.Buffer
+	for i := range vs {
+		vs[i] = uint32(r.Int31n(int32(unum.Unum32ValueBound))) >> uint(r.Intn(30))
+		unum.WriteUnum32(&buf, vs[i])
+	}
+	return vs, buf.Bytes()
+}
+
+func TestSeek(t *testing.T) {
+	for _, n := range []int{0, 1, 100, 10000} {
+		vs, stream := testStream(n)
+		for _, interval := range []int{0, 1, 7, 64} {
+			ix, e := seekindex.Build(bytes.NewReader(stream), interval)
+			if e != nil {
+				t.Fatalf("error building index - e:%s\n", e.Error())
+			}
+			if ix.Count != uint64(n) || ix.Length != int64(len(stream)) {
+				t.Errorf("unexpected index - count:%d length:%d\n", ix.Count, ix.Length)
+			}
+			s, e := seekindex.NewSeekReader(bytes.NewReader(stream), int64(len(stream)), ix)
+			if e != nil {
+				t.Fatalf("error creating reader - e:%s\n", e.Error())
+			}
+			for j := 0; j < 50 && n > 0; j++ {
+				i := uint64(rand.Intn(n))
+				if e := s.Seek(i); e != nil {
+					t.Fatalf("error seeking - i:%d e:%s\n", i, e.Error())
+				}
+				for k := i; k < i+3 && k < uint64(n); k++ {
+					v, e := s.Next()
+					if e != nil || v != vs[k] {
+						t.Fatalf("value %d - expected:%d have:%d e:%v\n", k, vs[k], v, e)
+					}
+				}
+			}
+			if e := s.Seek(uint64(n)); e != nil {
+				t.Fatalf("error seeking to end - e:%s\n", e.Error())
+			}
+			if _, e := s.Next(); e != unum.ErrorBufferEOF {
+				t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+			}
+			if e := s.Seek(uint64(n) + 1); e != seekindex.ErrorIndexRange {
+				t.Errorf("expected ErrorIndexRange - have:%v\n", e)
+			}
+		}
+	}
+}
+
+func TestSidecar(t *testing.T) {
+	_, stream := testStream(5000)
+	ix, _ := seekindex.Build(bytes.NewReader(stream), 100)
+	var buf bytes.Buffer
+	if _, e := ix.WriteTo(&buf); e != nil {
+		t.Fatalf("error writing index - e:%s\n", e.Error())
+	}
+	image := buf.Bytes()
+	if len(image) > 2*len(stream)/100+64 {
+		t.Errorf("index not compact - stream:%d index:%d\n", len(stream), len(image))
+	}
+
+	ix0, e := seekindex.ReadIndex(bytes.NewReader(image))
+	if e != nil {
+		t.Fatalf("error reading index - e:%s\n", e.Error())
+	}
+	var buf0 bytes.Buffer
+	ix0.WriteTo(&buf0)
+	if !bytes.Equal(buf0.Bytes(), image) {
+		t.Errorf("BUG - index image differs after round trip\n")
+	}
+
+	for i := 0; i < len(image); i++ {
+		if _, e := seekindex.ReadIndex(bytes.NewReader(image[:i])); e != seekindex.ErrorInvalidIndex {
+			t.Fatalf("expected ErrorInvalidIndex - len:%d have:%v\n", i, e)
+		}
+		c := append([]byte(nil), image...)
+		c[i] ^= 0x10
+		if _, e := seekindex.ReadIndex(bytes.NewReader(c)); e != seekindex.ErrorInvalidIndex {
+			t.Fatalf("expected ErrorInvalidIndex - corrupt offset:%d have:%v\n", i, e)
+		}
+	}
+}
+
+func TestStale(t *testing.T) {
+	_, stream := testStream(5000)
+	ix, _ := seekindex.Build(bytes.NewReader(stream), 0)
+	if e := ix.Verify(bytes.NewReader(stream), int64(len(stream))); e != nil {
+		t.Errorf("unexpected error verifying - e:%s\n", e.Error())
+	}
+
+	// appended stream
+	longer := append(append([]byte(nil), stream...), 0x01)
+	if _, e := seekindex.NewSeekReader(bytes.NewReader(longer), int64(len(longer)), ix); e != seekindex.ErrorStale {
+		t.Errorf("expected ErrorStale - have:%v\n", e)
+	}
+
+	// rewritten head; detected by the quick check
+	c := append([]byte(nil), stream...)
+	c[0] ^= 0x01
+	if _, e := seekindex.NewSeekReader(bytes.NewReader(c), int64(len(c)), ix); e != seekindex.ErrorStale {
+		t.Errorf("expected ErrorStale - have:%v\n", e)
+	}
+
+	// rewritten middle; detected by Verify only
+	c = append([]byte(nil), stream...)
+	c[len(c)/2] ^= 0x01
+	if _, e := seekindex.NewSeekReader(bytes.NewReader(c), int64(len(c)), ix); e != nil {
+		t.Errorf("unexpected error - e:%v\n", e)
+	}
+	if e := ix.Verify(bytes.NewReader(c), int64(len(c))); e != seekindex.ErrorStale {
+		t.Errorf("expected ErrorStale - have:%v\n", e)
+	}
+}
+
+func TestBuildTruncated(t *testing.T) {
+	if _, e := seekindex.Build(bytes.NewReader([]byte{0x01, 0xc0, 0x00}), 0); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+}
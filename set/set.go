@@ -0,0 +1,546 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package set implements a compressed set of unsigned integers.
+//
+// Values are kept sorted in blocks of at most BlockSize values. A block
+// records its minimum and maximum values and the UNUM-64 images of the
+// differences of its successive values, so dense sets of large ids cost
+// one or two bytes per value. Lookups binary search the block headers and
+// scan (at most) one block.
+package set
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorOrder   = fmt.Errorf("set.ErrorOrder")
+	ErrorCorrupt = fmt.Errorf("set.ErrorCorrupt")
+)
+
+// maximum number of values per block.
+const BlockSize = 256
+
+// block of sorted values.
+type block struct {
+	min  uint64
+	max  uint64
+	n    int
+	data []byte // UNUM-64 images of the n-1 differences of the values
+}
+
+// returns the block of strictly increasing values vs (len(vs) > 0).
+func newBlock(vs []uint64) block {
+	size := 0
+	for i := 1; i < len(vs); i++ {
+		size += unum.SizeUnum64(vs[i] - vs[i-1])
+	}
+	b := block{min: vs[0], max: vs[len(vs)-1], n: len(vs), data: make([]byte, size)}
+	off := 0
+	for i := 1; i < len(vs); i++ {
+		n, _ := unum.EncodeUnum64(b.data[off:], vs[i]-vs[i-1])
+		off += n
+	}
+	return b
+}
+
+// appends the values of the block to dst.
+func (b *block) values(dst []uint64) []uint64 {
+	v := b.min
+	dst = append(dst, v)
+	for off := 0; off < len(b.data); {
+		d, n, _ := unum.DecodeUnum64(b.data[off:])
+		v += d
+		dst = append(dst, v)
+		off += n
+	}
+	return dst
+}
+
+// returns the number of values of the block less than v, and whether v is
+// in the block.
+func (b *block) rank(v uint64) (int, bool) {
+	if v <= b.min {
+		return 0, v == b.min
+	}
+	if v > b.max {
+		return b.n, false
+	}
+	x, i := b.min, 1
+	for off := 0; off < len(b.data); i++ {
+		d, n, _ := unum.DecodeUnum64(b.data[off:])
+		x += d
+		if x >= v {
+			return i, x == v
+		}
+		off += n
+	}
+	return b.n, false
+}
+
+// returns the i-th value of the block.
+func (b *block) get(i int) uint64 {
+	v := b.min
+	for off := 0; i > 0; i-- {
+		d, n, _ := unum.DecodeUnum64(b.data[off:])
+		v += d
+		off += n
+	}
+	return v
+}
+
+// Set is a compressed set of unsigned integers, less than 2^62.
+//
+// The zero value is an empty set. A Set is not safe for concurrent use
+// if any goroutine modifies it; otherwise all its methods, Rank and
+// Select included, may be called concurrently.
+type Set struct {
+	blocks []block
+	n      int
+	ranks  *rankTable // nil for the zero value
+}
+
+// rankTable is the number of values preceding each block, built once by
+// the first Rank or Select after a modification.
+type rankTable struct {
+	once  sync.Once
+	ranks []int
+}
+
+// discards the rank table of the blocks.
+func (s *Set) invalidate() {
+	s.ranks = &rankTable{}
+}
+
+// Returns a new empty Set.
+func New() *Set {
+	return &Set{}
+}
+
+// Returns a new Set of the strictly increasing values vs.
+//
+// On error returns (nil, e) where e is:
+//    unum.ErrorMaxValue  -- invalid arg vs : value >= 2^62
+//    ErrorOrder          -- invalid arg vs : not strictly increasing
+func FromSorted(vs []uint64) (*Set, error) {
+	var bld builder
+	for i, v := range vs {
+		if v >= unum.Unum64ValueBound {
+			return nil, unum.ErrorMaxValue
+		}
+		if i > 0 && v <= vs[i-1] {
+			return nil, ErrorOrder
+		}
+		bld.add(v)
+	}
+	return bld.set(), nil
+}
+
+// builder builds a Set of increasing values in full blocks.
+type builder struct {
+	s       Set
+	pending []uint64
+}
+
+func (bld *builder) add(v uint64) {
+	bld.pending = append(bld.pending, v)
+	if len(bld.pending) == BlockSize {
+		bld.flush()
+	}
+}
+
+func (bld *builder) flush() {
+	if len(bld.pending) > 0 {
+		bld.s.blocks = append(bld.s.blocks, newBlock(bld.pending))
+		bld.s.n += len(bld.pending)
+		bld.pending = bld.pending[:0]
+	}
+}
+
+func (bld *builder) set() *Set {
+	bld.flush()
+	s := bld.s
+	s.invalidate()
+	return &s
+}
+
+// Returns the number of values in the set.
+func (s *Set) Len() int {
+	return s.n
+}
+
+// returns the index of the block that holds (or would hold) v.
+func (s *Set) find(v uint64) int {
+	i := sort.Search(len(s.blocks), func(i int) bool { return s.blocks[i].max >= v })
+	if i == len(s.blocks) && i > 0 {
+		i--
+	}
+	return i
+}
+
+// Returns true if v is in the set.
+func (s *Set) Contains(v uint64) bool {
+	if len(s.blocks) == 0 {
+		return false
+	}
+	b := &s.blocks[s.find(v)]
+	_, ok := b.rank(v)
+	return ok
+}
+
+// Adds v to the set. Returns true if v was not in the set.
+//
+// On error returns (false, e) where e is:
+//    unum.ErrorMaxValue  -- invalid arg v : v >= 2^62
+func (s *Set) Add(v uint64) (bool, error) {
+	if v >= unum.Unum64ValueBound {
+		return false, unum.ErrorMaxValue
+	}
+	if len(s.blocks) == 0 {
+		s.blocks = []block{newBlock([]uint64{v})}
+		s.n = 1
+		s.invalidate()
+		return true, nil
+	}
+	i := s.find(v)
+	b := &s.blocks[i]
+	r, ok := b.rank(v)
+	if ok {
+		return false, nil
+	}
+	vs := b.values(make([]uint64, 0, b.n+1))
+	vs = append(vs, 0)
+	copy(vs[r+1:], vs[r:])
+	vs[r] = v
+	if len(vs) <= BlockSize {
+		s.blocks[i] = newBlock(vs)
+	} else {
+		// split a full block in halves
+		h := len(vs) / 2
+		s.blocks = append(s.blocks, block{})
+		copy(s.blocks[i+2:], s.blocks[i+1:])
+		s.blocks[i], s.blocks[i+1] = newBlock(vs[:h]), newBlock(vs[h:])
+	}
+	s.n++
+	s.invalidate()
+	return true, nil
+}
+
+// Removes v from the set. Returns true if v was in the set.
+func (s *Set) Remove(v uint64) bool {
+	if len(s.blocks) == 0 {
+		return false
+	}
+	i := s.find(v)
+	b := &s.blocks[i]
+	r, ok := b.rank(v)
+	if !ok {
+		return false
+	}
+	if b.n == 1 {
+		s.blocks = append(s.blocks[:i], s.blocks[i+1:]...)
+	} else {
+		vs := b.values(make([]uint64, 0, b.n))
+		s.blocks[i] = newBlock(append(vs[:r], vs[r+1:]...))
+	}
+	s.n--
+	s.invalidate()
+	return true
+}
+
+// returns the number of values preceding each block.
+func (s *Set) blockRanks() []int {
+	t := s.ranks
+	t.once.Do(func() {
+		t.ranks = make([]int, len(s.blocks))
+		r := 0
+		for i := range s.blocks {
+			t.ranks[i] = r
+			r += s.blocks[i].n
+		}
+	})
+	return t.ranks
+}
+
+// Returns the number of values in the set less than v.
+func (s *Set) Rank(v uint64) int {
+	if len(s.blocks) == 0 {
+		return 0
+	}
+	i := s.find(v)
+	r, _ := s.blocks[i].rank(v)
+	return s.blockRanks()[i] + r
+}
+
+// Returns the i-th smallest value of the set (from 0), and true if
+// 0 <= i < Len().
+func (s *Set) Select(i int) (uint64, bool) {
+	if i < 0 || i >= s.n {
+		return 0, false
+	}
+	ranks := s.blockRanks()
+	k := sort.Search(len(ranks), func(k int) bool { return ranks[k] > i }) - 1
+	return s.blocks[k].get(i - ranks[k]), true
+}
+
+// Calls f for each value of the set in increasing order, until f returns
+// false.
+func (s *Set) Range(f func(v uint64) bool) {
+	it := s.Iterator()
+	for it.Next() {
+		if !f(it.Value()) {
+			return
+		}
+	}
+}
+
+// Appends the values of the set to dst, in increasing order.
+func (s *Set) Values(dst []uint64) []uint64 {
+	for i := range s.blocks {
+		dst = s.blocks[i].values(dst)
+	}
+	return dst
+}
+
+// Iterator iterates over the values of a Set in increasing order. The set
+// must not be modified during iteration.
+type Iterator struct {
+	s     *Set
+	block int
+	off   int
+	first bool
+	v     uint64
+}
+
+// Returns an iterator positioned before the first value of the set.
+func (s *Set) Iterator() *Iterator {
+	return &Iterator{s: s, first: true}
+}
+
+// Advances to the next value. Returns false at the end of the set.
+func (it *Iterator) Next() bool {
+	for it.block < len(it.s.blocks) {
+		b := &it.s.blocks[it.block]
+		if it.first {
+			it.first, it.v = false, b.min
+			return true
+		}
+		if it.off < len(b.data) {
+			d, n, _ := unum.DecodeUnum64(b.data[it.off:])
+			it.v += d
+			it.off += n
+			return true
+		}
+		it.block, it.off, it.first = it.block+1, 0, true
+	}
+	return false
+}
+
+// Advances to the first value not less than v, skipping blocks by their
+// headers. Returns false at the end of the set.
+func (it *Iterator) Seek(v uint64) bool {
+	if !it.first && it.block < len(it.s.blocks) && it.v >= v {
+		return true
+	}
+	if it.block < len(it.s.blocks) && it.s.blocks[it.block].max < v {
+		blocks := it.s.blocks[it.block:]
+		it.block += sort.Search(len(blocks), func(i int) bool { return blocks[i].max >= v })
+		it.off, it.first = 0, true
+	}
+	for it.Next() {
+		if it.v >= v {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the current value.
+func (it *Iterator) Value() uint64 {
+	return it.v
+}
+
+// merges the sets a and b, keeping values in a only (if inA), in b only
+// (if inB), and in both (if inAB).
+func merge(a, b *Set, inA, inB, inAB bool) *Set {
+	var bld builder
+	ia, ib := a.Iterator(), b.Iterator()
+	okA, okB := ia.Next(), ib.Next()
+	for okA || okB {
+		switch {
+		case okA && (!okB || ia.v < ib.v):
+			if inA {
+				bld.add(ia.v)
+			}
+			okA = ia.Next()
+		case okB && (!okA || ib.v < ia.v):
+			if inB {
+				bld.add(ib.v)
+			}
+			okB = ib.Next()
+		default:
+			if inAB {
+				bld.add(ia.v)
+			}
+			okA, okB = ia.Next(), ib.Next()
+		}
+		if !inA && !okB || !inB && !okA {
+			break
+		}
+	}
+	return bld.set()
+}
+
+// Returns the union of sets s and t.
+func (s *Set) Union(t *Set) *Set {
+	return merge(s, t, true, true, true)
+}
+
+// Returns the intersection of sets s and t.
+func (s *Set) Intersect(t *Set) *Set {
+	var bld builder
+	ia, ib := s.Iterator(), t.Iterator()
+	ok := ia.Next() && ib.Next()
+	for ok {
+		switch {
+		case ia.v < ib.v:
+			ok = ia.Seek(ib.v)
+		case ib.v < ia.v:
+			ok = ib.Seek(ia.v)
+		default:
+			bld.add(ia.v)
+			ok = ia.Next() && ib.Next()
+		}
+	}
+	return bld.set()
+}
+
+// Returns the values of s not in t.
+func (s *Set) Difference(t *Set) *Set {
+	return merge(s, t, true, false, false)
+}
+
+// Serialization
+//
+// The image of a Set is its block headers and data, which Load uses as is:
+//
+//      count   UNUM-64     number of values
+//      blocks  UNUM-64     number of blocks
+//      block:
+//      min     UNUM-64     minimum value
+//      span    UNUM-64     maximum - minimum value
+//      n       UNUM-32     number of values, in [1, BlockSize]
+//      len     UNUM-32     length of data
+//      data    [len]byte   UNUM-64 differences of successive values
+
+// Returns the serialized image of the set.
+func (s *Set) MarshalBinary() ([]byte, error) {
+	size := unum.SizeUnum64(uint64(s.n)) + unum.SizeUnum64(uint64(len(s.blocks)))
+	for i := range s.blocks {
+		b := &s.blocks[i]
+		size += unum.SizeUnum64(b.min) + unum.SizeUnum64(b.max-b.min) +
+			unum.SizeUnum32(uint32(b.n)) + unum.SizeUnum32(uint32(len(b.data))) + len(b.data)
+	}
+	p := make([]byte, size)
+	n, _ := unum.EncodeUnum64(p, uint64(s.n))
+	n0, _ := unum.EncodeUnum64(p[n:], uint64(len(s.blocks)))
+	n += n0
+	for i := range s.blocks {
+		b := &s.blocks[i]
+		n0, _ = unum.EncodeUnum64(p[n:], b.min)
+		n += n0
+		n0, _ = unum.EncodeUnum64(p[n:], b.max-b.min)
+		n += n0
+		n0, _ = unum.EncodeUnum32(p[n:], uint32(b.n))
+		n += n0
+		n0, _ = unum.EncodeUnum32(p[n:], uint32(len(b.data)))
+		n += n0
+		n += copy(p[n:], b.data)
+	}
+	return p, nil
+}
+
+// Decodes the serialized image b, replacing the values of the set. b is
+// copied. See Load.
+func (s *Set) UnmarshalBinary(b []byte) error {
+	s0, e := Load(append([]byte(nil), b...))
+	if e != nil {
+		return e
+	}
+	*s = *s0
+	return nil
+}
+
+// Returns the Set of serialized image b. The set refers to (and b must not
+// be modified while the set is in use) the block data of b, which is
+// checked but not decoded into a new set.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCorrupt  -- invalid arg b : non-conformant image
+func Load(b []byte) (*Set, error) {
+	r := unum.NewBufferDecoder(b)
+	count := r.Unum64()
+	nblocks := r.Unum64()
+	if r.Err() != nil || nblocks > uint64(len(b)) || count > uint64(BlockSize)*nblocks {
+		return nil, ErrorCorrupt
+	}
+	s := &Set{blocks: make([]block, nblocks)}
+	for i := range s.blocks {
+		blk := &s.blocks[i]
+		blk.min = r.Unum64()
+		span := r.Unum64()
+		blk.n = int(r.Unum32())
+		blk.data = r.Next(int(r.Unum32()))
+		blk.max = blk.min + span
+		if r.Err() != nil || blk.n < 1 || blk.n > BlockSize || blk.max >= unum.Unum64ValueBound ||
+			(i > 0 && blk.min <= s.blocks[i-1].max) || !blk.check() {
+			return nil, ErrorCorrupt
+		}
+		s.n += blk.n
+	}
+	if r.Err() != nil || len(r.Rest()) != 0 || uint64(s.n) != count {
+		return nil, ErrorCorrupt
+	}
+	s.invalidate()
+	return s, nil
+}
+
+// returns true if the block data holds n-1 positive differences summing
+// to max - min.
+func (b *block) check() bool {
+	v, i := b.min, 1
+	for off := 0; off < len(b.data); i++ {
+		d, n, e := unum.DecodeUnum64(b.data[off:])
+		if e != nil || d == 0 || d > b.max-v {
+			return false
+		}
+		v += d
+		off += n
+	}
+	return i == b.n && v == b.max
+}
@@ -0,0 +1,284 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package set_test
+
+import (
+	"bytes"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+	"unum"
+	"unum/set"
+)
+
+// reference set
+type ref map[uint64]bool
+
+func (m ref) sorted() []uint64 {
+	vs := make([]uint64, 0, len(m))
+	for v := range m {
+		vs = append(vs, v)
+	}
+	sort.Slice(vs, func(i, j int) bool { return vs[i] < vs[j] })
+	return vs
+}
+
+func randomIds(r *rand.Rand, n int, span int64) ref {
+	m := ref{}
+	for len(m) < n {
+		m[1<<40+uint64(r.Int63n(span))] = true
+	}
+	return m
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAddRemove(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	s := set.New()
+	m := ref{}
+	for i := 0; i < 20000; i++ {
+		v := 1<<40 + uint64(r.Int63n(10000))
+		if r.Intn(3) == 0 {
+			if s.Remove(v) != m[v] {
+				t.Fatalf("BUG - Remove(%d) - expected:%t\n", v, m[v])
+			}
+			delete(m, v)
+			continue
+		}
+		added, e := s.Add(v)
+		if e != nil {
+			t.Fatalf("error adding - e:%s\n", e.Error())
+		}
+		if added == m[v] {
+			t.Fatalf("BUG - Add(%d) - expected:%t\n", v, !m[v])
+		}
+		m[v] = true
+	}
+	if s.Len() != len(m) {
+		t.Errorf("expected len %d - have:%d\n", len(m), s.Len())
+	}
+	if !equal(s.Values(nil), m.sorted()) {
+		t.Errorf("BUG - values differ\n")
+	}
+	for i := 0; i < 1000; i++ {
+		v := 1<<40 + uint64(r.Int63n(10010)) - 5
+		if s.Contains(v) != m[v] {
+			t.Errorf("BUG - Contains(%d) - expected:%t\n", v, m[v])
+		}
+	}
+	if _, e := s.Add(unum.Unum64ValueBound); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+}
+
+func TestRankSelect(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	vs := randomIds(r, 5000, 1<<20).sorted()
+	s, e := set.FromSorted(vs)
+	if e != nil {
+		t.Fatalf("error creating set - e:%s\n", e.Error())
+	}
+	for i, v := range vs {
+		if rk := s.Rank(v); rk != i {
+			t.Fatalf("BUG - Rank(%d) - expected:%d have:%d\n", v, i, rk)
+		}
+		if rk := s.Rank(v + 1); rk != i+1 {
+			t.Fatalf("BUG - Rank(%d) - expected:%d have:%d\n", v+1, i+1, rk)
+		}
+		if v0, ok := s.Select(i); !ok || v0 != v {
+			t.Fatalf("BUG - Select(%d) - expected:%d have:%d\n", i, v, v0)
+		}
+	}
+	if rk := s.Rank(0); rk != 0 {
+		t.Errorf("expected rank 0 - have:%d\n", rk)
+	}
+	if _, ok := s.Select(len(vs)); ok {
+		t.Errorf("expected Select out of range\n")
+	}
+	// ranks follow modifications
+	s.Remove(vs[0])
+	if v0, _ := s.Select(0); v0 != vs[1] || s.Rank(vs[2]) != 1 {
+		t.Errorf("BUG - stale ranks\n")
+	}
+}
+
+// run with -race: the first Rank and Select build the rank table
+func TestRankSelectParallel(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	vs := randomIds(r, 2000, 1<<20).sorted()
+	s, e := set.FromSorted(vs)
+	if e != nil {
+		t.Fatalf("error creating set - e:%s\n", e.Error())
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := g; i < len(vs); i += 8 {
+				if rk := s.Rank(vs[i]); rk != i {
+					t.Errorf("BUG - Rank(%d) - expected:%d have:%d\n", vs[i], i, rk)
+					return
+				}
+				if v0, ok := s.Select(i); !ok || v0 != vs[i] {
+					t.Errorf("BUG - Select(%d) - expected:%d have:%d\n", i, vs[i], v0)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestFromSorted(t *testing.T) {
+	if _, e := set.FromSorted([]uint64{1, 2, 2}); e != set.ErrorOrder {
+		t.Errorf("expected ErrorOrder - have:%v\n", e)
+	}
+	if _, e := set.FromSorted([]uint64{1, unum.Unum64ValueBound}); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	s, _ := set.FromSorted(nil)
+	if s.Len() != 0 || s.Contains(0) || s.Rank(10) != 0 || s.Remove(0) {
+		t.Errorf("BUG - empty set\n")
+	}
+}
+
+func TestAlgebra(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, span := range []int64{3000, 1 << 30} {
+		ma, mb := randomIds(r, 2000, span), randomIds(r, 1500, span)
+		a, _ := set.FromSorted(ma.sorted())
+		b, _ := set.FromSorted(mb.sorted())
+		union, inter, diff := ref{}, ref{}, ref{}
+		for v := range ma {
+			union[v] = true
+			if mb[v] {
+				inter[v] = true
+			} else {
+				diff[v] = true
+			}
+		}
+		for v := range mb {
+			union[v] = true
+		}
+		for _, tc := range []struct {
+			name string
+			s    *set.Set
+			m    ref
+		}{
+			{"union", a.Union(b), union},
+			{"intersect", a.Intersect(b), inter},
+			{"difference", a.Difference(b), diff},
+			{"intersect-empty", a.Intersect(set.New()), ref{}},
+			{"difference-empty", a.Difference(set.New()), ma},
+		} {
+			if !equal(tc.s.Values(nil), tc.m.sorted()) || tc.s.Len() != len(tc.m) {
+				t.Errorf("BUG - %s - span:%d\n", tc.name, span)
+			}
+		}
+	}
+}
+
+func TestIterator(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	vs := randomIds(r, 3000, 100000).sorted()
+	s, _ := set.FromSorted(vs)
+	var vs0 []uint64
+	s.Range(func(v uint64) bool {
+		vs0 = append(vs0, v)
+		return len(vs0) < 100
+	})
+	if !equal(vs0, vs[:100]) {
+		t.Errorf("BUG - Range\n")
+	}
+	it := s.Iterator()
+	for i := 0; i < len(vs); i += 97 {
+		target := vs[i] - 1
+		if i > 0 && vs[i-1] == target {
+			target = vs[i]
+		}
+		if !it.Seek(target) || it.Value() != vs[i] {
+			t.Fatalf("BUG - Seek(%d) - expected:%d have:%d\n", target, vs[i], it.Value())
+		}
+	}
+	if it.Seek(vs[len(vs)-1] + 1) {
+		t.Errorf("expected Seek past end to fail\n")
+	}
+}
+
+func TestSerialization(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	vs := randomIds(r, 10000, 1<<24).sorted()
+	s, _ := set.FromSorted(vs)
+	b, e := s.MarshalBinary()
+	if e != nil {
+		t.Fatalf("error marshaling - e:%s\n", e.Error())
+	}
+	if len(b) > 3*len(vs) {
+		t.Errorf("image not compact - len:%d values:%d\n", len(b), len(vs))
+	}
+	s0, e := set.Load(b)
+	if e != nil {
+		t.Fatalf("error loading - e:%s\n", e.Error())
+	}
+	if !equal(s0.Values(nil), vs) || s0.Rank(vs[5000]) != 5000 {
+		t.Errorf("BUG - loaded set differs\n")
+	}
+	// loaded sets may be modified without changing the image
+	b0 := append([]byte(nil), b...)
+	s0.Add(vs[0] + 1)
+	s0.Remove(vs[1])
+	if !bytes.Equal(b, b0) {
+		t.Errorf("BUG - image modified\n")
+	}
+	var s1 set.Set
+	if e := s1.UnmarshalBinary(b); e != nil || !equal(s1.Values(nil), vs) {
+		t.Errorf("BUG - UnmarshalBinary - e:%v\n", e)
+	}
+
+	for _, p := range [][]byte{
+		b[:len(b)-1],
+		append(append([]byte(nil), b...), 0),
+		{},
+		{1, 1, 5, 0, 1, 1, 1},          // single value block, with data
+		{2, 1, 5, 0, 2, 1, 0},          // zero difference
+		{2, 2, 5, 0, 1, 0, 5, 0, 1, 0}, // unordered blocks
+	} {
+		if _, e := set.Load(p); e != set.ErrorCorrupt {
+			t.Errorf("expected ErrorCorrupt - b:%x have:%v\n", p, e)
+		}
+	}
+}
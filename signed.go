@@ -0,0 +1,221 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum
+
+import (
+	"io"
+)
+
+// Signed integers are mapped to unsigned integers by zigzag encoding, so
+// that values of small magnitude have small images:
+//
+//      int        | zigzag
+//      -----------+--------
+//      0          | 0
+//      -1         | 1
+//      1          | 2
+//      -2         | 3
+//      ...        | ...
+//
+// and the zigzag image is then UNUM encoded. The zigzag image must be
+// within the unsigned value bound of the encoding, so the signed range is
+// half that of the unsigned range: [-bound, bound).
+
+// signed int exclusive upper (and inclusive lower, negated) bound values
+const (
+	Int64ValueBound = int64(Unum64ValueBound >> 1)
+	Int32ValueBound = int32(Unum32ValueBound >> 1)
+	Int16ValueBound = int16(Unum16ValueBound >> 1)
+)
+
+// Returns the zigzag image of signed value v.
+func Zigzag64(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+// Returns the signed value of zigzag image u.
+func Unzigzag64(u uint64) int64 {
+	return int64(u>>1) ^ -int64(u&1)
+}
+
+// Returns the zigzag image of signed value v.
+func Zigzag32(v int32) uint32 {
+	return uint32(v<<1) ^ uint32(v>>31)
+}
+
+// Returns the signed value of zigzag image u.
+func Unzigzag32(u uint32) int32 {
+	return int32(u>>1) ^ -int32(u&1)
+}
+
+// Returns the zigzag image of signed value v.
+func Zigzag16(v int16) uint16 {
+	return uint16(v<<1) ^ uint16(v>>15)
+}
+
+// Returns the signed value of zigzag image u.
+func Unzigzag16(u uint16) int16 {
+	return int16(u>>1) ^ -int16(u&1)
+}
+
+// Zigzag UNUM-64 encodes the signed value v in buffer b.
+// returns number of bytes written { 1, 2, 4, 8 } on nil
+// error.
+//
+// On error returns (0, e) where e is:
+//    ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    ErrorMaxValue        -- invalid arg v : v < -2^61 or v >= 2^61
+func EncodeInt64(b []byte, v int64) (n int, e error) {
+	return EncodeUnum64(b, Zigzag64(v))
+}
+
+// decodes zigzag UNUM-64 encoded signed integer value v from input buffer b.
+// returns v, number of bytes n, if there are no errors.
+//
+// On error returns (0, 0, e) where e is:
+//    ErrorBufferEOF       -- invalid arg b : len(b) == 0
+//    ErrorInvalidBuffer   -- invalid arg b : non-conformant byte sequence
+func DecodeInt64(b []byte) (v int64, n int, e error) {
+	u, n, e := DecodeUnum64(b)
+	if e != nil {
+		return 0, 0, e
+	}
+	return Unzigzag64(u), n, nil
+}
+
+// Returns the zigzag UNUM-64 encoded size of value v { 1, 2, 4, 8 },
+// or 0 if v is not encodable.
+func SizeInt64(v int64) int {
+	return SizeUnum64(Zigzag64(v))
+}
+
+// Writes zigzag UNUM-64 encoded signed value 'v' to writer 'w'.
+// See WriteUnum64.
+func WriteInt64(w io.Writer, v int64) (n int, e error) {
+	return WriteUnum64(w, Zigzag64(v))
+}
+
+// Reads zigzag UNUM-64 encoded signed value from Reader r.
+// See ReadUint.
+func ReadInt64(r io.Reader) (v int64, n int, e error) {
+	u, n, e := ReadUint(r)
+	if e != nil {
+		return 0, n, e
+	}
+	return Unzigzag64(u), n, nil
+}
+
+// Zigzag UNUM-32 encodes the signed value v in buffer b.
+// returns number of bytes written { 1, 2, 3, 4 } on nil
+// error.
+//
+// On error returns (0, e) where e is:
+//    ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    ErrorMaxValue        -- invalid arg v : v < -2^29 or v >= 2^29
+func EncodeInt32(b []byte, v int32) (n int, e error) {
+	return EncodeUnum32(b, Zigzag32(v))
+}
+
+// decodes zigzag UNUM-32 encoded signed integer value v from input buffer b.
+// returns v, number of bytes n, if there are no errors.
+//
+// On error returns (0, 0, e) where e is:
+//    ErrorBufferEOF       -- invalid arg b : len(b) == 0
+//    ErrorInvalidBuffer   -- invalid arg b : non-conformant byte sequence
+func DecodeInt32(b []byte) (v int32, n int, e error) {
+	u, n, e := DecodeUnum32(b)
+	if e != nil {
+		return 0, 0, e
+	}
+	return Unzigzag32(u), n, nil
+}
+
+// Returns the zigzag UNUM-32 encoded size of value v { 1, 2, 3, 4 },
+// or 0 if v is not encodable.
+func SizeInt32(v int32) int {
+	return SizeUnum32(Zigzag32(v))
+}
+
+// Writes zigzag UNUM-32 encoded signed value 'v' to writer 'w'.
+// See WriteUnum32.
+func WriteInt32(w io.Writer, v int32) (n int, e error) {
+	return WriteUnum32(w, Zigzag32(v))
+}
+
+// Reads zigzag UNUM-32 encoded signed value from Reader r.
+// See ReadUnum32.
+func ReadInt32(r io.Reader) (v int32, n int, e error) {
+	u, n, e := ReadUnum32(r)
+	if e != nil {
+		return 0, n, e
+	}
+	return Unzigzag32(u), n, nil
+}
+
+// Zigzag UNUM-16 encodes the signed value v in buffer b.
+// returns number of bytes written { 1, 2 } on nil
+// error.
+//
+// On error returns (0, e) where e is:
+//    ErrorBufferOverflow  -- invalid arg b : len(b) < n
+//    ErrorMaxValue        -- invalid arg v : v < -2^14 or v >= 2^14
+func EncodeInt16(b []byte, v int16) (n int, e error) {
+	return EncodeUnum16(b, Zigzag16(v))
+}
+
+// decodes zigzag UNUM-16 encoded signed integer value v from input buffer b.
+// returns v, number of bytes n, if there are no errors.
+//
+// On error returns (0, 0, e) where e is:
+//    ErrorBufferEOF       -- invalid arg b : len(b) == 0
+//    ErrorInvalidBuffer   -- invalid arg b : non-conformant byte sequence
+func DecodeInt16(b []byte) (v int16, n int, e error) {
+	u, n, e := DecodeUnum16(b)
+	if e != nil {
+		return 0, 0, e
+	}
+	return Unzigzag16(u), n, nil
+}
+
+// Returns the zigzag UNUM-16 encoded size of value v { 1, 2 },
+// or 0 if v is not encodable.
+func SizeInt16(v int16) int {
+	return SizeUnum16(Zigzag16(v))
+}
+
+// Writes zigzag UNUM-16 encoded signed value 'v' to writer 'w'.
+// See WriteUnum16.
+func WriteInt16(w io.Writer, v int16) (n int, e error) {
+	return WriteUnum16(w, Zigzag16(v))
+}
+
+// Reads zigzag UNUM-16 encoded signed value from Reader r.
+// See ReadUnum16.
+func ReadInt16(r io.Reader) (v int16, n int, e error) {
+	u, n, e := ReadUnum16(r)
+	if e != nil {
+		return 0, n, e
+	}
+	return Unzigzag16(u), n, nil
+}
@@ -0,0 +1,154 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unum_test
+
+import (
+	"bytes"
+	"testing"
+	"testing/quick"
+	"unum"
+)
+
+func TestZigzag(t *testing.T) {
+	var tests = []struct {
+		v int64
+		u uint64
+	}{
+		{0, 0}, {-1, 1}, {1, 2}, {-2, 3}, {2, 4},
+		{-unum.Int64ValueBound, uint64(unum.Unum64ValueBound - 1)},
+		{unum.Int64ValueBound - 1, uint64(unum.Unum64ValueBound - 2)},
+	}
+	for _, test := range tests {
+		if u := unum.Zigzag64(test.v); u != test.u {
+			t.Errorf("Zigzag64 - v:%d - expected:%d have:%d\n", test.v, test.u, u)
+		}
+		if v := unum.Unzigzag64(test.u); v != test.v {
+			t.Errorf("Unzigzag64 - u:%d - expected:%d have:%d\n", test.u, test.v, v)
+		}
+	}
+}
+
+func TestCodecInt64(t *testing.T) {
+	f := func(v int64) bool {
+		errorExpected := v < -unum.Int64ValueBound || v >= unum.Int64ValueBound
+		var b0 [unum.Unum64Size]byte
+		n, e := unum.EncodeInt64(b0[:], v)
+		if e != nil {
+			if !errorExpected || e != unum.ErrorMaxValue {
+				t.Errorf("unexpected error encoding - v:%d - e:%s\n", v, e.Error())
+			}
+			return true
+		} else if errorExpected {
+			t.Errorf("expected error encoding - v:%d\n", v)
+		}
+		if size := unum.SizeInt64(v); size != n {
+			t.Errorf("SizeInt64 - v:%d - expected:%d have:%d\n", v, n, size)
+		}
+		v0, n0, e := unum.DecodeInt64(b0[:n])
+		if e != nil || n0 != n || v0 != v {
+			t.Errorf("BUG - v:%d - v0:%d n:%d n0:%d e:%v\n", v, v0, n, n0, e)
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+
+	// small magnitudes -- both shifted into range and direct
+	g := func(v int64) bool { return f(v >> 3) }
+	if e := quick.Check(g, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestCodecInt32(t *testing.T) {
+	f := func(v int32) bool {
+		v >>= 2
+		var b0 [unum.Unum32Size]byte
+		n, e := unum.EncodeInt32(b0[:], v)
+		if e != nil {
+			t.Errorf("unexpected error encoding - v:%d - e:%s\n", v, e.Error())
+			return true
+		}
+		v0, _, e := unum.DecodeInt32(b0[:n])
+		if e != nil || v0 != v {
+			t.Errorf("BUG - v:%d - v0:%d e:%v\n", v, v0, e)
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+	var b0 [unum.Unum32Size]byte
+	if _, e := unum.EncodeInt32(b0[:], unum.Int32ValueBound); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+}
+
+func TestCodecInt16(t *testing.T) {
+	for v := -unum.Int16ValueBound; v < unum.Int16ValueBound; v++ {
+		var b0 [unum.Unum16Size]byte
+		n, e := unum.EncodeInt16(b0[:], v)
+		if e != nil {
+			t.Fatalf("unexpected error encoding - v:%d - e:%s\n", v, e.Error())
+		}
+		v0, _, e := unum.DecodeInt16(b0[:n])
+		if e != nil || v0 != v {
+			t.Fatalf("BUG - v:%d - v0:%d e:%v\n", v, v0, e)
+		}
+	}
+}
+
+func TestReadWriteInt(t *testing.T) {
+	var buf bytes.Buffer
+	values := []int64{0, -1, 63, -64, 1 << 40, -(1 << 40)}
+	for _, v := range values {
+		if _, e := unum.WriteInt64(&buf, v); e != nil {
+			t.Fatalf("error writing - v:%d - e:%s\n", v, e.Error())
+		}
+		if _, e := unum.WriteInt32(&buf, int32(v>>20)); e != nil {
+			t.Fatalf("error writing - v:%d - e:%s\n", v, e.Error())
+		}
+		if _, e := unum.WriteInt16(&buf, int16(v>>40)); e != nil {
+			t.Fatalf("error writing - v:%d - e:%s\n", v, e.Error())
+		}
+	}
+	for _, v := range values {
+		v64, _, e := unum.ReadInt64(&buf)
+		if e != nil || v64 != v {
+			t.Errorf("ReadInt64 - expected:%d have:%d e:%v\n", v, v64, e)
+		}
+		v32, _, e := unum.ReadInt32(&buf)
+		if e != nil || v32 != int32(v>>20) {
+			t.Errorf("ReadInt32 - expected:%d have:%d e:%v\n", v>>20, v32, e)
+		}
+		v16, _, e := unum.ReadInt16(&buf)
+		if e != nil || v16 != int16(v>>40) {
+			t.Errorf("ReadInt16 - expected:%d have:%d e:%v\n", v>>40, v16, e)
+		}
+	}
+	if _, _, e := unum.ReadInt64(&buf); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+}
@@ -0,0 +1,248 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sparse
+
+import (
+	"unum"
+)
+
+// Matrix is an encoded sparse matrix in compressed sparse row (CSR) form.
+//
+// The image is:
+//
+//      rows        UNUM-32     number of rows, < 2^30
+//      cols        UNUM-32     number of columns, < 2^30
+//      type        UNUM-16     ValueType
+//      scale       8 bytes     Int only: quantization step
+//      nnz         UNUM-64     number of non-zero entries
+//      counts      [rows]UNUM-32  non-zero entries of each row (the
+//                              differences of the CSR row pointers)
+//      entries     entries of each row, in row order, as vector entries
+//                  (column gaps restart at each row)
+type Matrix struct {
+	rows, cols int
+	values
+	nnz     int
+	ptr     []int // index of first entry of each row, and nnz
+	offsets []int // offset in data of first entry of each row, and len(data)
+	data    []byte
+}
+
+// Returns the Matrix of the non-zero values of the rows of dense, which
+// must have equal lengths. See FromDense.
+//
+// On error returns (nil, e) where e is:
+//    ErrorValueType      -- invalid arg vt or scale
+//    ErrorDim            -- invalid arg dense : rows of unequal length
+//    unum.ErrorMaxValue  -- invalid arg dense : too many rows or columns,
+//                           or quantized value out of range
+func MatrixFromDense(dense [][]float64, vt ValueType, scale float64) (*Matrix, error) {
+	cols := 0
+	if len(dense) > 0 {
+		cols = len(dense[0])
+	}
+	rows := make([]*Vector, len(dense))
+	for i, row := range dense {
+		if len(row) != cols {
+			return nil, ErrorDim
+		}
+		v, e := FromDense(row, vt, scale)
+		if e != nil {
+			return nil, e
+		}
+		rows[i] = v
+	}
+	if len(rows) == 0 {
+		if e := checkValues(vt, scale); e != nil {
+			return nil, e
+		}
+		return &Matrix{values: values{vt: vt, scale: scale}, ptr: []int{0}, offsets: []int{0}}, nil
+	}
+	return MatrixFromRows(cols, rows)
+}
+
+// Returns the Matrix of the given rows, which must have dimension cols and
+// the same value type (and scale). The entries of the rows are copied as
+// they are.
+//
+// On error returns (nil, e) where e is:
+//    ErrorValueType      -- invalid arg rows : value types differ
+//    ErrorDim            -- invalid arg rows : dimension is not cols
+//    unum.ErrorMaxValue  -- invalid arg rows or cols : too many rows or
+//                           columns
+func MatrixFromRows(cols int, rows []*Vector) (*Matrix, error) {
+	if len(rows) > MaxDim || cols < 0 || cols > MaxDim {
+		return nil, unum.ErrorMaxValue
+	}
+	m := &Matrix{rows: len(rows), cols: cols, ptr: make([]int, len(rows)+1), offsets: make([]int, len(rows)+1)}
+	size := 0
+	for i, r := range rows {
+		if r.dim != cols {
+			return nil, ErrorDim
+		}
+		if i == 0 {
+			m.values = r.values
+		} else if r.values != m.values {
+			return nil, ErrorValueType
+		}
+		size += len(r.data)
+	}
+	m.data = make([]byte, 0, size)
+	for i, r := range rows {
+		m.data = append(m.data, r.data...)
+		m.nnz += r.nnz
+		m.ptr[i+1], m.offsets[i+1] = m.nnz, len(m.data)
+	}
+	return m, nil
+}
+
+// Returns the Matrix of image b. The matrix refers to b, which must not be
+// modified while the matrix is in use.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCorrupt  -- invalid arg b : non-conformant image
+func LoadMatrix(b []byte) (*Matrix, error) {
+	d := unum.NewBufferDecoder(b)
+	m := &Matrix{rows: int(d.Unum32()), cols: int(d.Unum32())}
+	m.values = decodeValues(d)
+	nnz := d.Unum64()
+	if d.Err() != nil || m.rows > len(d.Rest()) || nnz > uint64(len(d.Rest())) {
+		return nil, ErrorCorrupt
+	}
+	m.nnz = int(nnz)
+	m.ptr = make([]int, m.rows+1)
+	for i := 0; i < m.rows; i++ {
+		m.ptr[i+1] = m.ptr[i] + int(d.Unum32())
+		if d.Err() != nil || m.ptr[i+1] > m.nnz {
+			return nil, ErrorCorrupt
+		}
+	}
+	if m.ptr[m.rows] != m.nnz {
+		return nil, ErrorCorrupt
+	}
+	m.data = d.Rest()
+	m.offsets = make([]int, m.rows+1)
+	for i := 0; i < m.rows; i++ {
+		// the offset of row i+1 is found by walking the entries of row i
+		es := entries{values: m.values, nnz: m.ptr[i+1] - m.ptr[i], data: m.data[m.offsets[i]:]}
+		it := es.iterator()
+		for it.next() {
+		}
+		es.data = es.data[:it.off]
+		if !es.check(m.cols) {
+			return nil, ErrorCorrupt
+		}
+		m.offsets[i+1] = m.offsets[i] + it.off
+	}
+	if m.offsets[m.rows] != len(m.data) {
+		return nil, ErrorCorrupt
+	}
+	return m, nil
+}
+
+// Returns the image of the matrix.
+func (m *Matrix) MarshalBinary() ([]byte, error) {
+	b, _ := unum.AppendUnum32(nil, uint32(m.rows))
+	b, _ = unum.AppendUnum32(b, uint32(m.cols))
+	b = m.appendHeader(b)
+	b, _ = unum.AppendUnum64(b, uint64(m.nnz))
+	for i := 0; i < m.rows; i++ {
+		b, _ = unum.AppendUnum32(b, uint32(m.ptr[i+1]-m.ptr[i]))
+	}
+	return append(b, m.data...), nil
+}
+
+// Returns the number of rows.
+func (m *Matrix) Rows() int {
+	return m.rows
+}
+
+// Returns the number of columns.
+func (m *Matrix) Cols() int {
+	return m.cols
+}
+
+// Returns the number of non-zero entries.
+func (m *Matrix) NNZ() int {
+	return m.nnz
+}
+
+// Returns the value type of the matrix.
+func (m *Matrix) ValueType() ValueType {
+	return m.vt
+}
+
+// returns the entries of row i.
+func (m *Matrix) row(i int) entries {
+	return entries{values: m.values, nnz: m.ptr[i+1] - m.ptr[i], data: m.data[m.offsets[i]:m.offsets[i+1]]}
+}
+
+// Returns row i as a vector of dimension Cols(), referring to the entries
+// of the matrix. Panics if i is not in [0, Rows()).
+func (m *Matrix) Row(i int) *Vector {
+	return &Vector{dim: m.cols, entries: m.row(i)}
+}
+
+// Calls f for each non-zero entry (i, j, value) in row order, until f
+// returns false.
+func (m *Matrix) Range(f func(i, j int, value float64) bool) {
+	for i := 0; i < m.rows; i++ {
+		es := m.row(i)
+		it := es.iterator()
+		for it.next() {
+			if !f(i, it.i, it.v) {
+				return
+			}
+		}
+	}
+}
+
+// Returns the dense rows of the matrix.
+func (m *Matrix) Dense() [][]float64 {
+	dense := make([][]float64, m.rows)
+	for i := range dense {
+		dense[i] = m.Row(i).Dense(nil)
+	}
+	return dense
+}
+
+// Returns the product of the matrix and dense vector x in dst, which is
+// grown to Rows() values if needed.
+//
+// On error returns (dst, e) where e is:
+//    ErrorDim  -- invalid arg x : len(x) != Cols()
+func (m *Matrix) MulVec(dst, x []float64) ([]float64, error) {
+	if len(x) != m.cols {
+		return dst, ErrorDim
+	}
+	if cap(dst) < m.rows {
+		dst = make([]float64, m.rows)
+	}
+	dst = dst[:m.rows]
+	for i := range dst {
+		es := m.row(i)
+		dst[i] = es.dotDense(x)
+	}
+	return dst, nil
+}
@@ -0,0 +1,140 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sparse_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+	"unum/sparse"
+)
+
+func testMatrix(r *rand.Rand, rows, cols int) [][]float64 {
+	dense := make([][]float64, rows)
+	for i := range dense {
+		dense[i] = testDense(r, cols)
+	}
+	return dense
+}
+
+func TestMatrixDense(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	dense := testMatrix(r, 300, 2000)
+	dense[7] = make([]float64, 2000) // empty row
+	for _, vt := range []sparse.ValueType{sparse.Float32, sparse.Float64, sparse.Int} {
+		m, e := sparse.MatrixFromDense(dense, vt, 0.125)
+		if e != nil {
+			t.Fatalf("error encoding - e:%s\n", e.Error())
+		}
+		b, _ := m.MarshalBinary()
+		m0, e := sparse.LoadMatrix(b)
+		if e != nil {
+			t.Fatalf("error loading - e:%s\n", e.Error())
+		}
+		if m0.Rows() != 300 || m0.Cols() != 2000 || m0.NNZ() != m.NNZ() || m0.ValueType() != vt {
+			t.Errorf("BUG - rows:%d cols:%d nnz:%d\n", m0.Rows(), m0.Cols(), m0.NNZ())
+		}
+		if !reflect.DeepEqual(m0.Dense(), dense) {
+			t.Errorf("BUG - type:%d dense values differ\n", vt)
+		}
+		if row := m0.Row(3).Dense(nil); !reflect.DeepEqual(row, dense[3]) {
+			t.Errorf("BUG - row 3 differs\n")
+		}
+		nnz := 0
+		m0.Range(func(i, j int, v float64) bool {
+			if dense[i][j] != v || v == 0 {
+				t.Fatalf("BUG - entry (%d, %d) - expected:%g have:%g\n", i, j, dense[i][j], v)
+			}
+			nnz++
+			return true
+		})
+		if nnz != m.NNZ() {
+			t.Errorf("BUG - range nnz:%d expected:%d\n", nnz, m.NNZ())
+		}
+	}
+}
+
+func TestMulVec(t *testing.T) {
+	r := rand.New(rand.NewSource(5))
+	dense := testMatrix(r, 100, 500)
+	x := make([]float64, 500)
+	for i := range x {
+		x[i] = float64(r.Intn(16))
+	}
+	m, _ := sparse.MatrixFromDense(dense, sparse.Float64, 0)
+	y, e := m.MulVec(nil, x)
+	if e != nil {
+		t.Fatalf("error multiplying - e:%s\n", e.Error())
+	}
+	for i, row := range dense {
+		var expected float64
+		for j := range row {
+			expected += row[j] * x[j]
+		}
+		if y[i] != expected {
+			t.Errorf("BUG - row:%d expected:%g have:%g\n", i, expected, y[i])
+		}
+	}
+	if _, e := m.MulVec(nil, x[1:]); e != sparse.ErrorDim {
+		t.Errorf("expected ErrorDim - have:%v\n", e)
+	}
+}
+
+func TestMatrixFromRows(t *testing.T) {
+	a, _ := sparse.FromDense([]float64{0, 1, 0}, sparse.Float64, 0)
+	b, _ := sparse.FromDense([]float64{2, 0, 0}, sparse.Float32, 0)
+	c, _ := sparse.FromDense([]float64{2, 0}, sparse.Float64, 0)
+	if _, e := sparse.MatrixFromRows(3, []*sparse.Vector{a, b}); e != sparse.ErrorValueType {
+		t.Errorf("expected ErrorValueType - have:%v\n", e)
+	}
+	if _, e := sparse.MatrixFromRows(3, []*sparse.Vector{a, c}); e != sparse.ErrorDim {
+		t.Errorf("expected ErrorDim - have:%v\n", e)
+	}
+	if _, e := sparse.MatrixFromDense([][]float64{{1, 2}, {3}}, sparse.Float64, 0); e != sparse.ErrorDim {
+		t.Errorf("expected ErrorDim - have:%v\n", e)
+	}
+	m, e := sparse.MatrixFromDense(nil, sparse.Float64, 0)
+	if e != nil || m.Rows() != 0 {
+		t.Fatalf("BUG - empty matrix - e:%v\n", e)
+	}
+	p, _ := m.MarshalBinary()
+	if _, e := sparse.LoadMatrix(p); e != nil {
+		t.Errorf("error loading empty matrix - e:%v\n", e)
+	}
+}
+
+func TestLoadMatrixErrors(t *testing.T) {
+	m, _ := sparse.MatrixFromDense([][]float64{{0, 1, 0}, {2, 0, 3}}, sparse.Int, 1)
+	b, _ := m.MarshalBinary()
+	for _, p := range [][]byte{
+		b[:len(b)-1],
+		append(append([]byte(nil), b...), 0),
+		{},
+		{1, 2, 2, 1, 2, 0, 1, 0, 0, 0}, // row count exceeds nnz
+	} {
+		if _, e := sparse.LoadMatrix(p); e != sparse.ErrorCorrupt {
+			t.Errorf("expected ErrorCorrupt - b:%x have:%v\n", p, e)
+		}
+	}
+}
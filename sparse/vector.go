@@ -0,0 +1,413 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package sparse implements compact encodings of sparse vectors and
+// matrices.
+//
+// A sparse vector is encoded as its non-zero entries, each the gap from
+// the previous index (UNUM-32) followed by the value. The image is:
+//
+//      dim         UNUM-32     dimension, < 2^30
+//      type        UNUM-16     ValueType
+//      scale       8 bytes     Int only: quantization step (IEEE 754, big-endian)
+//      nnz         UNUM-32     number of non-zero entries
+//      entries:    per entry
+//      gap         UNUM-32     index - (previous index + 1), from -1
+//      value       Float32     4 bytes IEEE 754 (big-endian)
+//                  Float64     8 bytes IEEE 754 (big-endian)
+//                  Int         zigzag UNUM-64 of round(value / scale)
+//
+// Vectors and matrices operate directly on the entries of their images:
+// iteration, dot products and products decode entries as they go.
+package sparse
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorValueType = fmt.Errorf("sparse.ErrorValueType")
+	ErrorDim       = fmt.Errorf("sparse.ErrorDim")
+	ErrorCorrupt   = fmt.Errorf("sparse.ErrorCorrupt")
+)
+
+// ValueType is the encoding of the values of a vector or matrix.
+type ValueType byte
+
+// Value types
+const (
+	Float32 ValueType = 1
+	Float64 ValueType = 2
+	Int     ValueType = 3 // quantized
+)
+
+// MaxDim is the bound of dimensions.
+const MaxDim = int(unum.Unum32ValueBound - 1)
+
+// values of entries.
+type values struct {
+	vt    ValueType
+	scale float64 // Int only
+}
+
+// returns the encoded image of v.
+func (vs values) append(dst []byte, v float64) ([]byte, error) {
+	var b [unum.Unum64Size]byte
+	switch vs.vt {
+	case Float32:
+		binary.BigEndian.PutUint32(b[:], math.Float32bits(float32(v)))
+		return append(dst, b[:4]...), nil
+	case Float64:
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(v))
+		return append(dst, b[:8]...), nil
+	}
+	q := math.Round(v / vs.scale)
+	if !(q >= -float64(unum.Int64ValueBound) && q < float64(unum.Int64ValueBound)) {
+		return nil, unum.ErrorMaxValue
+	}
+	n, _ := unum.EncodeInt64(b[:], int64(q))
+	return append(dst, b[:n]...), nil
+}
+
+// decodes a value from b. returns the value and its length.
+func (vs values) decode(b []byte) (float64, int, bool) {
+	switch vs.vt {
+	case Float32:
+		if len(b) < 4 {
+			return 0, 0, false
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), 4, true
+	case Float64:
+		if len(b) < 8 {
+			return 0, 0, false
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(b)), 8, true
+	}
+	q, n, e := unum.DecodeInt64(b)
+	if e != nil {
+		return 0, 0, false
+	}
+	return float64(q) * vs.scale, n, true
+}
+
+// appends the header fields type and scale.
+func (vs values) appendHeader(dst []byte) []byte {
+	dst, _ = unum.AppendUnum16(dst, uint16(vs.vt))
+	if vs.vt == Int {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], math.Float64bits(vs.scale))
+		dst = append(dst, b[:]...)
+	}
+	return dst
+}
+
+// decodes the header fields type and scale.
+func decodeValues(d *unum.BufferDecoder) values {
+	vs := values{vt: ValueType(d.Unum16())}
+	if vs.vt == Int {
+		if p := d.Next(8); p != nil {
+			vs.scale = math.Float64frombits(binary.BigEndian.Uint64(p))
+		}
+	}
+	if d.Err() == nil && (vs.vt < Float32 || vs.vt > Int || vs.vt == Int && !(vs.scale > 0) || math.IsInf(vs.scale, 0)) {
+		d.Fail(ErrorCorrupt)
+	}
+	return vs
+}
+
+func checkValues(vt ValueType, scale float64) error {
+	if vt < Float32 || vt > Int {
+		return ErrorValueType
+	}
+	if vt == Int && (!(scale > 0) || math.IsInf(scale, 0)) {
+		return ErrorValueType
+	}
+	return nil
+}
+
+// entries is the encoded entries of a vector, or of a matrix row.
+type entries struct {
+	values
+	nnz  int
+	data []byte
+}
+
+// checks that the data holds nnz entries with indices less than dim.
+func (es *entries) check(dim int) bool {
+	i := -1
+	off := 0
+	for k := 0; k < es.nnz; k++ {
+		gap, n, e := unum.DecodeUnum32(es.data[off:])
+		if e != nil {
+			return false
+		}
+		i += int(gap) + 1
+		if i >= dim {
+			return false
+		}
+		_, n0, ok := es.decode(es.data[off+n:])
+		if !ok {
+			return false
+		}
+		off += n + n0
+	}
+	return off == len(es.data)
+}
+
+// iterator over entries.
+type iterator struct {
+	es  *entries
+	k   int
+	off int
+	i   int
+	v   float64
+}
+
+func (es *entries) iterator() iterator {
+	return iterator{es: es, i: -1}
+}
+
+func (it *iterator) next() bool {
+	if it.k == it.es.nnz {
+		return false
+	}
+	gap, n, _ := unum.DecodeUnum32(it.es.data[it.off:])
+	v, n0, _ := it.es.decode(it.es.data[it.off+n:])
+	it.off += n + n0
+	it.i += int(gap) + 1
+	it.v = v
+	it.k++
+	return true
+}
+
+// Vector is an encoded sparse vector.
+type Vector struct {
+	dim int
+	entries
+}
+
+// Returns the Vector of the non-zero values of dense, encoded as vt. Int
+// values are quantized with step scale (ignored for float types).
+//
+// On error returns (nil, e) where e is:
+//    ErrorValueType      -- invalid arg vt or scale
+//    unum.ErrorMaxValue  -- invalid arg dense : len(dense) > MaxDim, or
+//                           quantized value out of range
+func FromDense(dense []float64, vt ValueType, scale float64) (*Vector, error) {
+	if len(dense) > MaxDim {
+		return nil, unum.ErrorMaxValue
+	}
+	var b builder
+	if e := b.reset(len(dense), vt, scale); e != nil {
+		return nil, e
+	}
+	for i, v := range dense {
+		if v != 0 {
+			if e := b.add(i, v); e != nil {
+				return nil, e
+			}
+		}
+	}
+	return b.vector(), nil
+}
+
+// Returns the Vector of dimension dim with values vals at the increasing
+// indices. See FromDense.
+//
+// On error returns (nil, e) where e is:
+//    ErrorValueType      -- invalid arg vt or scale
+//    ErrorDim            -- invalid arg indices : not increasing, out of
+//                           range, or not one per value
+//    unum.ErrorMaxValue  -- invalid arg dim or vals : out of range
+func FromEntries(dim int, indices []int, vals []float64, vt ValueType, scale float64) (*Vector, error) {
+	if dim < 0 || dim > MaxDim {
+		return nil, unum.ErrorMaxValue
+	}
+	if len(indices) != len(vals) {
+		return nil, ErrorDim
+	}
+	var b builder
+	if e := b.reset(dim, vt, scale); e != nil {
+		return nil, e
+	}
+	for k, i := range indices {
+		if e := b.add(i, vals[k]); e != nil {
+			return nil, e
+		}
+	}
+	return b.vector(), nil
+}
+
+// builder encodes entries in index order.
+type builder struct {
+	dim  int
+	prev int
+	es   entries
+}
+
+func (b *builder) reset(dim int, vt ValueType, scale float64) error {
+	if e := checkValues(vt, scale); e != nil {
+		return e
+	}
+	b.dim, b.prev = dim, -1
+	b.es = entries{values: values{vt: vt, scale: scale}}
+	return nil
+}
+
+func (b *builder) add(i int, v float64) error {
+	if i <= b.prev || i >= b.dim {
+		return ErrorDim
+	}
+	data, _ := unum.AppendUnum32(b.es.data, uint32(i-b.prev-1))
+	data, e := b.es.append(data, v)
+	if e != nil {
+		return e
+	}
+	b.es.data, b.prev = data, i
+	b.es.nnz++
+	return nil
+}
+
+func (b *builder) vector() *Vector {
+	return &Vector{dim: b.dim, entries: b.es}
+}
+
+// Returns the Vector of image b. The vector refers to b, which must not be
+// modified while the vector is in use.
+//
+// On error returns (nil, e) where e is:
+//    ErrorCorrupt  -- invalid arg b : non-conformant image
+func Load(b []byte) (*Vector, error) {
+	d := unum.NewBufferDecoder(b)
+	dim := int(d.Unum32())
+	vs := decodeValues(d)
+	nnz := int(d.Unum32())
+	if d.Err() != nil || nnz > dim || nnz > len(d.Rest()) {
+		return nil, ErrorCorrupt
+	}
+	v := &Vector{dim: dim, entries: entries{values: vs, nnz: nnz, data: d.Rest()}}
+	if !v.check(dim) {
+		return nil, ErrorCorrupt
+	}
+	return v, nil
+}
+
+// Returns the image of the vector.
+func (v *Vector) MarshalBinary() ([]byte, error) {
+	b, _ := unum.AppendUnum32(nil, uint32(v.dim))
+	b = v.appendHeader(b)
+	b, _ = unum.AppendUnum32(b, uint32(v.nnz))
+	return append(b, v.data...), nil
+}
+
+// Returns the dimension of the vector.
+func (v *Vector) Dim() int {
+	return v.dim
+}
+
+// Returns the number of non-zero entries.
+func (v *Vector) NNZ() int {
+	return v.nnz
+}
+
+// Returns the value type of the vector.
+func (v *Vector) ValueType() ValueType {
+	return v.vt
+}
+
+// Calls f for each non-zero entry (i, value) in index order, until f
+// returns false.
+func (v *Vector) Range(f func(i int, value float64) bool) {
+	it := v.iterator()
+	for it.next() {
+		if !f(it.i, it.v) {
+			return
+		}
+	}
+}
+
+// Returns the dense values of the vector in dst, which is grown to Dim()
+// values if needed.
+func (v *Vector) Dense(dst []float64) []float64 {
+	if cap(dst) < v.dim {
+		dst = make([]float64, v.dim)
+	}
+	dst = dst[:v.dim]
+	for i := range dst {
+		dst[i] = 0
+	}
+	it := v.iterator()
+	for it.next() {
+		dst[it.i] = it.v
+	}
+	return dst
+}
+
+// Returns the dot product of vectors v and w.
+//
+// On error returns (0, e) where e is:
+//    ErrorDim  -- invalid arg w : dimension differs
+func (v *Vector) Dot(w *Vector) (float64, error) {
+	if v.dim != w.dim {
+		return 0, ErrorDim
+	}
+	var sum float64
+	a, b := v.iterator(), w.iterator()
+	okA, okB := a.next(), b.next()
+	for okA && okB {
+		switch {
+		case a.i < b.i:
+			okA = a.next()
+		case b.i < a.i:
+			okB = b.next()
+		default:
+			sum += a.v * b.v
+			okA, okB = a.next(), b.next()
+		}
+	}
+	return sum, nil
+}
+
+// Returns the dot product of vector v and dense vector x.
+//
+// On error returns (0, e) where e is:
+//    ErrorDim  -- invalid arg x : dimension differs
+func (v *Vector) DotDense(x []float64) (float64, error) {
+	if len(x) != v.dim {
+		return 0, ErrorDim
+	}
+	return v.dotDense(x), nil
+}
+
+func (es *entries) dotDense(x []float64) float64 {
+	var sum float64
+	it := es.iterator()
+	for it.next() {
+		sum += it.v * x[it.i]
+	}
+	return sum
+}
@@ -0,0 +1,169 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package sparse_test
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"unum"
+	"unum/sparse"
+)
+
+// dense vector of dimension n with about 1% non-zero values
+func testDense(r *rand.Rand, n int) []float64 {
+	x := make([]float64, n)
+	for i := range x {
+		if r.Intn(100) == 0 {
+			x[i] = float64(r.Intn(2000)-1000) / 8
+		}
+	}
+	return x
+}
+
+func TestVectorDense(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	x := testDense(r, 10000)
+	for _, tc := range []struct {
+		vt    sparse.ValueType
+		scale float64
+	}{
+		{sparse.Float64, 0}, {sparse.Float32, 0}, {sparse.Int, 0.125},
+	} {
+		v, e := sparse.FromDense(x, tc.vt, tc.scale)
+		if e != nil {
+			t.Fatalf("error encoding - e:%s\n", e.Error())
+		}
+		b, _ := v.MarshalBinary()
+		v0, e := sparse.Load(b)
+		if e != nil {
+			t.Fatalf("error loading - e:%s\n", e.Error())
+		}
+		if v0.Dim() != len(x) || v0.NNZ() != v.NNZ() || v0.ValueType() != tc.vt {
+			t.Errorf("BUG - dim:%d nnz:%d\n", v0.Dim(), v0.NNZ())
+		}
+		// values are multiples of 1/8, exact in all value types
+		if !reflect.DeepEqual(v0.Dense(nil), x) {
+			t.Errorf("BUG - type:%d dense values differ\n", tc.vt)
+		}
+		if tc.vt == sparse.Int && len(b) > 4*v.NNZ()+16 {
+			t.Errorf("vector not compact - nnz:%d len:%d\n", v.NNZ(), len(b))
+		}
+	}
+}
+
+func TestQuantize(t *testing.T) {
+	v, _ := sparse.FromDense([]float64{0, 0.26, -1.01, 0, 3}, sparse.Int, 0.5)
+	if d := v.Dense(nil); !reflect.DeepEqual(d, []float64{0, 0.5, -1, 0, 3}) {
+		t.Errorf("BUG - dense:%v\n", d)
+	}
+	if _, e := sparse.FromDense([]float64{1e30}, sparse.Int, 1); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	for _, scale := range []float64{0, -1, math.NaN(), math.Inf(1)} {
+		if _, e := sparse.FromDense(nil, sparse.Int, scale); e != sparse.ErrorValueType {
+			t.Errorf("expected ErrorValueType - scale:%g have:%v\n", scale, e)
+		}
+	}
+	if _, e := sparse.FromDense(nil, sparse.ValueType(0), 0); e != sparse.ErrorValueType {
+		t.Errorf("expected ErrorValueType - have:%v\n", e)
+	}
+}
+
+func TestFromEntries(t *testing.T) {
+	v, e := sparse.FromEntries(10, []int{0, 3, 9}, []float64{1, 2, 3}, sparse.Float64, 0)
+	if e != nil {
+		t.Fatalf("error encoding - e:%s\n", e.Error())
+	}
+	var is []int
+	var vs []float64
+	v.Range(func(i int, x float64) bool {
+		is, vs = append(is, i), append(vs, x)
+		return true
+	})
+	if !reflect.DeepEqual(is, []int{0, 3, 9}) || !reflect.DeepEqual(vs, []float64{1, 2, 3}) {
+		t.Errorf("BUG - indices:%v values:%v\n", is, vs)
+	}
+	for _, indices := range [][]int{{0, 3, 3}, {3, 2, 4}, {0, 3, 10}, {-1, 0, 1}, {0, 1}} {
+		if _, e := sparse.FromEntries(10, indices, []float64{1, 2, 3}, sparse.Float64, 0); e != sparse.ErrorDim {
+			t.Errorf("expected ErrorDim - indices:%v have:%v\n", indices, e)
+		}
+	}
+}
+
+func TestDot(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	x, y := testDense(r, 5000), testDense(r, 5000)
+	for i := 0; i < 5000; i += 7 {
+		// overlapping non-zeros
+		y[i] = x[i]
+	}
+	var expected float64
+	for i := range x {
+		expected += x[i] * y[i]
+	}
+	vx, _ := sparse.FromDense(x, sparse.Float64, 0)
+	vy, _ := sparse.FromDense(y, sparse.Int, 0.125)
+	if d, e := vx.Dot(vy); e != nil || d != expected {
+		t.Errorf("BUG - Dot - expected:%g have:%g e:%v\n", expected, d, e)
+	}
+	if d, e := vx.DotDense(y); e != nil || d != expected {
+		t.Errorf("BUG - DotDense - expected:%g have:%g e:%v\n", expected, d, e)
+	}
+	short, _ := sparse.FromDense(x[:10], sparse.Float64, 0)
+	if _, e := vx.Dot(short); e != sparse.ErrorDim {
+		t.Errorf("expected ErrorDim - have:%v\n", e)
+	}
+	if _, e := vx.DotDense(x[:10]); e != sparse.ErrorDim {
+		t.Errorf("expected ErrorDim - have:%v\n", e)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	v, _ := sparse.FromEntries(100, []int{1, 50, 99}, []float64{1, 2, 3}, sparse.Float32, 0)
+	b, _ := v.MarshalBinary()
+	for _, p := range [][]byte{
+		b[:len(b)-1],
+		append(append([]byte(nil), b...), 0),
+		{},
+		{10, 9, 0},                             // bad type
+		{10, 3, 0, 0, 0, 0, 0, 0, 0, 0, 0},     // zero scale
+		{10, 2, 1, 10, 0, 0, 0, 0, 0, 0, 0, 0}, // index out of range
+	} {
+		if _, e := sparse.Load(p); e != sparse.ErrorCorrupt {
+			t.Errorf("expected ErrorCorrupt - b:%x have:%v\n", p, e)
+		}
+	}
+}
+
+func BenchmarkDot(b *testing.B) {
+	r := rand.New(rand.NewSource(3))
+	vx, _ := sparse.FromDense(testDense(r, 100000), sparse.Float32, 0)
+	vy, _ := sparse.FromDense(testDense(r, 100000), sparse.Float32, 0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		vx.Dot(vy)
+	}
+}
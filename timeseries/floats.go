@@ -0,0 +1,173 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package timeseries
+
+import (
+	"math"
+	"math/bits"
+	"unum"
+)
+
+// FloatEncoder encodes a block of float64 values by XOR with the previous
+// value (the first value is XORed with 0).
+//
+// Slowly changing series share sign, exponent and high mantissa bits with
+// their predecessor, so the XOR has leading (and often trailing) zero bytes.
+// Each value is encoded as a UNUM-16 control, followed by the meaningful
+// bytes of the XOR, most significant first:
+//
+//      control     UNUM-16     0 if value is identical to previous, else
+//                              1 + (leading zero bytes << 3 | (meaningful bytes - 1))
+//      payload     [n]byte     meaningful bytes of XOR
+//
+// The block is:
+//
+//      count       UNUM-64     number of values
+//      values      ...
+//
+// Values are compared by their bit patterns, so NaN payloads and signed
+// zeros decode exactly.
+type FloatEncoder struct {
+	payload []byte
+	count   int
+	prev    uint64
+}
+
+// Returns a new FloatEncoder.
+func NewFloatEncoder() *FloatEncoder {
+	return &FloatEncoder{}
+}
+
+// Appends value f.
+func (enc *FloatEncoder) Append(f float64) {
+	v := math.Float64bits(f)
+	x := v ^ enc.prev
+	enc.prev = v
+	enc.count++
+	if x == 0 {
+		enc.payload = append(enc.payload, 0)
+		return
+	}
+	lzb := bits.LeadingZeros64(x) >> 3
+	tzb := bits.TrailingZeros64(x) >> 3
+	mb := 8 - lzb - tzb
+	// controls are < 2^7 and encode in one byte
+	enc.payload, _ = unum.AppendUnum16(enc.payload, uint16(1+(lzb<<3|(mb-1))))
+	for i := mb - 1; i >= 0; i-- {
+		enc.payload = append(enc.payload, byte(x>>uint((tzb+i)<<3)))
+	}
+}
+
+// Returns the number of values appended.
+func (enc *FloatEncoder) Len() int {
+	return enc.count
+}
+
+// Returns the encoded block.
+func (enc *FloatEncoder) Bytes() []byte {
+	var h [unum.Unum64Size]byte
+	n, _ := unum.EncodeUnum64(h[:], uint64(enc.count))
+	b := make([]byte, n+len(enc.payload))
+	copy(b, h[:n])
+	copy(b[n:], enc.payload)
+	return b
+}
+
+// Resets the encoder to begin a new block.
+func (enc *FloatEncoder) Reset() {
+	enc.payload = enc.payload[:0]
+	enc.count, enc.prev = 0, 0
+}
+
+// FloatDecoder decodes a block of values produced by FloatEncoder.
+type FloatDecoder struct {
+	count int
+	b     []byte
+	i     int
+	prev  uint64
+}
+
+// Returns a new FloatDecoder for block b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock  -- invalid block header
+func NewFloatDecoder(b []byte) (*FloatDecoder, error) {
+	count, n, e := unum.DecodeUnum64(b)
+	if e != nil || count > uint64(len(b)) {
+		return nil, ErrorBlock
+	}
+	return &FloatDecoder{count: int(count), b: b[n:]}, nil
+}
+
+// Returns the number of values in the block.
+func (d *FloatDecoder) Len() int {
+	return d.count
+}
+
+// Returns the next value.
+//
+// On error returns (0, e) where e is:
+//    unum.ErrorBufferEOF      -- end of block
+//    unum.ErrorInvalidBuffer  -- non-conformant block
+func (d *FloatDecoder) Next() (float64, error) {
+	if d.i == d.count {
+		return 0, unum.ErrorBufferEOF
+	}
+	c, n, e := unum.DecodeUnum16(d.b)
+	if e != nil || c > 64 {
+		return 0, unum.ErrorInvalidBuffer
+	}
+	if c > 0 {
+		c--
+		lzb, mb := int(c>>3), int(c&7)+1
+		if lzb+mb > 8 || len(d.b) < n+mb {
+			return 0, unum.ErrorInvalidBuffer
+		}
+		var x uint64
+		for _, b := range d.b[n : n+mb] {
+			x = x<<8 | uint64(b)
+		}
+		d.prev ^= x << uint((8-lzb-mb)<<3)
+		n += mb
+	}
+	d.b = d.b[n:]
+	d.i++
+	return math.Float64frombits(d.prev), nil
+}
+
+// Returns the values of block b, appended to dst.
+func DecodeFloats(dst []float64, b []byte) ([]float64, error) {
+	d, e := NewFloatDecoder(b)
+	if e != nil {
+		return dst, e
+	}
+	for i := 0; i < d.count; i++ {
+		f, e := d.Next()
+		if e != nil {
+			return dst, e
+		}
+		dst = append(dst, f)
+	}
+	return dst, nil
+}
@@ -0,0 +1,118 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package timeseries_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"unum"
+	"unum/timeseries"
+)
+
+func testFloats(n int) []float64 {
+	r := rand.New(rand.NewSource(int64(n)))
+	fs := make([]float64, n)
+	f := 20.0
+	for i := range fs {
+		switch r.Intn(4) {
+		case 0:
+			f += 0.5
+		case 1:
+			f = math.Floor(f + r.NormFloat64())
+		case 2:
+			f = r.Float64() * 100
+		}
+		fs[i] = f
+	}
+	return fs
+}
+
+func TestFloatCodec(t *testing.T) {
+	special := []float64{
+		0, math.Copysign(0, -1), 0, math.NaN(),
+		math.Float64frombits(0x7ff8000000000001), math.Float64frombits(0xfff0000000000abc),
+		math.Inf(1), math.Inf(-1), math.MaxFloat64, math.SmallestNonzeroFloat64, -1,
+	}
+	for _, fs := range [][]float64{nil, special, testFloats(1000)} {
+		enc := timeseries.NewFloatEncoder()
+		for _, f := range fs {
+			enc.Append(f)
+		}
+		fs0, e := timeseries.DecodeFloats(nil, enc.Bytes())
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		if len(fs0) != len(fs) {
+			t.Fatalf("expected %d values - have:%d\n", len(fs), len(fs0))
+		}
+		for i := range fs {
+			if math.Float64bits(fs[i]) != math.Float64bits(fs0[i]) {
+				t.Errorf("BUG - index:%d f:%x f0:%x\n", i, math.Float64bits(fs[i]), math.Float64bits(fs0[i]))
+			}
+		}
+	}
+}
+
+func TestFloatSize(t *testing.T) {
+	enc := timeseries.NewFloatEncoder()
+	for i := 0; i < 1000; i++ {
+		enc.Append(42.5)
+	}
+	if n := len(enc.Bytes()); n > 1000+2+8 {
+		t.Errorf("constant series not compact - len:%d\n", n)
+	}
+}
+
+func TestFloatErrors(t *testing.T) {
+	enc := timeseries.NewFloatEncoder()
+	for _, f := range testFloats(100) {
+		enc.Append(f)
+	}
+	block := enc.Bytes()
+	if _, e := timeseries.DecodeFloats(nil, block[:len(block)-1]); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+	// control 1 + (7<<3 | 1) : 7 leading zero bytes with 2 meaningful bytes
+	if _, e := timeseries.DecodeFloats(nil, []byte{1, 58, 0, 0}); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+	if _, e := timeseries.NewFloatDecoder([]byte{100}); e != timeseries.ErrorBlock {
+		t.Errorf("expected ErrorBlock - have:%v\n", e)
+	}
+}
+
+func BenchmarkFloatEncoder(b *testing.B) {
+	fs := testFloats(4096)
+	enc := timeseries.NewFloatEncoder()
+	var size int
+	for i := 0; i < b.N; i++ {
+		enc.Reset()
+		for _, f := range fs {
+			enc.Append(f)
+		}
+		size = len(enc.Bytes())
+	}
+	b.ReportMetric(float64(size)/float64(len(fs)), "bytes/value")
+}
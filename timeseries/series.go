@@ -0,0 +1,143 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package timeseries
+
+import (
+	"time"
+	"unum"
+)
+
+// Series encodes a block of (timestamp, value) points, pairing a
+// TimeEncoder for timestamps with a FloatEncoder for values.
+//
+// The block is:
+//
+//      tlen        UNUM-64     length of timestamp block
+//      times       [tlen]byte  timestamp block (see TimeEncoder)
+//      values      ...         value block (see FloatEncoder)
+type Series struct {
+	times  *TimeEncoder
+	values *FloatEncoder
+}
+
+// Returns a new Series with timestamps at the given resolution.
+//
+// On error returns (nil, e) where e is:
+//    ErrorResolution   -- invalid arg resolution : not positive, or >= 2^62ns
+func NewSeries(resolution time.Duration) (*Series, error) {
+	times, e := NewTimeEncoder(resolution)
+	if e != nil {
+		return nil, e
+	}
+	return &Series{times: times, values: NewFloatEncoder()}, nil
+}
+
+// Appends point (t, v). The series is unchanged on error.
+//
+// On error returns e:
+//    unum.ErrorMaxValue  -- invalid arg t : delta (of delta) out of range
+func (s *Series) Append(t time.Time, v float64) error {
+	if e := s.times.Append(t); e != nil {
+		return e
+	}
+	s.values.Append(v)
+	return nil
+}
+
+// Returns the number of points appended.
+func (s *Series) Len() int {
+	return s.times.Len()
+}
+
+// Returns the encoded block.
+func (s *Series) Bytes() []byte {
+	tb := s.times.Bytes()
+	vb := s.values.Bytes()
+	b := make([]byte, 0, unum.Unum64Size+len(tb)+len(vb))
+	b, _ = unum.AppendUnum64(b, uint64(len(tb)))
+	b = append(b, tb...)
+	return append(b, vb...)
+}
+
+// Resets the series to begin a new block, retaining the resolution.
+func (s *Series) Reset() {
+	s.times.Reset()
+	s.values.Reset()
+}
+
+// SeriesDecoder decodes a block of points produced by Series.
+type SeriesDecoder struct {
+	times  *TimeDecoder
+	values *FloatDecoder
+}
+
+// Returns a new SeriesDecoder for block b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock  -- invalid block header
+func NewSeriesDecoder(b []byte) (*SeriesDecoder, error) {
+	tlen, n, e := unum.DecodeUnum64(b)
+	if e != nil || tlen > uint64(len(b)-n) {
+		return nil, ErrorBlock
+	}
+	times, e := NewTimeDecoder(b[n : n+int(tlen)])
+	if e != nil {
+		return nil, e
+	}
+	values, e := NewFloatDecoder(b[n+int(tlen):])
+	if e != nil {
+		return nil, e
+	}
+	if times.Len() != values.Len() {
+		return nil, ErrorBlock
+	}
+	return &SeriesDecoder{times: times, values: values}, nil
+}
+
+// Returns the resolution of the timestamps.
+func (d *SeriesDecoder) Resolution() time.Duration {
+	return d.times.Resolution()
+}
+
+// Returns the number of points in the block.
+func (d *SeriesDecoder) Len() int {
+	return d.times.Len()
+}
+
+// Returns the next point, with the timestamp in UTC.
+//
+// On error returns (time.Time{}, 0, e) where e is:
+//    unum.ErrorBufferEOF      -- end of block
+//    unum.ErrorInvalidBuffer  -- non-conformant block
+func (d *SeriesDecoder) Next() (time.Time, float64, error) {
+	t, e := d.times.Next()
+	if e != nil {
+		return time.Time{}, 0, e
+	}
+	v, e := d.values.Next()
+	if e != nil {
+		return time.Time{}, 0, e
+	}
+	return t, v, nil
+}
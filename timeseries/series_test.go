@@ -0,0 +1,88 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package timeseries_test
+
+import (
+	"math"
+	"testing"
+	"time"
+	"unum"
+	"unum/timeseries"
+)
+
+func TestSeries(t *testing.T) {
+	ts := testTimes(500, time.Minute)
+	fs := testFloats(500)
+	s, e := timeseries.NewSeries(time.Second)
+	if e != nil {
+		t.Fatalf("error creating series - e:%s\n", e.Error())
+	}
+	for i := range ts {
+		if e := s.Append(ts[i], fs[i]); e != nil {
+			t.Fatalf("error appending - e:%s\n", e.Error())
+		}
+	}
+	if s.Len() != len(ts) {
+		t.Errorf("expected len %d - have:%d\n", len(ts), s.Len())
+	}
+	block := s.Bytes()
+	d, e := timeseries.NewSeriesDecoder(block)
+	if e != nil {
+		t.Fatalf("error creating decoder - e:%s\n", e.Error())
+	}
+	if d.Len() != len(ts) || d.Resolution() != time.Second {
+		t.Errorf("unexpected header - len:%d res:%s\n", d.Len(), d.Resolution())
+	}
+	for i := range ts {
+		t0, f0, e := d.Next()
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		if !t0.Equal(ts[i]) || math.Float64bits(f0) != math.Float64bits(fs[i]) {
+			t.Errorf("BUG - index:%d t:%s t0:%s f:%g f0:%g\n", i, ts[i], t0, fs[i], f0)
+		}
+	}
+	if _, _, e := d.Next(); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+	if n := len(block); n >= 16*len(ts) {
+		t.Errorf("series not compact - len:%d\n", n)
+	}
+
+	// mismatched time and value counts
+	tenc, _ := timeseries.NewTimeEncoder(time.Second)
+	tenc.Append(ts[0])
+	tenc.Append(ts[1])
+	fenc := timeseries.NewFloatEncoder()
+	fenc.Append(fs[0])
+	tb := tenc.Bytes()
+	block = append([]byte{byte(len(tb))}, tb...)
+	block = append(block, fenc.Bytes()...)
+	if _, e := timeseries.NewSeriesDecoder(block); e != timeseries.ErrorBlock {
+		t.Errorf("expected ErrorBlock - have:%v\n", e)
+	}
+	if _, e := timeseries.NewSeries(-time.Second); e != timeseries.ErrorResolution {
+		t.Errorf("expected ErrorResolution - have:%v\n", e)
+	}
+}
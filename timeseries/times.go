@@ -0,0 +1,272 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package timeseries implements compact encodings of time series columns.
+//
+// Timestamps of regular interval series are encoded as delta-of-deltas:
+// the first time, the first delta, and then the difference of each delta
+// from the previous delta. For regular series the delta-of-deltas are 0 or
+// near 0, and each encodes in a single byte.
+//
+// Values are float64 encoded by XOR with the previous value, and Series
+// pairs the two into a block of (timestamp, value) points.
+package timeseries
+
+import (
+	"fmt"
+	"time"
+	"unum"
+)
+
+// Errors
+var (
+	ErrorResolution = fmt.Errorf("timeseries.ErrorResolution")
+	ErrorBlock      = fmt.Errorf("timeseries.ErrorBlock")
+)
+
+// TimeEncoder encodes a block of timestamps at a fixed resolution.
+//
+// Timestamps are mapped to ticks, the number of resolution units since the
+// Unix epoch (rounded down, also before the epoch), and the block is:
+//
+//      header:
+//      resolution  UNUM-64         nanoseconds per tick
+//      count       UNUM-64         number of timestamps
+//
+//      payload:
+//      t0          zigzag UNUM-64  ticks of first timestamp
+//      d0          zigzag UNUM-64  t1 - t0
+//      dod         zigzag UNUM-64  (t[i] - t[i-1]) - (t[i-1] - t[i-2]), for i >= 2
+//
+// Timestamps need not be ordered, but the encoding is most compact for
+// series with regular intervals.
+type TimeEncoder struct {
+	res     time.Duration
+	payload []byte
+	count   int
+	prev    int64
+	delta   int64
+}
+
+// Returns a new TimeEncoder at the given resolution (e.g. time.Second).
+//
+// On error returns (nil, e) where e is:
+//    ErrorResolution   -- invalid arg resolution : not positive, or >= 2^62ns
+func NewTimeEncoder(resolution time.Duration) (*TimeEncoder, error) {
+	if resolution <= 0 || uint64(resolution) >= unum.Unum64ValueBound {
+		return nil, ErrorResolution
+	}
+	return &TimeEncoder{res: resolution}, nil
+}
+
+// Returns the ticks of t at resolution res, rounded down.
+func ticks(t time.Time, res time.Duration) int64 {
+	// avoid overflow of UnixNano for coarse resolutions far from the epoch
+	if res%time.Second == 0 {
+		return floorDiv(t.Unix(), int64(res/time.Second))
+	}
+	return floorDiv(t.UnixNano(), int64(res))
+}
+
+// Returns a / b rounded down, for b > 0.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b < 0 {
+		q--
+	}
+	return q
+}
+
+// Returns the time of ticks v at resolution res.
+func unticks(v int64, res time.Duration) time.Time {
+	if res%time.Second == 0 {
+		return time.Unix(v*int64(res/time.Second), 0).UTC()
+	}
+	ns := v * int64(res)
+	return time.Unix(ns/1e9, ns%1e9).UTC()
+}
+
+// Returns the ticks of duration d at resolution res, truncated towards zero.
+func DurationTicks(d, res time.Duration) int64 {
+	return int64(d / res)
+}
+
+// Returns the duration of ticks v at resolution res.
+func TicksDuration(v int64, res time.Duration) time.Duration {
+	return time.Duration(v) * res
+}
+
+// Appends timestamp t, rounded down to the resolution.
+// The encoder state is unchanged on error.
+//
+// On error returns e:
+//    unum.ErrorMaxValue  -- invalid arg t : delta (of delta) out of range
+func (enc *TimeEncoder) Append(t time.Time) error {
+	return enc.AppendTicks(ticks(t, enc.res))
+}
+
+// Appends a timestamp given in ticks. See Append.
+func (enc *TimeEncoder) AppendTicks(v int64) error {
+	var b [2 * unum.Unum64Size]byte
+	var n int
+	var e error
+	switch enc.count {
+	case 0:
+		n, e = unum.EncodeInt64(b[:], v)
+	default:
+		delta := v - enc.prev
+		if (delta > 0) != (v > enc.prev) {
+			return unum.ErrorMaxValue
+		}
+		dod := delta
+		if enc.count > 1 {
+			dod = delta - enc.delta
+			if (dod > 0) != (delta > enc.delta) {
+				return unum.ErrorMaxValue
+			}
+		}
+		if n, e = unum.EncodeInt64(b[:], dod); e == nil {
+			enc.delta = delta
+		}
+	}
+	if e != nil {
+		return e
+	}
+	enc.payload = append(enc.payload, b[:n]...)
+	enc.prev = v
+	enc.count++
+	return nil
+}
+
+// Returns the number of timestamps appended.
+func (enc *TimeEncoder) Len() int {
+	return enc.count
+}
+
+// Returns the encoded block.
+func (enc *TimeEncoder) Bytes() []byte {
+	var h [2 * unum.Unum64Size]byte
+	n, _ := unum.EncodeUnum64(h[:], uint64(enc.res))
+	n0, _ := unum.EncodeUnum64(h[n:], uint64(enc.count))
+	n += n0
+	b := make([]byte, n+len(enc.payload))
+	copy(b, h[:n])
+	copy(b[n:], enc.payload)
+	return b
+}
+
+// Resets the encoder to begin a new block, retaining the resolution.
+func (enc *TimeEncoder) Reset() {
+	enc.payload = enc.payload[:0]
+	enc.count, enc.prev, enc.delta = 0, 0, 0
+}
+
+// TimeDecoder decodes a block of timestamps produced by TimeEncoder.
+type TimeDecoder struct {
+	res   time.Duration
+	count int
+	b     []byte
+	i     int
+	prev  int64
+	delta int64
+}
+
+// Returns a new TimeDecoder for block b.
+//
+// On error returns (nil, e) where e is:
+//    ErrorBlock  -- invalid block header
+func NewTimeDecoder(b []byte) (*TimeDecoder, error) {
+	res, n, e := unum.DecodeUnum64(b)
+	if e != nil || res == 0 || res > uint64(1<<63-1) {
+		return nil, ErrorBlock
+	}
+	count, n0, e := unum.DecodeUnum64(b[n:])
+	if e != nil || count > uint64(len(b)) {
+		return nil, ErrorBlock
+	}
+	n += n0
+	return &TimeDecoder{res: time.Duration(res), count: int(count), b: b[n:]}, nil
+}
+
+// Returns the resolution of the block.
+func (d *TimeDecoder) Resolution() time.Duration {
+	return d.res
+}
+
+// Returns the number of timestamps in the block.
+func (d *TimeDecoder) Len() int {
+	return d.count
+}
+
+// Returns the next timestamp, in UTC.
+//
+// On error returns (time.Time{}, e) where e is:
+//    unum.ErrorBufferEOF      -- end of block
+//    unum.ErrorInvalidBuffer  -- non-conformant block
+func (d *TimeDecoder) Next() (time.Time, error) {
+	v, e := d.NextTicks()
+	if e != nil {
+		return time.Time{}, e
+	}
+	return unticks(v, d.res), nil
+}
+
+// Returns the next timestamp in ticks. See Next.
+func (d *TimeDecoder) NextTicks() (int64, error) {
+	if d.i == d.count {
+		return 0, unum.ErrorBufferEOF
+	}
+	x, n, e := unum.DecodeInt64(d.b)
+	if e != nil {
+		return 0, unum.ErrorInvalidBuffer
+	}
+	d.b = d.b[n:]
+	switch d.i {
+	case 0:
+		d.prev = x
+	case 1:
+		d.delta = x
+		d.prev += d.delta
+	default:
+		d.delta += x
+		d.prev += d.delta
+	}
+	d.i++
+	return d.prev, nil
+}
+
+// Returns the timestamps of block b, appended to dst.
+func DecodeTimes(dst []time.Time, b []byte) ([]time.Time, error) {
+	d, e := NewTimeDecoder(b)
+	if e != nil {
+		return dst, e
+	}
+	for i := 0; i < d.count; i++ {
+		t, e := d.Next()
+		if e != nil {
+			return dst, e
+		}
+		dst = append(dst, t)
+	}
+	return dst, nil
+}
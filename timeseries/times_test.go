@@ -0,0 +1,219 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package timeseries_test
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+	"time"
+	"unum"
+	"unum/timeseries"
+)
+
+// regular interval series with occasional jitter and gaps
+func testTimes(n int, interval time.Duration) []time.Time {
+	r := rand.New(rand.NewSource(int64(n)))
+	ts := make([]time.Time, n)
+	t := time.Date(2016, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := range ts {
+		ts[i] = t
+		t = t.Add(interval)
+		switch r.Intn(20) {
+		case 0:
+			t = t.Add(time.Duration(r.Intn(3)-1) * time.Second)
+		case 1:
+			t = t.Add(time.Duration(r.Intn(10)) * interval)
+		}
+	}
+	return ts
+}
+
+func TestTimeCodec(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 3, 1000} {
+		ts := testTimes(n, 10*time.Second)
+		enc, e := timeseries.NewTimeEncoder(time.Second)
+		if e != nil {
+			t.Fatalf("error creating encoder - e:%s\n", e.Error())
+		}
+		for _, ti := range ts {
+			if e := enc.Append(ti); e != nil {
+				t.Fatalf("error appending - e:%s\n", e.Error())
+			}
+		}
+		block := enc.Bytes()
+		ts0, e := timeseries.DecodeTimes(nil, block)
+		if e != nil {
+			t.Fatalf("error decoding - e:%s\n", e.Error())
+		}
+		if len(ts0) != n {
+			t.Fatalf("expected %d timestamps - have:%d\n", n, len(ts0))
+		}
+		for i := range ts {
+			if !ts[i].Equal(ts0[i]) {
+				t.Errorf("BUG - index:%d t:%s t0:%s\n", i, ts[i], ts0[i])
+			}
+		}
+		if n == 1000 && len(block) > 2*n {
+			t.Errorf("block not compact - n:%d len:%d\n", n, len(block))
+		}
+	}
+}
+
+func TestTimeResolution(t *testing.T) {
+	ts := []time.Time{
+		time.Date(2016, 3, 1, 0, 0, 0, 123456789, time.UTC),
+		time.Date(2016, 3, 1, 0, 0, 1, 623456789, time.UTC),
+		time.Date(1969, 12, 31, 23, 59, 59, 500000000, time.UTC),
+	}
+	for _, res := range []time.Duration{time.Nanosecond, time.Microsecond, time.Millisecond, time.Second, time.Minute} {
+		enc, _ := timeseries.NewTimeEncoder(res)
+		for _, ti := range ts {
+			enc.Append(ti)
+		}
+		d, e := timeseries.NewTimeDecoder(enc.Bytes())
+		if e != nil {
+			t.Fatalf("error creating decoder - e:%s\n", e.Error())
+		}
+		if d.Resolution() != res || d.Len() != len(ts) {
+			t.Errorf("unexpected header - res:%s len:%d\n", d.Resolution(), d.Len())
+		}
+		for _, ti := range ts {
+			t0, e := d.Next()
+			if e != nil {
+				t.Fatalf("error decoding - e:%s\n", e.Error())
+			}
+			if diff := ti.Sub(t0); diff < 0 || diff >= res {
+				t.Errorf("res:%s - expected:%s have:%s\n", res, ti, t0)
+			}
+		}
+		if _, e := d.Next(); e != unum.ErrorBufferEOF {
+			t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+		}
+	}
+}
+
+func TestDurationTicks(t *testing.T) {
+	if v := timeseries.DurationTicks(1500*time.Millisecond, time.Second); v != 1 {
+		t.Errorf("expected 1 - have:%d\n", v)
+	}
+	if v := timeseries.DurationTicks(-90*time.Second, time.Minute); v != -1 {
+		t.Errorf("expected -1 - have:%d\n", v)
+	}
+	if d := timeseries.TicksDuration(15, time.Second); d != 15*time.Second {
+		t.Errorf("expected 15s - have:%s\n", d)
+	}
+}
+
+func TestTimeErrors(t *testing.T) {
+	for _, res := range []time.Duration{0, -time.Second, 1 << 62} {
+		if _, e := timeseries.NewTimeEncoder(res); e != timeseries.ErrorResolution {
+			t.Errorf("expected ErrorResolution - res:%d have:%v\n", res, e)
+		}
+	}
+	enc, _ := timeseries.NewTimeEncoder(time.Nanosecond)
+	enc.AppendTicks(-unum.Int64ValueBound)
+	if e := enc.AppendTicks(unum.Int64ValueBound - 1); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+	if enc.Len() != 1 {
+		t.Errorf("expected unchanged encoder - len:%d\n", enc.Len())
+	}
+
+	ts := testTimes(100, time.Second)
+	enc, _ = timeseries.NewTimeEncoder(time.Second)
+	for _, ti := range ts {
+		enc.Append(ti)
+	}
+	block := enc.Bytes()
+	if _, e := timeseries.DecodeTimes(nil, block[:len(block)-1]); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+	if _, e := timeseries.NewTimeDecoder([]byte{0}); e != timeseries.ErrorBlock {
+		t.Errorf("expected ErrorBlock - have:%v\n", e)
+	}
+}
+
+// compares block sizes and speed with plain (signed) delta encoding
+func benchTimes() ([]time.Time, []uint64) {
+	ts := testTimes(4096, 15*time.Second)
+	vs := make([]uint64, len(ts))
+	for i, ti := range ts {
+		vs[i] = uint64(ti.Unix())
+	}
+	return ts, vs
+}
+
+func BenchmarkTimeEncoder(b *testing.B) {
+	ts, _ := benchTimes()
+	enc, _ := timeseries.NewTimeEncoder(time.Second)
+	var size int
+	for i := 0; i < b.N; i++ {
+		enc.Reset()
+		for _, t := range ts {
+			enc.Append(t)
+		}
+		size = len(enc.Bytes())
+	}
+	b.ReportMetric(float64(size)/float64(len(ts)), "bytes/value")
+}
+
+func BenchmarkDeltaEncoder(b *testing.B) {
+	ts, _ := benchTimes()
+	var buf bytes.Buffer
+	enc := unum.NewSignedDeltaEncoder(&buf)
+	for i := 0; i < b.N; i++ {
+		buf.Reset()
+		enc.Reset()
+		for _, t := range ts {
+			enc.Encode(uint64(t.Unix()))
+		}
+	}
+	b.ReportMetric(float64(buf.Len())/float64(len(ts)), "bytes/value")
+}
+
+func BenchmarkTimeDecoder(b *testing.B) {
+	ts, _ := benchTimes()
+	enc, _ := timeseries.NewTimeEncoder(time.Second)
+	for _, t := range ts {
+		enc.Append(t)
+	}
+	block := enc.Bytes()
+	dst := make([]time.Time, 0, len(ts))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		timeseries.DecodeTimes(dst[:0], block)
+	}
+}
+
+func BenchmarkDeltaDecoder(b *testing.B) {
+	_, vs := benchTimes()
+	buf := make([]byte, len(vs)*unum.Unum64Size)
+	n, _ := unum.EncodeSignedDelta(buf, vs)
+	dst := make([]uint64, len(vs))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		unum.DecodeSignedDelta(buf[:n], dst)
+	}
+}
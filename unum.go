@@ -34,10 +34,18 @@
 // Given that unlike Gustafson's we're not discussing hardware here, and we use
 // byte buffers, this scheme is a good match for shuffling sequence of numbers in I/O.
 //
-// This implementation (as of now) only supports unsigned integer values.
+// This implementation (as of now) only supports integer values. Signed values
+// are zigzag mapped to unsigned values (see signed.go) and then encoded per
+// the unsigned schemes below.
 //
 // Note that the scheme is uniformly byte-aligned, not word-aligned.
 //
+// UNUM-64 is bit for bit the variable-length integer encoding of QUIC (RFC
+// 9000, section 16): the same 2-bit tag, 1/2/4/8 byte lengths and 2^62
+// bound. EncodeUnum64 produces the minimal QUIC image of a value, and
+// DecodeUnum64 decodes any QUIC image, including non-minimal ones (e.g.
+// 0x4025 is 37). See package quic.
+//
 //      -- bit layout for UNUM-64 and UNUM-32 unsigned values
 //
 //      0          2                            *
@@ -105,10 +113,12 @@ const (
 var (
 	ErrorBufferOverflow = fmt.Errorf("unum.ErrorBufferOverflow")
 	ErrorMaxValue       = fmt.Errorf("unum.ErrorMaxValue")
+	ErrorDeltaOrder     = fmt.Errorf("unum.ErrorDeltaOrder")
 )
 
 // Decode/Read errors
 var (
 	ErrorBufferEOF     = fmt.Errorf("unum.ErrorBufferEOF")
 	ErrorInvalidBuffer = fmt.Errorf("unum.ErrorInvalidBuffer")
+	ErrorDeltaOverflow = fmt.Errorf("unum.ErrorDeltaOverflow")
 )
@@ -55,6 +55,32 @@ func EncodeUnum16(b []byte, v uint16) (n int, e error) {
 	panic("bug - asserted unreachable")
 }
 
+// Returns the UNUM-16 encoded size of value v { 1, 2 },
+// or 0 if v is not encodable (i.e. v >= Unum16ValueBound).
+func SizeUnum16(v uint16) int {
+	switch {
+	case v < 0x80:
+		return 1
+	case v < 0x8000:
+		return 2
+	}
+	return 0
+}
+
+// Appends the UNUM-16 encoding of value v to dst.
+// returns the extended buffer on nil error.
+//
+// On error returns (dst, e) where e is:
+//    ErrorMaxValue        -- invalid arg v : v >= 2^15
+func AppendUnum16(dst []byte, v uint16) ([]byte, error) {
+	var b [Unum16Size]byte
+	n, e := EncodeUnum16(b[:], v)
+	if e != nil {
+		return dst, e
+	}
+	return append(dst, b[:n]...), nil
+}
+
 // Writes UNUM-16 encoded uint value 'v' to writer 'w'.
 // Returns number of bytes written 'n' (n > 0) if there are no errors.
 //
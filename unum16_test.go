@@ -106,3 +106,42 @@ func TestCodecUnum16(t *testing.T) {
 		t.Error(e)
 	}
 }
+
+func TestSizeUnum16(t *testing.T) {
+	f := func(v uint16) bool {
+		var b0 [unum.Unum16Size]byte
+		n, e := unum.EncodeUnum16(b0[:], v)
+		if e != nil {
+			n = 0
+		}
+		if size := unum.SizeUnum16(v); size != n {
+			t.Errorf("SizeUnum16 - v:%d - expected:%d have:%d\n", v, n, size)
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestAppendUnum16(t *testing.T) {
+	f := func(v uint16) bool {
+		var b0 [unum.Unum16Size]byte
+		n, e := unum.EncodeUnum16(b0[:], v)
+		prefix := []byte{0xff}
+		b, e0 := unum.AppendUnum16(prefix, v)
+		if e0 != e {
+			t.Errorf("AppendUnum16 - v:%d - expected error:%v have:%v\n", v, e, e0)
+		}
+		if e != nil {
+			n = 0
+		}
+		if len(b) != 1+n || b[0] != 0xff || string(b[1:]) != string(b0[:n]) {
+			t.Errorf("AppendUnum16 - v:%d - expected:% x have:% x\n", v, b0[:n], b[1:])
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
@@ -72,6 +72,36 @@ func EncodeUnum32(b []byte, v uint32) (n int, e error) {
 	panic("bug - asserted unreachable")
 }
 
+// Returns the UNUM-32 encoded size of value v { 1, 2, 3, 4 },
+// or 0 if v is not encodable (i.e. v >= Unum32ValueBound).
+func SizeUnum32(v uint32) int {
+	switch {
+	case v < 0x40:
+		return 1
+	case v < 0x4000:
+		return 2
+	case v < 0x400000:
+		return 3
+	case v < 0x40000000:
+		return 4
+	}
+	return 0
+}
+
+// Appends the UNUM-32 encoding of value v to dst.
+// returns the extended buffer on nil error.
+//
+// On error returns (dst, e) where e is:
+//    ErrorMaxValue        -- invalid arg v : v >= 2^30
+func AppendUnum32(dst []byte, v uint32) ([]byte, error) {
+	var b [Unum32Size]byte
+	n, e := EncodeUnum32(b[:], v)
+	if e != nil {
+		return dst, e
+	}
+	return append(dst, b[:n]...), nil
+}
+
 // Writes encoded uint value 'v' to writer 'w'.
 // Returns number of bytes written 'n' (n > 0) if there are no errors.
 //
@@ -153,7 +183,7 @@ func ReadUnum32(r io.Reader) (v uint32, n int, e error) {
 
 	// compute expected encoded len directly
 	// and read the remaining bytes (if any)
-	vlen := 1 << (b[0] >> 6)
+	vlen := int(b[0]>>6) + 1
 	if vlen > 1 {
 		n, e = io.ReadFull(r, b[1:vlen])
 		if e != nil {
@@ -24,6 +24,7 @@
 package unum_test
 
 import (
+	"bytes"
 	"math/rand"
 	"testing"
 	"testing/quick"
@@ -103,3 +104,61 @@ func TestCodecUnum32(t *testing.T) {
 		t.Error(e)
 	}
 }
+
+func TestSizeUnum32(t *testing.T) {
+	f := func(v uint32) bool {
+		var b0 [unum.Unum32Size]byte
+		n, e := unum.EncodeUnum32(b0[:], v)
+		if e != nil {
+			n = 0
+		}
+		if size := unum.SizeUnum32(v); size != n {
+			t.Errorf("SizeUnum32 - v:%d - expected:%d have:%d\n", v, n, size)
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestAppendUnum32(t *testing.T) {
+	f := func(v uint32) bool {
+		var b0 [unum.Unum32Size]byte
+		n, e := unum.EncodeUnum32(b0[:], v)
+		prefix := []byte{0xff}
+		b, e0 := unum.AppendUnum32(prefix, v)
+		if e0 != e {
+			t.Errorf("AppendUnum32 - v:%d - expected error:%v have:%v\n", v, e, e0)
+		}
+		if e != nil {
+			n = 0
+		}
+		if len(b) != 1+n || b[0] != 0xff || string(b[1:]) != string(b0[:n]) {
+			t.Errorf("AppendUnum32 - v:%d - expected:% x have:% x\n", v, b0[:n], b[1:])
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestReadWriteUnum32(t *testing.T) {
+	values := []uint32{0x3b, 0x3bab, 0x2a35c4, 0x2fe8d5bc, 0}
+	var buf bytes.Buffer
+	for _, v := range values {
+		if _, e := unum.WriteUnum32(&buf, v); e != nil {
+			t.Fatalf("error writing - v:%d - e:%s\n", v, e.Error())
+		}
+	}
+	for _, v := range values {
+		v0, _, e := unum.ReadUnum32(&buf)
+		if e != nil || v0 != v {
+			t.Errorf("ReadUnum32 - expected:%x have:%x e:%v\n", v, v0, e)
+		}
+	}
+	if _, _, e := unum.ReadUnum32(&buf); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+}
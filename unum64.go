@@ -77,6 +77,36 @@ func EncodeUnum64(b []byte, v uint64) (n int, e error) {
 	panic("bug - asserted unreachable")
 }
 
+// Returns the UNUM-64 encoded size of value v { 1, 2, 4, 8 },
+// or 0 if v is not encodable (i.e. v >= Unum64ValueBound).
+func SizeUnum64(v uint64) int {
+	switch {
+	case v < 0x40:
+		return 1
+	case v < 0x4000:
+		return 2
+	case v < 0x40000000:
+		return 4
+	case v < 0x4000000000000000:
+		return 8
+	}
+	return 0
+}
+
+// Appends the UNUM-64 encoding of value v to dst.
+// returns the extended buffer on nil error.
+//
+// On error returns (dst, e) where e is:
+//    ErrorMaxValue        -- invalid arg v : v >= 2^62
+func AppendUnum64(dst []byte, v uint64) ([]byte, error) {
+	var b [Unum64Size]byte
+	n, e := EncodeUnum64(b[:], v)
+	if e != nil {
+		return dst, e
+	}
+	return append(dst, b[:n]...), nil
+}
+
 // Writes encoded uint value 'v' to writer 'w'.
 // Returns number of bytes written 'n' (n > 0) if there are no errors.
 //
@@ -103,3 +103,78 @@ func TestCodecUnum64(t *testing.T) {
 		t.Error(e)
 	}
 }
+
+func TestSizeUnum64(t *testing.T) {
+	f := func(v uint64) bool {
+		var b0 [unum.Unum64Size]byte
+		n, e := unum.EncodeUnum64(b0[:], v)
+		if e != nil {
+			n = 0
+		}
+		if size := unum.SizeUnum64(v); size != n {
+			t.Errorf("SizeUnum64 - v:%d - expected:%d have:%d\n", v, n, size)
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+func TestAppendUnum64(t *testing.T) {
+	f := func(v uint64) bool {
+		var b0 [unum.Unum64Size]byte
+		n, e := unum.EncodeUnum64(b0[:], v)
+		prefix := []byte{0xff}
+		b, e0 := unum.AppendUnum64(prefix, v)
+		if e0 != e {
+			t.Errorf("AppendUnum64 - v:%d - expected error:%v have:%v\n", v, e, e0)
+		}
+		if e != nil {
+			n = 0
+		}
+		if len(b) != 1+n || b[0] != 0xff || string(b[1:]) != string(b0[:n]) {
+			t.Errorf("AppendUnum64 - v:%d - expected:% x have:% x\n", v, b0[:n], b[1:])
+		}
+		return true
+	}
+	if e := quick.Check(f, nil); e != nil {
+		t.Error(e)
+	}
+}
+
+// RFC 9000 (QUIC) variable-length integer sample encodings, Appendix A.1.
+// UNUM-64 is the QUIC varint: images decode per the RFC, including the
+// non-minimal 2 byte image of 37, and values encode to the RFC's minimal
+// images.
+func TestRFC9000Unum64(t *testing.T) {
+	for _, tc := range []struct {
+		b       []byte
+		v       uint64
+		minimal bool
+	}{
+		{[]byte{0xc2, 0x19, 0x7c, 0x5e, 0xff, 0x14, 0xe8, 0x8c}, 151288809941952652, true},
+		{[]byte{0x9d, 0x7f, 0x3e, 0x7d}, 494878333, true},
+		{[]byte{0x7b, 0xbd}, 15293, true},
+		{[]byte{0x25}, 37, true},
+		{[]byte{0x40, 0x25}, 37, false},
+	} {
+		v, n, e := unum.DecodeUnum64(tc.b)
+		if e != nil || v != tc.v || n != len(tc.b) {
+			t.Errorf("DecodeUnum64 - b:%x - expected:%d have:%d n:%d e:%v\n", tc.b, tc.v, v, n, e)
+		}
+		if !tc.minimal {
+			continue
+		}
+		var b0 [unum.Unum64Size]byte
+		n, e = unum.EncodeUnum64(b0[:], tc.v)
+		if e != nil || string(b0[:n]) != string(tc.b) {
+			t.Errorf("EncodeUnum64 - v:%d - expected:%x have:%x e:%v\n", tc.v, tc.b, b0[:n], e)
+		}
+	}
+	// the QUIC varint bound
+	var b0 [unum.Unum64Size]byte
+	if _, e := unum.EncodeUnum64(b0[:], 1<<62); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - v:2^62 - have:%v\n", e)
+	}
+}
@@ -0,0 +1,226 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package unumrpc implements rpc.ServerCodec and rpc.ClientCodec of net/rpc
+// over unum framing, in place of gob.
+//
+// Each request and response is a frame (see package framing) of a header
+// followed by the body, the image of a unum.Marshaler (typically generated
+// by cmd/unumgen):
+//
+//      method      UNUM-32 length, bytes   service method
+//      seq         UNUM-64                 sequence number
+//      error       UNUM-32 length, bytes   responses only: error, or empty
+//      body        [...]byte               MarshalUnum image of the body
+//
+// Arguments and replies of services must implement unum.Marshaler and
+// unum.Unmarshaler (the latter with pointer receivers). Bodies are decoded
+// from the read buffer of the Framer, which is reused for the next frame,
+// so UnmarshalUnum must not retain its argument.
+package unumrpc
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/rpc"
+	"unum"
+	"unum/framing"
+)
+
+// Errors
+var (
+	ErrorType   = fmt.Errorf("unumrpc.ErrorType")
+	ErrorHeader = fmt.Errorf("unumrpc.ErrorHeader")
+)
+
+// codec reads and writes frames of headers and bodies.
+type codec struct {
+	conn io.ReadWriteCloser
+	f    *framing.Framer
+	buf  []byte // of writes
+	body []byte // of the last frame read
+}
+
+func newCodec(conn io.ReadWriteCloser, opts framing.Options) codec {
+	return codec{conn: conn, f: framing.NewFramer(conn, opts)}
+}
+
+// Appends the UNUM-32 length and bytes of s to b.
+func appendString(b []byte, s string) ([]byte, error) {
+	if len(s) >= int(unum.Unum32ValueBound) {
+		return b, unum.ErrorMaxValue
+	}
+	b, _ = unum.AppendUnum32(b, uint32(len(s)))
+	return append(b, s...), nil
+}
+
+// Decodes a string of b.
+func decodeString(b []byte) (string, int, error) {
+	length, n, e := unum.DecodeUnum32(b)
+	if e != nil || int(length) > len(b)-n {
+		return "", 0, ErrorHeader
+	}
+	return string(b[n : n+int(length)]), n + int(length), nil
+}
+
+// Writes a frame of the header fields and body. A body that is not a
+// unum.Marshaler is written empty if empty is set.
+func (c *codec) write(method string, seq uint64, errmsg *string, body interface{}, empty bool) error {
+	b, e := appendString(c.buf[:0], method)
+	if e != nil {
+		return e
+	}
+	if b, e = unum.AppendUnum64(b, seq); e != nil {
+		return e
+	}
+	if errmsg != nil {
+		if b, e = appendString(b, *errmsg); e != nil {
+			return e
+		}
+	}
+	if m, ok := body.(unum.Marshaler); ok {
+		p, e := m.MarshalUnum()
+		if e != nil {
+			return e
+		}
+		b = append(b, p...)
+	} else if !empty {
+		return ErrorType
+	}
+	c.buf = b
+	return c.f.WriteFrame(b)
+}
+
+// Reads a frame, and returns its header fields, retaining the body.
+func (c *codec) read(response bool) (method string, seq uint64, errmsg string, e error) {
+	b, e := c.f.ReadFrame()
+	if e != nil {
+		return "", 0, "", e
+	}
+	method, n, e := decodeString(b)
+	if e != nil {
+		return "", 0, "", e
+	}
+	seq, n0, e := unum.DecodeUnum64(b[n:])
+	if e != nil {
+		return "", 0, "", ErrorHeader
+	}
+	n += n0
+	if response {
+		if errmsg, n0, e = decodeString(b[n:]); e != nil {
+			return "", 0, "", e
+		}
+		n += n0
+	}
+	c.body = b[n:]
+	return method, seq, errmsg, nil
+}
+
+// Decodes the retained body into body, unless body is nil. The body is
+// valid until the next read.
+func (c *codec) readBody(body interface{}) error {
+	b := c.body
+	c.body = nil
+	if body == nil {
+		return nil
+	}
+	u, ok := body.(unum.Unmarshaler)
+	if !ok {
+		return ErrorType
+	}
+	return u.UnmarshalUnum(b)
+}
+
+func (c *codec) Close() error {
+	return c.conn.Close()
+}
+
+type serverCodec struct {
+	codec
+}
+
+// Returns a new rpc.ServerCodec of conn, framed per opts (which must match
+// the client's).
+func NewServerCodec(conn io.ReadWriteCloser, opts framing.Options) rpc.ServerCodec {
+	return &serverCodec{newCodec(conn, opts)}
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) (e error) {
+	r.ServiceMethod, r.Seq, _, e = c.read(false)
+	return e
+}
+
+func (c *serverCodec) ReadRequestBody(body interface{}) error {
+	return c.readBody(body)
+}
+
+// Writes the response. The body of error responses (which net/rpc sends as
+// an empty struct) need not be a unum.Marshaler.
+func (c *serverCodec) WriteResponse(r *rpc.Response, body interface{}) error {
+	return c.write(r.ServiceMethod, r.Seq, &r.Error, body, r.Error != "")
+}
+
+type clientCodec struct {
+	codec
+}
+
+// Returns a new rpc.ClientCodec of conn, framed per opts (which must match
+// the server's).
+func NewClientCodec(conn io.ReadWriteCloser, opts framing.Options) rpc.ClientCodec {
+	return &clientCodec{newCodec(conn, opts)}
+}
+
+func (c *clientCodec) WriteRequest(r *rpc.Request, body interface{}) error {
+	return c.write(r.ServiceMethod, r.Seq, nil, body, false)
+}
+
+func (c *clientCodec) ReadResponseHeader(r *rpc.Response) (e error) {
+	r.ServiceMethod, r.Seq, r.Error, e = c.read(true)
+	return e
+}
+
+func (c *clientCodec) ReadResponseBody(body interface{}) error {
+	return c.readBody(body)
+}
+
+// Serves the connection conn with the default rpc.Server, framed with the
+// default framing.Options, until the client hangs up.
+func ServeConn(conn io.ReadWriteCloser) {
+	rpc.ServeCodec(NewServerCodec(conn, framing.Options{}))
+}
+
+// Returns a new rpc.Client of conn, framed with the default
+// framing.Options.
+func NewClient(conn io.ReadWriteCloser) *rpc.Client {
+	return rpc.NewClientWithCodec(NewClientCodec(conn, framing.Options{}))
+}
+
+// Returns a new rpc.Client of a connection to address on network.
+func Dial(network, address string) (*rpc.Client, error) {
+	conn, e := net.Dial(network, address)
+	if e != nil {
+		return nil, e
+	}
+	return NewClient(conn), nil
+}
@@ -0,0 +1,226 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package unumrpc_test
+
+import (
+	"errors"
+	"net"
+	"net/rpc"
+	"sync"
+	"testing"
+	"unum"
+	"unum/framing"
+	"unum/unumrpc"
+)
+
+// Args and Reply implement unum.Marshaler and unum.Unmarshaler as
+// cmd/unumgen would.
+type Args struct {
+	A, B int64
+}
+
+func (x *Args) SizeUnum() int {
+	return unum.SizeInt64(x.A) + unum.SizeInt64(x.B)
+}
+
+func (x *Args) MarshalUnum() ([]byte, error) {
+	b := make([]byte, x.SizeUnum())
+	n, e := unum.EncodeInt64(b, x.A)
+	if e != nil {
+		return nil, e
+	}
+	if _, e := unum.EncodeInt64(b[n:], x.B); e != nil {
+		return nil, e
+	}
+	return b, nil
+}
+
+func (x *Args) UnmarshalUnum(b []byte) (e error) {
+	var n, n0 int
+	if x.A, n, e = unum.DecodeInt64(b); e != nil {
+		return e
+	}
+	if x.B, n0, e = unum.DecodeInt64(b[n:]); e != nil {
+		return e
+	}
+	if n+n0 != len(b) {
+		return unum.ErrorInvalidBuffer
+	}
+	return nil
+}
+
+type Reply struct {
+	C int64
+}
+
+func (x *Reply) SizeUnum() int {
+	return unum.SizeInt64(x.C)
+}
+
+func (x *Reply) MarshalUnum() ([]byte, error) {
+	b := make([]byte, x.SizeUnum())
+	if _, e := unum.EncodeInt64(b, x.C); e != nil {
+		return nil, e
+	}
+	return b, nil
+}
+
+func (x *Reply) UnmarshalUnum(b []byte) (e error) {
+	var n int
+	if x.C, n, e = unum.DecodeInt64(b); e != nil {
+		return e
+	}
+	if n != len(b) {
+		return unum.ErrorInvalidBuffer
+	}
+	return nil
+}
+
+type Arith int
+
+var errDivide = errors.New("divide by zero")
+
+func (t *Arith) Add(args *Args, reply *Reply) error {
+	reply.C = args.A + args.B
+	return nil
+}
+
+func (t *Arith) Div(args *Args, reply *Reply) error {
+	if args.B == 0 {
+		return errDivide
+	}
+	reply.C = args.A / args.B
+	return nil
+}
+
+// returns a client of a server of Arith over net.Pipe
+func pipe(t *testing.T, opts framing.Options) *rpc.Client {
+	server := rpc.NewServer()
+	if e := server.Register(new(Arith)); e != nil {
+		t.Fatalf("error registering - e:%s\n", e.Error())
+	}
+	c0, c1 := net.Pipe()
+	go server.ServeCodec(unumrpc.NewServerCodec(c0, opts))
+	return rpc.NewClientWithCodec(unumrpc.NewClientCodec(c1, opts))
+}
+
+func TestCall(t *testing.T) {
+	for _, opts := range []framing.Options{{}, {Checksum: true}} {
+		client := pipe(t, opts)
+		var reply Reply
+		if e := client.Call("Arith.Add", &Args{7, -8}, &reply); e != nil || reply.C != -1 {
+			t.Errorf("BUG - Add - reply:%d e:%v\n", reply.C, e)
+		}
+		if e := client.Call("Arith.Div", &Args{7, 2}, &reply); e != nil || reply.C != 3 {
+			t.Errorf("BUG - Div - reply:%d e:%v\n", reply.C, e)
+		}
+		e := client.Call("Arith.Div", &Args{7, 0}, &reply)
+		if se, ok := e.(rpc.ServerError); !ok || string(se) != errDivide.Error() {
+			t.Errorf("expected ServerError - have:%v\n", e)
+		}
+		if e := client.Call("Arith.Mul", &Args{1, 2}, &reply); e == nil {
+			t.Errorf("BUG - expected error of unknown method\n")
+		}
+		// the connection is usable after errors
+		if e := client.Call("Arith.Add", &Args{1, 2}, &reply); e != nil || reply.C != 3 {
+			t.Errorf("BUG - Add - reply:%d e:%v\n", reply.C, e)
+		}
+		client.Close()
+	}
+}
+
+func TestConcurrent(t *testing.T) {
+	client := pipe(t, framing.Options{})
+	defer client.Close()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				var reply Reply
+				args := &Args{int64(g), int64(i) << 40}
+				if e := client.Call("Arith.Add", args, &reply); e != nil || reply.C != args.A+args.B {
+					t.Errorf("BUG - reply:%d e:%v\n", reply.C, e)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestErrorType(t *testing.T) {
+	client := pipe(t, framing.Options{})
+	defer client.Close()
+	var reply Reply
+	if e := client.Call("Arith.Add", struct{ A, B int64 }{1, 2}, &reply); e != unumrpc.ErrorType {
+		t.Errorf("expected ErrorType - have:%v\n", e)
+	}
+	// args out of range
+	if e := client.Call("Arith.Add", &Args{1 << 62, 0}, &reply); e != unum.ErrorMaxValue {
+		t.Errorf("expected ErrorMaxValue - have:%v\n", e)
+	}
+}
+
+func TestServeConn(t *testing.T) {
+	rpc.Register(new(Arith))
+	l, e := net.Listen("tcp", "127.0.0.1:0")
+	if e != nil {
+		t.Skipf("skipping - listen - e:%s\n", e.Error())
+	}
+	defer l.Close()
+	go func() {
+		for {
+			conn, e := l.Accept()
+			if e != nil {
+				return
+			}
+			go unumrpc.ServeConn(conn)
+		}
+	}()
+	client, e := unumrpc.Dial("tcp", l.Addr().String())
+	if e != nil {
+		t.Fatalf("error dialing - e:%s\n", e.Error())
+	}
+	defer client.Close()
+	var reply Reply
+	if e := client.Call("Arith.Add", &Args{40, 2}, &reply); e != nil || reply.C != 42 {
+		t.Errorf("BUG - reply:%d e:%v\n", reply.C, e)
+	}
+}
+
+func BenchmarkCall(b *testing.B) {
+	server := rpc.NewServer()
+	server.Register(new(Arith))
+	c0, c1 := net.Pipe()
+	go server.ServeCodec(unumrpc.NewServerCodec(c0, framing.Options{}))
+	client := rpc.NewClientWithCodec(unumrpc.NewClientCodec(c1, framing.Options{}))
+	defer client.Close()
+	var reply Reply
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		client.Call("Arith.Add", &Args{int64(i), 1}, &reply)
+	}
+}
@@ -0,0 +1,308 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package value
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"sort"
+	"unum"
+)
+
+// ----------------------------------------------------------------------------
+// Encoder
+// ----------------------------------------------------------------------------
+
+// Encoder writes Go values to an output stream.
+//
+// Go values are mapped as follows:
+//
+//      nil, nil pointer            -- Null
+//      bool                        -- Bool
+//      uint, uint8, .. uint64      -- Uint
+//      int, int8, .. int64         -- Int
+//      float32                     -- Float32
+//      float64                     -- Float64
+//      []byte                      -- Bytes
+//      string                      -- String
+//      slice, array                -- Array
+//      map with string keys        -- Map
+//      pointer                     -- the value pointed to
+//
+// Other types are not supported.
+type Encoder struct {
+	*Writer
+
+	// If set, map entries are written in ascending (bytewise) key order, so
+	// that equal values have identical images (e.g. for hashing).
+	Deterministic bool
+
+	// maximum nesting depth of encoded values
+	MaxDepth int
+}
+
+// Returns a new Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{Writer: NewWriter(w), MaxDepth: DefaultLimits.MaxDepth}
+}
+
+// Writes the image of v.
+//
+// On error returns e:
+//    ErrorUnsupportedType  -- v is (or contains) a value of unsupported type
+//    ErrorMaxDepth         -- v is nested, or chains pointers, deeper than MaxDepth
+//    unum.ErrorMaxValue    -- v is (or contains) an integer out of range
+//    <other>               -- propagated io.Writer error
+func (enc *Encoder) Encode(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		return enc.WriteNull()
+	case bool:
+		return enc.WriteBool(v)
+	case uint64:
+		return enc.WriteUint(v)
+	case int64:
+		return enc.WriteInt(v)
+	case int:
+		return enc.WriteInt(int64(v))
+	case float64:
+		return enc.WriteFloat64(v)
+	case string:
+		return enc.WriteString(v)
+	case []byte:
+		return enc.WriteBytes(v)
+	}
+	return enc.encode(reflect.ValueOf(v), 0)
+}
+
+func (enc *Encoder) encode(v reflect.Value, depth int) error {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return enc.WriteNull()
+	case reflect.Bool:
+		return enc.WriteBool(v.Bool())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return enc.WriteUint(v.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return enc.WriteInt(v.Int())
+	case reflect.Float32:
+		return enc.WriteFloat32(float32(v.Float()))
+	case reflect.Float64:
+		return enc.WriteFloat64(v.Float())
+	case reflect.String:
+		return enc.WriteString(v.String())
+	case reflect.Ptr, reflect.Interface:
+		// a (possibly cyclic) chain of indirections is bound by MaxDepth
+		for n := 0; v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface; n++ {
+			if v.IsNil() {
+				return enc.WriteNull()
+			}
+			if n >= enc.MaxDepth {
+				return ErrorMaxDepth
+			}
+			v = v.Elem()
+		}
+		return enc.encode(v, depth)
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			if v.Kind() == reflect.Slice {
+				return enc.WriteBytes(v.Bytes())
+			}
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			return enc.WriteBytes(b)
+		}
+		if depth >= enc.MaxDepth {
+			return ErrorMaxDepth
+		}
+		if e := enc.BeginArray(v.Len()); e != nil {
+			return e
+		}
+		for i := 0; i < v.Len(); i++ {
+			if e := enc.encode(v.Index(i), depth+1); e != nil {
+				return e
+			}
+		}
+		return nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return ErrorUnsupportedType
+		}
+		if depth >= enc.MaxDepth {
+			return ErrorMaxDepth
+		}
+		keys := v.MapKeys()
+		if enc.Deterministic {
+			sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		}
+		if e := enc.BeginMap(len(keys)); e != nil {
+			return e
+		}
+		for _, k := range keys {
+			if e := enc.WriteString(k.String()); e != nil {
+				return e
+			}
+			if e := enc.encode(v.MapIndex(k), depth+1); e != nil {
+				return e
+			}
+		}
+		return nil
+	}
+	return ErrorUnsupportedType
+}
+
+// Returns the image of v. See Encoder.
+func Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if e := NewEncoder(&buf).Encode(v); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+// Returns the deterministic image of v; map entries are sorted by key.
+func MarshalDeterministic(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	enc.Deterministic = true
+	if e := enc.Encode(v); e != nil {
+		return nil, e
+	}
+	return buf.Bytes(), nil
+}
+
+// ----------------------------------------------------------------------------
+// Decoder
+// ----------------------------------------------------------------------------
+
+// Decoder reads Go values from an input stream. Items are decoded as:
+//
+//      Null     -- nil
+//      Bool     -- bool
+//      Uint     -- uint64
+//      Int      -- int64
+//      Float32  -- float32
+//      Float64  -- float64
+//      Bytes    -- []byte
+//      String   -- string
+//      Array    -- []interface{}
+//      Map      -- map[string]interface{}
+//
+// Decoding is bound by the embedded Reader's Limits.
+type Decoder struct {
+	*Reader
+}
+
+// Returns a new Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{NewReader(r)}
+}
+
+// Reads the next value. See Reader.Next for errors.
+func (d *Decoder) Decode() (interface{}, error) {
+	t, e := d.Next()
+	if e != nil {
+		return nil, e
+	}
+	return d.value(t)
+}
+
+// maximum number of elements preallocated for a container of declared length
+const maxPrealloc = 1024
+
+func (d *Decoder) value(t Token) (interface{}, error) {
+	switch t.Kind {
+	case Null:
+		return nil, nil
+	case Bool:
+		return t.Bool, nil
+	case Uint:
+		return t.Uint, nil
+	case Int:
+		return t.Int, nil
+	case Float32:
+		return float32(t.Float), nil
+	case Float64:
+		return t.Float, nil
+	case Bytes:
+		return t.Bytes, nil
+	case String:
+		return string(t.Bytes), nil
+	case Array:
+		n := t.Len
+		if n < 0 || n > maxPrealloc {
+			n = maxPrealloc
+		}
+		a := make([]interface{}, 0, n)
+		for i := 0; t.Len < 0 || i < t.Len; i++ {
+			t0, e := d.Next()
+			if e != nil {
+				return nil, e
+			}
+			if t0.Kind == End {
+				break
+			}
+			v, e := d.value(t0)
+			if e != nil {
+				return nil, e
+			}
+			a = append(a, v)
+		}
+		return a, nil
+	case Map:
+		m := make(map[string]interface{})
+		for i := 0; t.Len < 0 || i < t.Len; i++ {
+			k, e := d.Next()
+			if e != nil {
+				return nil, e
+			}
+			if k.Kind == End {
+				break
+			}
+			v, e := d.Decode()
+			if e != nil {
+				return nil, e
+			}
+			m[string(k.Bytes)] = v
+		}
+		return m, nil
+	}
+	// End outside of container is rejected by the Reader
+	panic("bug - asserted unreachable")
+}
+
+// Returns the value of image b. b must contain exactly one value.
+func Unmarshal(b []byte) (interface{}, error) {
+	r := bytes.NewReader(b)
+	d := NewDecoder(r)
+	v, e := d.Decode()
+	if e != nil {
+		return nil, e
+	}
+	if r.Len() != 0 {
+		return nil, unum.ErrorInvalidBuffer
+	}
+	return v, nil
+}
@@ -0,0 +1,173 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package value_test
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"unum"
+	"unum/value"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	type ID uint32
+	var nilptr *int
+	x := 7
+	var tests = []struct {
+		in  interface{}
+		out interface{}
+	}{
+		{nil, nil},
+		{true, true},
+		{uint8(200), uint64(200)},
+		{ID(1 << 20), uint64(1 << 20)},
+		{-3, int64(-3)},
+		{int16(-300), int64(-300)},
+		{float32(0.25), float32(0.25)},
+		{math.Inf(-1), math.Inf(-1)},
+		{"héllo", "héllo"},
+		{[]byte{1, 2}, []byte{1, 2}},
+		{[2]byte{1, 2}, []byte{1, 2}},
+		{nilptr, nil},
+		{&x, int64(7)},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{[]interface{}{}, []interface{}{}},
+		{
+			map[string]interface{}{"a": []int{1, -1}, "b": map[string]bool{"c": true}, "d": nil},
+			map[string]interface{}{"a": []interface{}{int64(1), int64(-1)}, "b": map[string]interface{}{"c": true}, "d": nil},
+		},
+	}
+	for _, test := range tests {
+		b, e := value.Marshal(test.in)
+		if e != nil {
+			t.Errorf("error marshaling - v:%v - e:%s\n", test.in, e.Error())
+			continue
+		}
+		v, e := value.Unmarshal(b)
+		if e != nil {
+			t.Errorf("error unmarshaling - v:%v - e:%s\n", test.in, e.Error())
+			continue
+		}
+		if !reflect.DeepEqual(v, test.out) {
+			t.Errorf("expected:%#v have:%#v\n", test.out, v)
+		}
+	}
+}
+
+func TestMarshalDeterministic(t *testing.T) {
+	m := make(map[string]interface{})
+	for _, k := range []string{"zeta", "alpha", "mu", "beta", "omega", "pi", "rho", "chi"} {
+		m[k] = map[string]int{k + "1": 1, k + "2": 2, k + "3": 3}
+	}
+	b0, e := value.MarshalDeterministic(m)
+	if e != nil {
+		t.Fatalf("error marshaling - e:%s\n", e.Error())
+	}
+	for i := 0; i < 20; i++ {
+		b, _ := value.MarshalDeterministic(m)
+		if !bytes.Equal(b, b0) {
+			t.Fatalf("deterministic images differ\n")
+		}
+	}
+
+	// keys are in ascending order
+	r := value.NewReader(bytes.NewReader(b0))
+	r.Next()
+	var last string
+	for i := 0; i < len(m); i++ {
+		k, _ := r.Next()
+		if string(k.Bytes) <= last {
+			t.Errorf("keys out of order - %q after %q\n", k.Bytes, last)
+		}
+		last = string(k.Bytes)
+		for j := 0; j < 7; j++ { // skip value: map of 3 entries
+			r.Next()
+		}
+	}
+}
+
+func TestMarshalErrors(t *testing.T) {
+	var tests = []struct {
+		in  interface{}
+		err error
+	}{
+		{struct{}{}, value.ErrorUnsupportedType},
+		{map[int]int{1: 1}, value.ErrorUnsupportedType},
+		{[]interface{}{make(chan int)}, value.ErrorUnsupportedType},
+		{unum.Unum64ValueBound, unum.ErrorMaxValue},
+		{int64(math.MinInt64), unum.ErrorMaxValue},
+	}
+	for _, test := range tests {
+		if _, e := value.Marshal(test.in); e != test.err {
+			t.Errorf("%T - expected:%v have:%v\n", test.in, test.err, e)
+		}
+	}
+
+	// cyclic values exceed MaxDepth
+	a := make([]interface{}, 1)
+	a[0] = a
+	if _, e := value.Marshal(a); e != value.ErrorMaxDepth {
+		t.Errorf("expected ErrorMaxDepth - have:%v\n", e)
+	}
+	var p interface{}
+	p = &p
+	if _, e := value.Marshal(p); e != value.ErrorMaxDepth {
+		t.Errorf("expected ErrorMaxDepth - have:%v\n", e)
+	}
+}
+
+func TestDecodeIndefinite(t *testing.T) {
+	var buf bytes.Buffer
+	w := value.NewWriter(&buf)
+	w.BeginMap(-1)
+	w.WriteString("xs")
+	w.BeginArray(-1)
+	w.WriteUint(1)
+	w.WriteUint(2)
+	w.End()
+	w.End()
+	w.WriteNull() // second top-level value
+
+	d := value.NewDecoder(&buf)
+	v, e := d.Decode()
+	if e != nil {
+		t.Fatalf("error decoding - e:%s\n", e.Error())
+	}
+	expected := map[string]interface{}{"xs": []interface{}{uint64(1), uint64(2)}}
+	if !reflect.DeepEqual(v, expected) {
+		t.Errorf("expected:%v have:%v\n", expected, v)
+	}
+	if v, e := d.Decode(); v != nil || e != nil {
+		t.Errorf("expected nil - have:%v e:%v\n", v, e)
+	}
+	if _, e := d.Decode(); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+
+	if _, e := value.Unmarshal([]byte{0x00, 0x00}); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer for trailing bytes - have:%v\n", e)
+	}
+}
@@ -0,0 +1,267 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package value
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+	"unum"
+)
+
+// JSON transcoding
+//
+// ToJSON and FromJSON transcode one value at a time, streaming, without
+// materializing the value in memory. Map entries retain their order in
+// both directions.
+//
+//      unum                 | JSON
+//      ---------------------+------------------------------------------
+//      Null                 | null
+//      Bool                 | true, false
+//      Uint, Int            | integer number (exact)
+//      Float32, Float64     | number with a fraction or exponent
+//      Bytes                | base64 (std, padded) string
+//      String               | string
+//      Array                | array
+//      Map                  | object
+//
+// JSON integers in [-2^61, 2^62) are transcoded exactly, to Uint if not
+// negative and to Int otherwise. Other JSON numbers are transcoded to
+// Float64. JSON strings are always transcoded to String, so Bytes do not
+// round-trip. Floats that are NaN or infinite have no JSON representation.
+
+// json container
+type jsonFrame struct {
+	isMap bool
+	count int // items (keys and values for maps); -1 if indefinite
+	n     int // items started
+}
+
+// Transcodes the next value of r to JSON, written to w.
+//
+// On error returns e:
+//    unum.ErrorInvalidBuffer -- next token of r ends its container, not a value
+//    ErrorUnsupportedType    -- value contains a NaN or infinite float
+//    <other>                 -- see Reader.Next, and propagated io.Writer error
+func ToJSON(w io.Writer, r *Reader) error {
+	bw := bufio.NewWriter(w)
+	var stack []jsonFrame
+	for {
+		t, e := r.Next()
+		if e != nil {
+			return e
+		}
+
+		if t.Kind == End {
+			if len(stack) == 0 {
+				return unum.ErrorInvalidBuffer
+			}
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			closeJSON(bw, top.isMap)
+		} else {
+			if len(stack) > 0 {
+				top := &stack[len(stack)-1]
+				switch {
+				case top.isMap && top.n%2 == 1:
+					bw.WriteByte(':')
+				case top.n > 0:
+					bw.WriteByte(',')
+				}
+				top.n++
+			}
+			switch t.Kind {
+			case Null:
+				bw.WriteString("null")
+			case Bool:
+				bw.WriteString(strconv.FormatBool(t.Bool))
+			case Uint:
+				bw.WriteString(strconv.FormatUint(t.Uint, 10))
+			case Int:
+				bw.WriteString(strconv.FormatInt(t.Int, 10))
+			case Float32, Float64:
+				if math.IsNaN(t.Float) || math.IsInf(t.Float, 0) {
+					return ErrorUnsupportedType
+				}
+				bits := 64
+				if t.Kind == Float32 {
+					bits = 32
+				}
+				s := strconv.FormatFloat(t.Float, 'g', -1, bits)
+				if !strings.ContainsAny(s, ".e") {
+					s += ".0"
+				}
+				bw.WriteString(s)
+			case Bytes:
+				bw.WriteByte('"')
+				enc := base64.NewEncoder(base64.StdEncoding, bw)
+				enc.Write(t.Bytes)
+				enc.Close()
+				bw.WriteByte('"')
+			case String:
+				writeJSONString(bw, t.Bytes)
+			case Array, Map:
+				f := jsonFrame{isMap: t.Kind == Map, count: t.Len}
+				if f.isMap {
+					bw.WriteByte('{')
+					f.count *= 2
+				} else {
+					bw.WriteByte('[')
+				}
+				if t.Len == 0 {
+					closeJSON(bw, f.isMap)
+				} else {
+					stack = append(stack, f)
+					continue
+				}
+			}
+		}
+
+		// close completed definite length containers
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.count < 0 || top.n < top.count {
+				break
+			}
+			stack = stack[:len(stack)-1]
+			closeJSON(bw, top.isMap)
+		}
+		if len(stack) == 0 {
+			return bw.Flush()
+		}
+	}
+}
+
+func closeJSON(bw *bufio.Writer, isMap bool) {
+	if isMap {
+		bw.WriteByte('}')
+	} else {
+		bw.WriteByte(']')
+	}
+}
+
+const hex = "0123456789abcdef"
+
+func writeJSONString(bw *bufio.Writer, s []byte) {
+	bw.WriteByte('"')
+	for len(s) > 0 {
+		c := s[0]
+		if c < utf8.RuneSelf {
+			switch {
+			case c == '"' || c == '\\':
+				bw.WriteByte('\\')
+				bw.WriteByte(c)
+			case c == '\n':
+				bw.WriteString(`\n`)
+			case c == '\r':
+				bw.WriteString(`\r`)
+			case c == '\t':
+				bw.WriteString(`\t`)
+			case c < 0x20:
+				bw.WriteString(`\u00`)
+				bw.WriteByte(hex[c>>4])
+				bw.WriteByte(hex[c&0xf])
+			default:
+				bw.WriteByte(c)
+			}
+			s = s[1:]
+			continue
+		}
+		r, n := utf8.DecodeRune(s)
+		if r == utf8.RuneError && n == 1 {
+			bw.WriteString(`\ufffd`)
+		} else {
+			bw.Write(s[:n])
+		}
+		s = s[n:]
+	}
+	bw.WriteByte('"')
+}
+
+// Transcodes the next JSON value of dec to w. Arrays and objects are
+// written as indefinite length containers. dec is set to UseNumber.
+//
+// On error returns e:
+//    <other>  -- propagated json.Decoder and Writer errors
+func FromJSON(w *Writer, dec *json.Decoder) error {
+	dec.UseNumber()
+	depth := 0
+	for {
+		t, e := dec.Token()
+		if e != nil {
+			return e
+		}
+		switch t := t.(type) {
+		case json.Delim:
+			switch t {
+			case '[':
+				e = w.BeginArray(-1)
+				depth++
+			case '{':
+				e = w.BeginMap(-1)
+				depth++
+			default:
+				e = w.End()
+				depth--
+			}
+		case nil:
+			e = w.WriteNull()
+		case bool:
+			e = w.WriteBool(t)
+		case string:
+			e = w.WriteString(t)
+		case json.Number:
+			e = writeJSONNumber(w, string(t))
+		}
+		if e != nil {
+			return e
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func writeJSONNumber(w *Writer, s string) error {
+	if !strings.ContainsAny(s, ".eE") {
+		if s[0] == '-' {
+			if v, e := strconv.ParseInt(s, 10, 64); e == nil && v >= -unum.Int64ValueBound {
+				return w.WriteInt(v)
+			}
+		} else if v, e := strconv.ParseUint(s, 10, 64); e == nil && v < unum.Unum64ValueBound {
+			return w.WriteUint(v)
+		}
+	}
+	f, e := strconv.ParseFloat(s, 64)
+	if e != nil {
+		return e
+	}
+	return w.WriteFloat64(f)
+}
@@ -0,0 +1,158 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package value_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"unum"
+	"unum/value"
+)
+
+func transcode(t *testing.T, in string) string {
+	var ubuf bytes.Buffer
+	if e := value.FromJSON(value.NewWriter(&ubuf), json.NewDecoder(strings.NewReader(in))); e != nil {
+		t.Fatalf("error transcoding from JSON - in:%s - e:%s\n", in, e.Error())
+	}
+	var jbuf bytes.Buffer
+	if e := value.ToJSON(&jbuf, value.NewReader(&ubuf)); e != nil {
+		t.Fatalf("error transcoding to JSON - in:%s - e:%s\n", in, e.Error())
+	}
+	if ubuf.Len() != 0 {
+		t.Errorf("trailing bytes after transcoding - in:%s\n", in)
+	}
+	return jbuf.String()
+}
+
+func TestJSONRoundTrip(t *testing.T) {
+	var tests = []string{
+		`null`,
+		`true`,
+		`0`,
+		`4611686018427387903`,  // 2^62 - 1
+		`9007199254740993`,     // 2^53 + 1: not exact as float64
+		`-2305843009213693952`, // -2^61
+		`1.5`,
+		`-0.001`,
+		`1e+100`,
+		`1.0`,
+		`"a \"quoted\"\nstring\u0001 ☃"`,
+		`[]`,
+		`{}`,
+		`[1,[2,[3,[]]],{"a":null}]`,
+		`{"zeta":1,"alpha":2,"mu":{"y":true,"x":false},"beta":[]}`,
+	}
+	for _, in := range tests {
+		if out := transcode(t, in); out != in {
+			t.Errorf("expected:%s have:%s\n", in, out)
+		}
+	}
+
+	// out of exact range integers are floats
+	if out := transcode(t, `4611686018427387904`); out != `4.611686018427388e+18` {
+		t.Errorf("expected float - have:%s\n", out)
+	}
+	if out := transcode(t, `-2305843009213693953`); out != `-2.305843009213694e+18` {
+		t.Errorf("expected float - have:%s\n", out)
+	}
+}
+
+func TestJSONIntegersExact(t *testing.T) {
+	var ubuf bytes.Buffer
+	in := `[4611686018427387903, -2305843009213693952, 12.0]`
+	if e := value.FromJSON(value.NewWriter(&ubuf), json.NewDecoder(strings.NewReader(in))); e != nil {
+		t.Fatalf("error transcoding from JSON - e:%s\n", e.Error())
+	}
+	v, e := value.Unmarshal(ubuf.Bytes())
+	if e != nil {
+		t.Fatalf("error decoding - e:%s\n", e.Error())
+	}
+	a := v.([]interface{})
+	if a[0] != uint64(unum.Unum64ValueBound-1) || a[1] != -unum.Int64ValueBound || a[2] != float64(12) {
+		t.Errorf("unexpected values - have:%#v\n", a)
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	var buf bytes.Buffer
+	enc := value.NewEncoder(&buf)
+	enc.Encode([]interface{}{[]byte("hello"), float32(0.5), map[string]interface{}{}})
+	enc.Encode(uint64(1))
+	enc.BeginMap(-1)
+	enc.WriteString("k")
+	enc.WriteBytes(nil)
+	enc.End()
+
+	r := value.NewReader(&buf)
+	var out []string
+	for {
+		var jbuf bytes.Buffer
+		e := value.ToJSON(&jbuf, r)
+		if e == unum.ErrorBufferEOF {
+			break
+		} else if e != nil {
+			t.Fatalf("error transcoding - e:%s\n", e.Error())
+		}
+		out = append(out, jbuf.String())
+	}
+	expected := []string{`["aGVsbG8=",0.5,{}]`, `1`, `{"k":""}`}
+	if strings.Join(out, " ") != strings.Join(expected, " ") {
+		t.Errorf("expected:%v have:%v\n", expected, out)
+	}
+
+	// output is valid JSON
+	for _, s := range out {
+		if !json.Valid([]byte(s)) {
+			t.Errorf("invalid JSON - %s\n", s)
+		}
+	}
+
+	buf.Reset()
+	enc.Encode(math.NaN())
+	if e := value.ToJSON(&bytes.Buffer{}, value.NewReader(&buf)); e != value.ErrorUnsupportedType {
+		t.Errorf("expected ErrorUnsupportedType - have:%v\n", e)
+	}
+
+	// r positioned at the end of an enclosing container
+	buf.Reset()
+	enc.BeginArray(-1)
+	enc.End()
+	r = value.NewReader(&buf)
+	r.Next()
+	if e := value.ToJSON(&bytes.Buffer{}, r); e != unum.ErrorInvalidBuffer {
+		t.Errorf("expected ErrorInvalidBuffer - have:%v\n", e)
+	}
+}
+
+func TestFromJSONErrors(t *testing.T) {
+	for _, in := range []string{`[1,`, `{"a"}`, `]`, ``} {
+		var ubuf bytes.Buffer
+		if e := value.FromJSON(value.NewWriter(&ubuf), json.NewDecoder(strings.NewReader(in))); e == nil {
+			t.Errorf("expected error - in:%q\n", in)
+		}
+	}
+}
@@ -0,0 +1,386 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package value
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"unum"
+)
+
+// ----------------------------------------------------------------------------
+// Writer
+// ----------------------------------------------------------------------------
+
+// Writer writes a stream of tokens to an io.Writer. The Writer does not
+// verify that the token sequence is well formed; e.g. that a definite length
+// Array is followed by exactly the declared number of items.
+type Writer struct {
+	w   io.Writer
+	buf [1 + unum.Unum64Size]byte
+}
+
+// Returns a new Writer writing to w. Each token is written with one or two
+// calls to w.Write; wrap w in a bufio.Writer if that is a concern.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// writes the type code, and the UNUM-64 argument if arg is true.
+func (w *Writer) head(code byte, v uint64, arg bool) error {
+	w.buf[0] = code
+	n := 1
+	if arg {
+		n0, e := unum.EncodeUnum64(w.buf[1:], v)
+		if e != nil {
+			return e
+		}
+		n += n0
+	}
+	_, e := w.w.Write(w.buf[:n])
+	return e
+}
+
+func (w *Writer) WriteNull() error {
+	return w.head(codeNull, 0, false)
+}
+
+func (w *Writer) WriteBool(v bool) error {
+	if v {
+		return w.head(codeTrue, 0, false)
+	}
+	return w.head(codeFalse, 0, false)
+}
+
+// On error returns unum.ErrorMaxValue if v >= 2^62.
+func (w *Writer) WriteUint(v uint64) error {
+	return w.head(codeUint, v, true)
+}
+
+// On error returns unum.ErrorMaxValue if v < -2^61 or v >= 2^61.
+func (w *Writer) WriteInt(v int64) error {
+	if v < -unum.Int64ValueBound || v >= unum.Int64ValueBound {
+		return unum.ErrorMaxValue
+	}
+	return w.head(codeInt, unum.Zigzag64(v), true)
+}
+
+func (w *Writer) WriteFloat32(v float32) error {
+	w.buf[0] = codeFloat32
+	binary.BigEndian.PutUint32(w.buf[1:], math.Float32bits(v))
+	_, e := w.w.Write(w.buf[:5])
+	return e
+}
+
+func (w *Writer) WriteFloat64(v float64) error {
+	w.buf[0] = codeFloat64
+	binary.BigEndian.PutUint64(w.buf[1:], math.Float64bits(v))
+	_, e := w.w.Write(w.buf[:9])
+	return e
+}
+
+func (w *Writer) WriteBytes(p []byte) error {
+	if e := w.head(codeBytes, uint64(len(p)), true); e != nil {
+		return e
+	}
+	_, e := w.w.Write(p)
+	return e
+}
+
+func (w *Writer) WriteString(s string) error {
+	if e := w.head(codeString, uint64(len(s)), true); e != nil {
+		return e
+	}
+	_, e := io.WriteString(w.w, s)
+	return e
+}
+
+// Begins an array of n items. If n < 0 the array is of indefinite length
+// and must be terminated by a call to End.
+func (w *Writer) BeginArray(n int) error {
+	if n < 0 {
+		return w.head(codeArrayStream, 0, false)
+	}
+	return w.head(codeArray, uint64(n), true)
+}
+
+// Begins a map of n (key, value) pairs. Keys must be written with
+// WriteString. If n < 0 the map is of indefinite length and must be
+// terminated by a call to End.
+func (w *Writer) BeginMap(n int) error {
+	if n < 0 {
+		return w.head(codeMapStream, 0, false)
+	}
+	return w.head(codeMap, uint64(n), true)
+}
+
+// Ends an indefinite length array or map.
+func (w *Writer) End() error {
+	return w.head(codeEnd, 0, false)
+}
+
+// Writes token t.
+func (w *Writer) WriteToken(t Token) error {
+	switch t.Kind {
+	case Null:
+		return w.WriteNull()
+	case Bool:
+		return w.WriteBool(t.Bool)
+	case Uint:
+		return w.WriteUint(t.Uint)
+	case Int:
+		return w.WriteInt(t.Int)
+	case Float32:
+		return w.WriteFloat32(float32(t.Float))
+	case Float64:
+		return w.WriteFloat64(t.Float)
+	case Bytes:
+		return w.WriteBytes(t.Bytes)
+	case String:
+		if e := w.head(codeString, uint64(len(t.Bytes)), true); e != nil {
+			return e
+		}
+		_, e := w.w.Write(t.Bytes)
+		return e
+	case Array:
+		return w.BeginArray(t.Len)
+	case Map:
+		return w.BeginMap(t.Len)
+	case End:
+		return w.End()
+	}
+	return ErrorUnsupportedType
+}
+
+// ----------------------------------------------------------------------------
+// Reader
+// ----------------------------------------------------------------------------
+
+type byteReader interface {
+	io.Reader
+	io.ByteReader
+}
+
+// open container
+type container struct {
+	isMap bool
+	count int // items (keys and values for maps) in container; -1 if indefinite
+	n     int // items read
+}
+
+// Reader reads a stream of tokens from an io.Reader, verifying that the
+// token sequence is well formed and within Limits.
+type Reader struct {
+	Limits Limits
+
+	r     byteReader
+	stack []container
+	buf   [unum.Unum64Size]byte
+}
+
+// Returns a new Reader reading from r, with DefaultLimits. If r does not
+// implement io.ByteReader it is buffered, and the Reader may read beyond
+// the last token it returns.
+func NewReader(r io.Reader) *Reader {
+	br, ok := r.(byteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	return &Reader{Limits: DefaultLimits, r: br}
+}
+
+// Returns the nesting depth of the next token.
+func (r *Reader) Depth() int {
+	return len(r.stack)
+}
+
+// Returns true if the next token is a map key.
+func (r *Reader) InKey() bool {
+	if len(r.stack) == 0 {
+		return false
+	}
+	top := &r.stack[len(r.stack)-1]
+	return top.isMap && top.n%2 == 0
+}
+
+// Reads the next token. The Bytes of a Bytes or String token are owned by
+// the caller.
+//
+// On error returns (Token{}, e) where e is:
+//    unum.ErrorBufferEOF     -- end of input between top-level items
+//    unum.ErrorInvalidBuffer -- non-conformant or truncated input
+//    ErrorMapKey             -- map key is not a String
+//    ErrorMaxDepth           -- Limits.MaxDepth exceeded
+//    ErrorMaxSize            -- Limits.MaxSize exceeded
+//    <other>                 -- propagated io.Reader error
+func (r *Reader) Next() (t Token, e error) {
+	code, e := r.r.ReadByte()
+	if e != nil {
+		return t, r.eof(e, len(r.stack) == 0)
+	}
+
+	var top *container
+	if len(r.stack) > 0 {
+		top = &r.stack[len(r.stack)-1]
+	}
+	if code == codeEnd {
+		if top == nil || top.count >= 0 || (top.isMap && top.n%2 == 1) {
+			return t, unum.ErrorInvalidBuffer
+		}
+		r.stack = r.stack[:len(r.stack)-1]
+		r.done()
+		return Token{Kind: End}, nil
+	}
+	if top != nil && top.isMap && top.n%2 == 0 && code != codeString {
+		return t, ErrorMapKey
+	}
+
+	switch code {
+	case codeNull:
+		t.Kind = Null
+	case codeFalse, codeTrue:
+		t.Kind, t.Bool = Bool, code == codeTrue
+	case codeUint:
+		t.Kind = Uint
+		if t.Uint, e = r.arg(); e != nil {
+			return Token{}, e
+		}
+	case codeInt:
+		t.Kind = Int
+		u, e := r.arg()
+		if e != nil {
+			return Token{}, e
+		}
+		t.Int = unum.Unzigzag64(u)
+	case codeFloat32:
+		if _, e := io.ReadFull(r.r, r.buf[:4]); e != nil {
+			return t, r.eof(e, false)
+		}
+		t.Kind = Float32
+		t.Float = float64(math.Float32frombits(binary.BigEndian.Uint32(r.buf[:])))
+	case codeFloat64:
+		if _, e := io.ReadFull(r.r, r.buf[:8]); e != nil {
+			return t, r.eof(e, false)
+		}
+		t.Kind = Float64
+		t.Float = math.Float64frombits(binary.BigEndian.Uint64(r.buf[:]))
+	case codeBytes, codeString:
+		t.Kind = Bytes
+		if code == codeString {
+			t.Kind = String
+		}
+		l, e := r.size()
+		if e != nil {
+			return Token{}, e
+		}
+		t.Bytes = make([]byte, l)
+		if _, e := io.ReadFull(r.r, t.Bytes); e != nil {
+			return Token{}, r.eof(e, false)
+		}
+	case codeArray, codeMap, codeArrayStream, codeMapStream:
+		c := container{isMap: code == codeMap || code == codeMapStream, count: -1}
+		t.Kind, t.Len = Array, -1
+		if c.isMap {
+			t.Kind = Map
+		}
+		if code == codeArray || code == codeMap {
+			l, e := r.size()
+			if e != nil {
+				return Token{}, e
+			}
+			t.Len, c.count = l, l
+			if c.isMap {
+				c.count *= 2
+			}
+		}
+		if len(r.stack) >= r.Limits.MaxDepth {
+			return Token{}, ErrorMaxDepth
+		}
+		if c.count == 0 {
+			r.done()
+		} else {
+			r.stack = append(r.stack, c)
+		}
+		return t, nil
+	default:
+		return t, unum.ErrorInvalidBuffer
+	}
+	r.done()
+	return t, nil
+}
+
+// marks the completion of an item in the innermost open container, closing
+// definite length containers as they are completed.
+func (r *Reader) done() {
+	for len(r.stack) > 0 {
+		top := &r.stack[len(r.stack)-1]
+		top.n++
+		if top.count < 0 || top.n < top.count {
+			return
+		}
+		r.stack = r.stack[:len(r.stack)-1]
+	}
+}
+
+// reads UNUM-64 argument
+func (r *Reader) arg() (uint64, error) {
+	b0, e := r.r.ReadByte()
+	if e != nil {
+		return 0, r.eof(e, false)
+	}
+	r.buf[0] = b0
+	if vlen := 1 << (b0 >> 6); vlen > 1 {
+		if _, e := io.ReadFull(r.r, r.buf[1:vlen]); e != nil {
+			return 0, r.eof(e, false)
+		}
+	}
+	v, _, e := unum.DecodeUnum64(r.buf[:])
+	return v, e
+}
+
+// reads UNUM-64 length argument, bound by Limits.MaxSize
+func (r *Reader) size() (int, error) {
+	l, e := r.arg()
+	if e != nil {
+		return 0, e
+	}
+	if l > uint64(r.Limits.MaxSize) {
+		return 0, ErrorMaxSize
+	}
+	return int(l), nil
+}
+
+// maps io.EOF to unum.ErrorBufferEOF at item boundaries, and to
+// unum.ErrorInvalidBuffer otherwise.
+func (r *Reader) eof(e error, boundary bool) error {
+	switch {
+	case e == io.EOF && boundary:
+		return unum.ErrorBufferEOF
+	case e == io.EOF || e == io.ErrUnexpectedEOF:
+		return unum.ErrorInvalidBuffer
+	}
+	return e
+}
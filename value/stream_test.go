@@ -0,0 +1,151 @@
+// friend!
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+package value_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"unum"
+	"unum/value"
+)
+
+func writeTokens(t *testing.T, tokens []value.Token) []byte {
+	var buf bytes.Buffer
+	w := value.NewWriter(&buf)
+	for _, tok := range tokens {
+		if e := w.WriteToken(tok); e != nil {
+			t.Fatalf("error writing token - t:%v - e:%s\n", tok, e.Error())
+		}
+	}
+	return buf.Bytes()
+}
+
+func TestTokenRoundTrip(t *testing.T) {
+	tokens := []value.Token{
+		{Kind: value.Null},
+		{Kind: value.Bool, Bool: true},
+		{Kind: value.Uint, Uint: unum.Unum64ValueBound - 1},
+		{Kind: value.Int, Int: -unum.Int64ValueBound},
+		{Kind: value.Float32, Float: 1.5},
+		{Kind: value.Float64, Float: -0.1},
+		{Kind: value.Array, Len: 2},
+		{Kind: value.Bytes, Bytes: []byte{0, 1, 2}},
+		{Kind: value.Map, Len: -1},
+		{Kind: value.String, Bytes: []byte("k")},
+		{Kind: value.Array, Len: -1},
+		{Kind: value.Array, Len: 0},
+		{Kind: value.End},
+		{Kind: value.End},
+		{Kind: value.Map, Len: 1},
+		{Kind: value.String, Bytes: []byte("")},
+		{Kind: value.Bool, Bool: false},
+	}
+	depths := []int{0, 0, 0, 0, 0, 0, 0, 1, 1, 2, 2, 3, 3, 2, 0, 1, 1}
+
+	r := value.NewReader(bytes.NewReader(writeTokens(t, tokens)))
+	for i, expected := range tokens {
+		if d := r.Depth(); d != depths[i] {
+			t.Errorf("unexpected depth - token:%d - expected:%d have:%d\n", i, depths[i], d)
+		}
+		tok, e := r.Next()
+		if e != nil {
+			t.Fatalf("error reading token %d - e:%s\n", i, e.Error())
+		}
+		if len(expected.Bytes) == 0 && len(tok.Bytes) == 0 {
+			tok.Bytes = expected.Bytes
+		}
+		if !reflect.DeepEqual(tok, expected) {
+			t.Errorf("token %d - expected:%v have:%v\n", i, expected, tok)
+		}
+	}
+	if _, e := r.Next(); e != unum.ErrorBufferEOF {
+		t.Errorf("expected ErrorBufferEOF - have:%v\n", e)
+	}
+}
+
+func TestReaderErrors(t *testing.T) {
+	var tests = []struct {
+		name  string
+		image []byte
+		err   error
+	}{
+		{"end at top level", []byte{0x0d}, unum.ErrorInvalidBuffer},
+		{"end in definite array", []byte{0x09, 0x01, 0x0d}, unum.ErrorInvalidBuffer},
+		{"end after map key", []byte{0x0c, 0x08, 0x00, 0x0d}, unum.ErrorInvalidBuffer},
+		{"non-string map key", []byte{0x0a, 0x01, 0x03, 0x00, 0x00}, value.ErrorMapKey},
+		{"unknown code", []byte{0x7f}, unum.ErrorInvalidBuffer},
+		{"truncated uint", []byte{0x03, 0x80, 0x00}, unum.ErrorInvalidBuffer},
+		{"truncated float", []byte{0x06, 0x00}, unum.ErrorInvalidBuffer},
+		{"truncated string", []byte{0x08, 0x05, 'a'}, unum.ErrorInvalidBuffer},
+		{"truncated array", []byte{0x09, 0x02, 0x00}, unum.ErrorInvalidBuffer},
+		{"missing argument", []byte{0x08}, unum.ErrorInvalidBuffer},
+	}
+	for _, test := range tests {
+		r := value.NewReader(bytes.NewReader(test.image))
+		var e error
+		for e == nil {
+			_, e = r.Next()
+		}
+		if e != test.err {
+			t.Errorf("%s - expected:%v have:%v\n", test.name, test.err, e)
+		}
+	}
+}
+
+func TestReaderLimits(t *testing.T) {
+	var buf bytes.Buffer
+	w := value.NewWriter(&buf)
+	for i := 0; i < 5; i++ {
+		w.BeginArray(1)
+	}
+	w.WriteString("0123456789")
+
+	r := value.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Limits.MaxDepth = 4
+	var e error
+	for e == nil {
+		_, e = r.Next()
+	}
+	if e != value.ErrorMaxDepth {
+		t.Errorf("expected ErrorMaxDepth - have:%v\n", e)
+	}
+
+	r = value.NewReader(bytes.NewReader(buf.Bytes()))
+	r.Limits.MaxSize = 9
+	for e = nil; e == nil; {
+		_, e = r.Next()
+	}
+	if e != value.ErrorMaxSize {
+		t.Errorf("expected ErrorMaxSize - have:%v\n", e)
+	}
+
+	// declared container sizes are bound as well
+	buf.Reset()
+	w.BeginMap(1 << 30)
+	r = value.NewReader(bytes.NewReader(buf.Bytes()))
+	if _, e := r.Next(); e != value.ErrorMaxSize {
+		t.Errorf("expected ErrorMaxSize - have:%v\n", e)
+	}
+}
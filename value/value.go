@@ -0,0 +1,151 @@
+// friend
+
+// The MIT License (MIT)
+//
+// Copyright (c) 2016 Joubin Muhammad Houshyar
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy of
+// this software and associated documentation files (the "Software"), to deal in
+// the Software without restriction, including without limitation the rights to
+// use, copy, modify, merge, publish, distribute, sublicense, and/or sell copies of
+// the Software, and to permit persons to whom the Software is furnished to do so,
+// subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in all
+// copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY, FITNESS
+// FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE AUTHORS OR
+// COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER LIABILITY, WHETHER
+// IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM, OUT OF OR IN
+// CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE SOFTWARE.
+
+// package value implements a self-describing binary format for schemaless
+// values (null, bool, integers, floats, bytes, strings, arrays and maps),
+// in the spirit of CBOR, built on the unum encodings.
+//
+// Every item begins with a type code, encoded as UNUM-16 (all currently
+// defined codes are single byte images), followed by an argument:
+//
+//      code | kind         | argument
+//      -----+--------------+---------------------------------------------
+//      0x00 | Null         | -
+//      0x01 | Bool (false) | -
+//      0x02 | Bool (true)  | -
+//      0x03 | Uint         | UNUM-64 value
+//      0x04 | Int          | zigzag UNUM-64 value
+//      0x05 | Float32      | 4 bytes, big-endian IEEE 754
+//      0x06 | Float64      | 8 bytes, big-endian IEEE 754
+//      0x07 | Bytes        | UNUM-64 length, followed by length bytes
+//      0x08 | String       | UNUM-64 length, followed by length bytes (UTF-8)
+//      0x09 | Array        | UNUM-64 count, followed by count items
+//      0x0a | Map          | UNUM-64 count, followed by count (key, value) items
+//      0x0b | Array        | indefinite length: items, followed by End
+//      0x0c | Map          | indefinite length: (key, value) items, followed by End
+//      0x0d | End          | -
+//
+// Map keys are String items.
+//
+// Writer and Reader provide streaming token level access to the format.
+// Encoder and Decoder map Go values to and from the format.
+package value
+
+import (
+	"fmt"
+)
+
+// Kind is the kind of a Token.
+type Kind uint8
+
+const (
+	Null Kind = iota
+	Bool
+	Uint
+	Int
+	Float32
+	Float64
+	Bytes
+	String
+	Array
+	Map
+	End
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Null:
+		return "null"
+	case Bool:
+		return "bool"
+	case Uint:
+		return "uint"
+	case Int:
+		return "int"
+	case Float32:
+		return "float32"
+	case Float64:
+		return "float64"
+	case Bytes:
+		return "bytes"
+	case String:
+		return "string"
+	case Array:
+		return "array"
+	case Map:
+		return "map"
+	case End:
+		return "end"
+	}
+	return fmt.Sprintf("Kind(%d)", uint8(k))
+}
+
+// type codes
+const (
+	codeNull        = 0x00
+	codeFalse       = 0x01
+	codeTrue        = 0x02
+	codeUint        = 0x03
+	codeInt         = 0x04
+	codeFloat32     = 0x05
+	codeFloat64     = 0x06
+	codeBytes       = 0x07
+	codeString      = 0x08
+	codeArray       = 0x09
+	codeMap         = 0x0a
+	codeArrayStream = 0x0b
+	codeMapStream   = 0x0c
+	codeEnd         = 0x0d
+)
+
+// Token is a single item of the format. For Array and Map tokens, Len is
+// the number of items (or pairs) that follow, or -1 if the container is of
+// indefinite length and terminated by an End token.
+type Token struct {
+	Kind  Kind
+	Bool  bool    // Bool
+	Uint  uint64  // Uint
+	Int   int64   // Int
+	Float float64 // Float32, Float64
+	Bytes []byte  // Bytes, String
+	Len   int     // Array, Map
+}
+
+// Limits bound the resources a Reader will commit to decoding its input.
+type Limits struct {
+	MaxDepth int // maximum nesting depth of arrays and maps
+	MaxSize  int // maximum length of bytes and strings, and item count of arrays and maps
+}
+
+var DefaultLimits = Limits{
+	MaxDepth: 64,
+	MaxSize:  1 << 24,
+}
+
+// Errors
+var (
+	ErrorUnsupportedType = fmt.Errorf("value.ErrorUnsupportedType")
+	ErrorMapKey          = fmt.Errorf("value.ErrorMapKey")
+	ErrorMaxDepth        = fmt.Errorf("value.ErrorMaxDepth")
+	ErrorMaxSize         = fmt.Errorf("value.ErrorMaxSize")
+)